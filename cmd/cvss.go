@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+
+	prompt "github.com/c-bata/go-prompt"
+	"github.com/parsiya/borrowedtime/cvss"
+	"github.com/starkriedesel/prompter"
+)
+
+// CVSS command.
+
+// CVSSCmd returns the cvss command.
+func CVSSCmd() prompter.Command {
+	cvssCmd := prompter.Command{
+		Name:        "cvss",
+		Description: "print the scores and severity of a CVSS v3.1 or v4.0 vector",
+		Executor:    cvssExecutor,
+	}
+	cvssCmd.AddArguments(prompter.Argument{
+		Name:              " ",
+		Description:       "vector string",
+		ArgumentCompleter: cvssCompleter,
+	})
+	return cvssCmd
+}
+
+// cvssCompleter shows the prefixes of the supported versions.
+func cvssCompleter(_ string, _ []string) []prompt.Suggest {
+	return []prompt.Suggest{
+		{Text: "CVSS:3.1/", Description: "CVSS v3.1"},
+		{Text: "CVSS:4.0/", Description: "CVSS v4.0"},
+	}
+}
+
+// cvssExecutor prints the scores of a vector.
+func cvssExecutor(args prompter.CmdArgs) error {
+	vector, err := args.GetFirstValue("_")
+	if err != nil {
+		return fmt.Errorf("cmd.cvssExecutor: please provide a vector")
+	}
+	v, err := cvss.Parse(vector)
+	if err != nil {
+		return err
+	}
+	s := v.Scores()
+	temporal, score := "temporal", fmt.Sprintf("%.1f", v.Score())
+	if v.Version == cvss.Version40 {
+		temporal = "threat"
+		score += " (" + v.Nomenclature() + ")"
+	}
+	fmt.Println(Table([][]string{
+		{"vector", v.String()},
+		{"base", fmt.Sprintf("%.1f", s.Base)},
+		{temporal, fmt.Sprintf("%.1f", s.Temporal)},
+		{"environmental", fmt.Sprintf("%.1f", s.Environmental)},
+		{"score", score},
+		{"severity", cvss.Severity(v.Score())},
+	}, false))
+	return nil
+}
@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"fmt"
+
+	prompt "github.com/c-bata/go-prompt"
+	"github.com/parsiya/borrowedtime/project"
+	"github.com/starkriedesel/prompter"
+)
+
+// Evidence command.
+
+// EvidenceCmd returns the evidence command.
+func EvidenceCmd() prompter.Command {
+	addEvidenceCmd := prompter.Command{
+		Name:        "add",
+		Description: "copy a file to an evidence directory and record its hash in @evidence.jsonl",
+		Executor:    addEvidenceExecutor,
+	}
+	addEvidenceCmd.AddArguments(
+		prompter.Argument{
+			Name:              "-dir",
+			Description:       "(optional) evidence directory, defaults to @pix for images and @clientFiles for other files",
+			ArgumentCompleter: evidenceCompleter,
+		},
+		prompter.Argument{
+			Name:        "-finding",
+			Description: "(optional) finding ID to attach the evidence to",
+		},
+		prompter.Argument{
+			Name:        "-by",
+			Description: "(optional) who captured the evidence, defaults to yourname in the config",
+		},
+		prompter.Argument{
+			Name:        "-note",
+			Description: "(optional) note in the manifest, use \" for spaces",
+		},
+		prompter.Argument{
+			Name:              " ",
+			Description:       "project name and file - use \" for paths with spaces",
+			ArgumentCompleter: openProjectCompleter,
+		},
+	)
+
+	attachEvidenceCmd := prompter.Command{
+		Name:        "attach",
+		Description: "attach evidence in the manifest to a finding",
+		Executor:    attachEvidenceExecutor,
+	}
+	attachEvidenceCmd.AddArguments(
+		prompter.Argument{
+			Name:        "-by",
+			Description: "(optional) who attached the evidence, defaults to yourname in the config",
+		},
+		prompter.Argument{
+			Name:              " ",
+			Description:       "project name, evidence path and finding ID",
+			ArgumentCompleter: openProjectCompleter,
+		},
+	)
+
+	logEvidenceCmd := prompter.Command{
+		Name:        "log",
+		Description: "print the evidence manifest",
+		Executor:    logEvidenceExecutor,
+	}
+	logEvidenceCmd.AddArguments(prompter.Argument{
+		Name:              " ",
+		Description:       "project name",
+		ArgumentCompleter: openProjectCompleter,
+	})
+
+	verifyEvidenceCmd := prompter.Command{
+		Name:        "verify",
+		Description: "check the manifest, hash the evidence and print changed, missing and untracked files",
+		Executor:    verifyEvidenceExecutor,
+	}
+	verifyEvidenceCmd.AddArguments(
+		switchArgument("-all", "(optional) also print unchanged files"),
+		prompter.Argument{
+			Name:              " ",
+			Description:       "project name",
+			ArgumentCompleter: openProjectCompleter,
+		},
+	)
+
+	evidenceCmd := prompter.Command{
+		Name:        "evidence",
+		Description: "track the hashes of the files in the evidence directories",
+	}
+	evidenceCmd.AddSubCommands(addEvidenceCmd, attachEvidenceCmd, logEvidenceCmd,
+		verifyEvidenceCmd)
+	return evidenceCmd
+}
+
+// evidenceCompleter shows the default evidence directories.
+func evidenceCompleter(_ string, _ []string) []prompt.Suggest {
+	return []prompt.Suggest{
+		{Text: "@pix", Description: "screenshots and images"},
+		{Text: "@clientFiles", Description: "files from the client"},
+	}
+}
+
+// evidenceOptions returns the options in the arguments.
+func evidenceOptions(args prompter.CmdArgs) project.EvidenceOptions {
+	var opts project.EvidenceOptions
+	opts.Dir, _ = args.GetFirstValue("-dir")
+	opts.Finding, _ = args.GetFirstValue("-finding")
+	opts.CapturedBy, _ = args.GetFirstValue("-by")
+	opts.Note, _ = args.GetFirstValue("-note")
+	return opts
+}
+
+// addEvidenceExecutor adds a file to the evidence of a project.
+func addEvidenceExecutor(args prompter.CmdArgs) error {
+	pos := args["_"]
+	if len(pos) < 2 {
+		return fmt.Errorf("cmd.addEvidenceExecutor: please provide project name and file")
+	}
+	prj, err := project.Load(pos[0])
+	if err != nil {
+		return err
+	}
+	r, err := prj.AddEvidence(pos[1], evidenceOptions(args))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Added %s (sha256 %s).\n", r.Path, r.SHA256)
+	if r.Finding != "" {
+		fmt.Printf("Attached to %s.\n", r.Finding)
+	}
+	return nil
+}
+
+// attachEvidenceExecutor attaches evidence to a finding.
+func attachEvidenceExecutor(args prompter.CmdArgs) error {
+	pos := args["_"]
+	if len(pos) < 3 {
+		return fmt.Errorf("cmd.attachEvidenceExecutor: please provide project name, evidence path and finding ID")
+	}
+	prj, err := project.Load(pos[0])
+	if err != nil {
+		return err
+	}
+	r, err := prj.AttachEvidence(pos[1], pos[2], evidenceOptions(args))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Attached %s to %s.\n", r.Path, r.Finding)
+	return nil
+}
+
+// logEvidenceExecutor prints the evidence manifest in a table.
+func logEvidenceExecutor(args prompter.CmdArgs) error {
+	projectName, err := args.GetFirstValue("_")
+	if err != nil {
+		return fmt.Errorf("cmd.logEvidenceExecutor: please provide project name")
+	}
+	prj, err := project.Load(projectName)
+	if err != nil {
+		return err
+	}
+	records, err := prj.EvidenceLog()
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		fmt.Println("No evidence.")
+		return nil
+	}
+	rows := [][]string{{"CAPTURED", "BY", "ACTION", "PATH", "FINDING", "SHA256", "SOURCE"}}
+	for _, r := range records {
+		rows = append(rows, []string{r.Captured, r.CapturedBy, r.Action, r.Path,
+			r.Finding, r.SHA256, r.Source})
+	}
+	fmt.Println(Table(rows, false))
+	return nil
+}
+
+// verifyEvidenceExecutor prints the evidence that does not match the manifest.
+func verifyEvidenceExecutor(args prompter.CmdArgs) error {
+	projectName, err := args.GetFirstValue("_")
+	if err != nil {
+		return fmt.Errorf("cmd.verifyEvidenceExecutor: please provide project name")
+	}
+	prj, err := project.Load(projectName)
+	if err != nil {
+		return err
+	}
+	results, err := prj.VerifyEvidence()
+	if err != nil {
+		return err
+	}
+	rows := [][]string{{"STATUS", "PATH", "SHA256"}}
+	counts := make(map[string]int)
+	for _, r := range results {
+		counts[r.Status]++
+		if r.Status == project.EvidenceOK && !args.Contains("-all") {
+			continue
+		}
+		hash := r.Want
+		if r.Status == project.EvidenceChanged || r.Status == project.EvidenceTampered {
+			hash = r.Got
+		}
+		rows = append(rows, []string{r.Status, r.Path, hash})
+	}
+	if len(rows) > 1 {
+		fmt.Println(Table(rows, false))
+	}
+	fmt.Printf("%d ok, %d changed, %d missing, %d untracked, %d tampered manifest records.\n",
+		counts[project.EvidenceOK], counts[project.EvidenceChanged], counts[project.EvidenceMissing],
+		counts[project.EvidenceUntracked], counts[project.EvidenceTampered])
+	return nil
+}
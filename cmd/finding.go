@@ -0,0 +1,359 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	prompt "github.com/c-bata/go-prompt"
+	"github.com/parsiya/borrowedtime/project"
+	"github.com/starkriedesel/prompter"
+)
+
+// Finding command.
+
+// FindingCmd returns the finding command.
+func FindingCmd() prompter.Command {
+	nameArgument := prompter.Argument{
+		Name:              " ",
+		Description:       "project name",
+		ArgumentCompleter: openProjectCompleter,
+	}
+
+	listFindingsCmd := prompter.Command{
+		Name:        "list",
+		Description: "list the findings of a project by severity",
+		Executor:    listFindingsExecutor,
+	}
+	listFindingsCmd.AddArguments(
+		prompter.Argument{
+			Name:              "-severity",
+			Description:       "(optional) only show findings with this severity, can be repeated or comma separated",
+			ArgumentCompleter: findingCompleter,
+			Repeatable:        true,
+		},
+		prompter.Argument{
+			Name:              "-status",
+			Description:       "(optional) only show findings with this status, can be repeated or comma separated",
+			ArgumentCompleter: findingCompleter,
+			Repeatable:        true,
+		},
+		nameArgument,
+	)
+
+	addFindingCmd := prompter.Command{
+		Name:        "add",
+		Description: "add a finding to a project, the title can be passed after the project name",
+		Executor:    addFindingExecutor,
+	}
+	addFindingCmd.AddArguments(append(findingArguments(),
+		prompter.Argument{
+			Name:              "-from",
+			Description:       "(optional) library ID, the library finding is rendered with the project",
+			ArgumentCompleter: libraryCompleter,
+		},
+		nameArgument)...)
+
+	showFindingCmd := prompter.Command{
+		Name:        "show",
+		Description: "print a finding",
+		Executor:    showFindingExecutor,
+	}
+	showFindingCmd.AddArguments(prompter.Argument{
+		Name:              " ",
+		Description:       "project name and finding ID",
+		ArgumentCompleter: openProjectCompleter,
+	})
+
+	editFindingCmd := prompter.Command{
+		Name:        "edit",
+		Description: "open a finding in the editor or update the fields that are passed",
+		Executor:    editFindingExecutor,
+	}
+	editFindingCmd.AddArguments(append(findingArguments(), prompter.Argument{
+		Name:              " ",
+		Description:       "project name and finding ID",
+		ArgumentCompleter: openProjectCompleter,
+	})...)
+
+	setStatusCmd := prompter.Command{
+		Name:        "set-status",
+		Description: "change the status of a finding",
+		Executor:    setFindingStatusExecutor,
+	}
+	setStatusCmd.AddArguments(
+		prompter.Argument{
+			Name:              "-status",
+			Description:       "new status, can also be passed after the finding ID",
+			ArgumentCompleter: findingCompleter,
+		},
+		prompter.Argument{
+			Name:              " ",
+			Description:       "project name, finding ID and status",
+			ArgumentCompleter: openProjectCompleter,
+		},
+	)
+
+	scoreFindingCmd := prompter.Command{
+		Name:        "score",
+		Description: "set the CVSS v3.1 or v4.0 vector of a finding and its severity",
+		Executor:    scoreFindingExecutor,
+	}
+	scoreFindingCmd.AddArguments(prompter.Argument{
+		Name:              " ",
+		Description:       "project name, finding ID and vector",
+		ArgumentCompleter: openProjectCompleter,
+	})
+
+	findingCmd := prompter.Command{
+		Name:        "finding",
+		Description: "track the findings of a project in @findings",
+	}
+	findingCmd.AddSubCommands(listFindingsCmd, addFindingCmd, showFindingCmd,
+		editFindingCmd, setStatusCmd, scoreFindingCmd)
+	return findingCmd
+}
+
+// findingArguments returns the arguments that set finding fields.
+func findingArguments() []prompter.Argument {
+	return []prompter.Argument{
+		{
+			Name:        "-title",
+			Description: "(optional) title, use \" for spaces",
+		},
+		{
+			Name:              "-severity",
+			Description:       "(optional) critical, high, medium, low or info",
+			ArgumentCompleter: findingCompleter,
+		},
+		{
+			Name:        "-asset",
+			Description: "(optional) affected asset, can be repeated or comma separated",
+			Repeatable:  true,
+		},
+		{
+			Name:        "-evidence",
+			Description: "(optional) path to evidence relative to the project, can be repeated or comma separated",
+			Repeatable:  true,
+		},
+		{
+			Name:        "-description",
+			Description: "(optional) description, use \" for spaces",
+		},
+		{
+			Name:        "-remediation",
+			Description: "(optional) remediation, use \" for spaces",
+		},
+	}
+}
+
+// findingCompleter shows the severities and statuses.
+func findingCompleter(optName string, _ []string) []prompt.Suggest {
+	sugs := []prompt.Suggest{}
+	values := project.FindingStatuses
+	if optName == "-severity" {
+		values = project.Severities
+	}
+	for _, v := range values {
+		sugs = append(sugs, prompt.Suggest{Text: v})
+	}
+	return sugs
+}
+
+// parseFinding updates f with the finding arguments and returns true if any
+// argument was passed.
+func parseFinding(args prompter.CmdArgs, f *project.Finding) bool {
+	changed := false
+	for name, field := range map[string]*string{
+		"-title":       &f.Title,
+		"-severity":    &f.Severity,
+		"-description": &f.Description,
+		"-remediation": &f.Remediation,
+	} {
+		if v, err := args.GetFirstValue(name); err == nil {
+			*field, changed = v, true
+		}
+	}
+	f.Severity = strings.ToLower(f.Severity)
+	// Lists replace the existing values.
+	if args.Contains("-asset") {
+		f.Assets, changed = project.ParseTags(args["-asset"]...), true
+	}
+	if args.Contains("-evidence") {
+		f.Evidence, changed = project.ParseTags(args["-evidence"]...), true
+	}
+	return changed
+}
+
+// findingArgs returns the loaded project and finding from the positional
+// arguments and the remaining arguments.
+func findingArgs(args prompter.CmdArgs) (*project.Project, *project.Finding, []string, error) {
+	pos := args["_"]
+	if len(pos) < 2 {
+		return nil, nil, nil, fmt.Errorf("cmd.findingArgs: please provide project name and finding ID")
+	}
+	prj, err := project.Load(pos[0])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	f, err := prj.Finding(pos[1])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return prj, f, pos[2:], nil
+}
+
+// listFindingsExecutor prints the findings of a project in a table.
+func listFindingsExecutor(args prompter.CmdArgs) error {
+	projectName, err := args.GetFirstValue("_")
+	if err != nil {
+		return fmt.Errorf("cmd.listFindingsExecutor: please provide project name")
+	}
+	prj, err := project.Load(projectName)
+	if err != nil {
+		return err
+	}
+	findings, err := prj.Findings(project.FindingFilter{
+		Severities: project.ParseTags(args["-severity"]...),
+		Statuses:   project.ParseTags(args["-status"]...),
+	})
+	if err != nil {
+		return err
+	}
+	if len(findings) == 0 {
+		fmt.Println("No findings.")
+		return nil
+	}
+	rows := [][]string{{"ID", "SEVERITY", "CVSS", "STATUS", "TITLE", "ASSETS"}}
+	for _, f := range findings {
+		rows = append(rows, []string{f.ID, f.Severity, findingScore(*f), f.Status,
+			f.Title, strings.Join(f.Assets, ",")})
+	}
+	fmt.Println(Table(rows, false))
+	return nil
+}
+
+// addFindingExecutor adds a finding to a project.
+func addFindingExecutor(args prompter.CmdArgs) error {
+	pos := args["_"]
+	if len(pos) == 0 {
+		return fmt.Errorf("cmd.addFindingExecutor: please provide project name")
+	}
+	prj, err := project.Load(pos[0])
+	if err != nil {
+		return err
+	}
+	f := &project.Finding{}
+	if id, err := args.GetFirstValue("-from"); err == nil {
+		e, err := project.FindLibraryEntry(id)
+		if err != nil {
+			return err
+		}
+		if f, err = prj.RenderLibraryEntry(e); err != nil {
+			return err
+		}
+	}
+	// Arguments replace the values in the library finding.
+	if title := strings.Join(pos[1:], " "); title != "" {
+		f.Title = title
+	}
+	parseFinding(args, f)
+	if err := prj.AddFinding(f); err != nil {
+		return err
+	}
+	fmt.Printf("Added %s to %s.\n", f.ID, prj.ProjectName)
+	return nil
+}
+
+// showFindingExecutor prints a finding.
+func showFindingExecutor(args prompter.CmdArgs) error {
+	_, f, _, err := findingArgs(args)
+	if err != nil {
+		return err
+	}
+	fmt.Println(Table([][]string{
+		{"id", f.ID},
+		{"title", f.Title},
+		{"severity", f.Severity},
+		{"status", f.Status},
+		{"assets", strings.Join(f.Assets, ",")},
+		{"evidence", strings.Join(f.Evidence, ",")},
+		{"cvss", strings.TrimSpace(findingScore(*f) + " " + f.CVSS)},
+		{"references", strings.Join(f.References, ",")},
+		{"created", f.Created},
+		{"updated", f.Updated},
+		{"original", f.Original},
+		{"file", f.Path()},
+	}, false))
+	for _, s := range [][2]string{
+		{"Description", f.Description},
+		{"Remediation", f.Remediation},
+	} {
+		if s[1] != "" {
+			fmt.Printf("%s:\n%s\n\n", s[0], s[1])
+		}
+	}
+	return nil
+}
+
+// editFindingExecutor opens a finding in the editor or updates the fields in
+// the arguments.
+func editFindingExecutor(args prompter.CmdArgs) error {
+	prj, f, _, err := findingArgs(args)
+	if err != nil {
+		return err
+	}
+	if !parseFinding(args, f) {
+		return OpenWith(f.Path())
+	}
+	if err := prj.SaveFinding(f); err != nil {
+		return err
+	}
+	fmt.Printf("Updated %s.\n", f.ID)
+	return nil
+}
+
+// setFindingStatusExecutor changes the status of a finding.
+func setFindingStatusExecutor(args prompter.CmdArgs) error {
+	prj, f, rest, err := findingArgs(args)
+	if err != nil {
+		return err
+	}
+	status, err := args.GetFirstValue("-status")
+	if err != nil {
+		if len(rest) == 0 {
+			return fmt.Errorf("cmd.setFindingStatusExecutor: please provide a status")
+		}
+		status = rest[0]
+	}
+	if f, err = prj.SetFindingStatus(f.ID, status); err != nil {
+		return err
+	}
+	fmt.Printf("%s is %s.\n", f.ID, f.Status)
+	return nil
+}
+
+// scoreFindingExecutor sets the CVSS vector of a finding.
+func scoreFindingExecutor(args prompter.CmdArgs) error {
+	prj, f, rest, err := findingArgs(args)
+	if err != nil {
+		return err
+	}
+	if len(rest) == 0 {
+		return fmt.Errorf("cmd.scoreFindingExecutor: please provide a CVSS vector")
+	}
+	if f, err = prj.ScoreFinding(f.ID, rest[0]); err != nil {
+		return err
+	}
+	fmt.Printf("%s is %s (%s).\n", f.ID, findingScore(*f), f.Severity)
+	return nil
+}
+
+// findingScore returns the CVSS score of a finding with one decimal or an
+// empty string if it has no vector.
+func findingScore(f project.Finding) string {
+	score, ok := f.CVSSScore()
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%.1f", score)
+}
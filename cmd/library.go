@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	prompt "github.com/c-bata/go-prompt"
+	"github.com/parsiya/borrowedtime/project"
+	"github.com/starkriedesel/prompter"
+)
+
+// Library command.
+
+// LibraryCmd returns the library command.
+func LibraryCmd() prompter.Command {
+	listLibraryCmd := prompter.Command{
+		Name:        "list",
+		Description: "list the findings in the library",
+		Executor:    listLibraryExecutor,
+	}
+
+	searchLibraryCmd := prompter.Command{
+		Name:        "search",
+		Description: "list the findings in the library that contain every term",
+		Executor:    searchLibraryExecutor,
+	}
+	searchLibraryCmd.AddArguments(prompter.Argument{
+		Name:        " ",
+		Description: "search terms, use \" for spaces",
+	})
+
+	showLibraryCmd := prompter.Command{
+		Name:        "show",
+		Description: "print a finding in the library",
+		Executor:    showLibraryExecutor,
+	}
+	showLibraryCmd.AddArguments(prompter.Argument{
+		Name:              " ",
+		Description:       "library ID",
+		ArgumentCompleter: libraryCompleter,
+	})
+
+	promoteLibraryCmd := prompter.Command{
+		Name:        "promote",
+		Description: "add a project finding to the library without its assets, evidence and status",
+		Executor:    promoteLibraryExecutor,
+	}
+	promoteLibraryCmd.AddArguments(
+		prompter.Argument{
+			Name:              "-id",
+			Description:       "(optional) library ID, defaults to the entry the finding was added from or the title",
+			ArgumentCompleter: libraryCompleter,
+		},
+		switchArgument("-overwrite", "(optional) overwrite an existing library finding"),
+		prompter.Argument{
+			Name:              " ",
+			Description:       "project name and finding ID",
+			ArgumentCompleter: openProjectCompleter,
+		},
+	)
+
+	libraryCmd := prompter.Command{
+		Name:        "library",
+		Description: "reusable findings in data/library",
+	}
+	libraryCmd.AddSubCommands(listLibraryCmd, searchLibraryCmd, showLibraryCmd,
+		promoteLibraryCmd)
+	return libraryCmd
+}
+
+// libraryCompleter shows the library IDs and titles.
+func libraryCompleter(_ string, _ []string) []prompt.Suggest {
+	sugs := []prompt.Suggest{}
+	entries, err := project.Library()
+	if err != nil {
+		return sugs
+	}
+	for _, e := range entries {
+		sugs = append(sugs, prompt.Suggest{Text: e.ID, Description: e.Finding.Title})
+	}
+	return sugs
+}
+
+// printLibrary prints library entries in a table.
+func printLibrary(entries []*project.LibraryEntry) {
+	if len(entries) == 0 {
+		fmt.Println("No library findings.")
+		return
+	}
+	rows := [][]string{{"ID", "SEVERITY", "CVSS", "TITLE"}}
+	for _, e := range entries {
+		rows = append(rows, []string{e.ID, e.Finding.Severity, findingScore(*e.Finding), e.Finding.Title})
+	}
+	fmt.Println(Table(rows, false))
+}
+
+// listLibraryExecutor prints the library.
+func listLibraryExecutor(_ prompter.CmdArgs) error {
+	entries, err := project.Library()
+	if err != nil {
+		return err
+	}
+	printLibrary(entries)
+	return nil
+}
+
+// searchLibraryExecutor prints the library findings that contain the terms.
+func searchLibraryExecutor(args prompter.CmdArgs) error {
+	terms := args["_"]
+	if len(terms) == 0 {
+		return fmt.Errorf("cmd.searchLibraryExecutor: please provide search terms")
+	}
+	entries, err := project.SearchLibrary(terms...)
+	if err != nil {
+		return err
+	}
+	printLibrary(entries)
+	return nil
+}
+
+// showLibraryExecutor prints a library finding. Actions are not executed.
+func showLibraryExecutor(args prompter.CmdArgs) error {
+	id, err := args.GetFirstValue("_")
+	if err != nil {
+		return fmt.Errorf("cmd.showLibraryExecutor: please provide a library ID")
+	}
+	e, err := project.FindLibraryEntry(id)
+	if err != nil {
+		return err
+	}
+	f := e.Finding
+	fmt.Println(Table([][]string{
+		{"id", e.ID},
+		{"title", f.Title},
+		{"severity", f.Severity},
+		{"cvss", strings.TrimSpace(findingScore(*f) + " " + f.CVSS)},
+		{"references", strings.Join(f.References, ",")},
+		{"file", e.Path()},
+	}, false))
+	for _, s := range [][2]string{
+		{"Description", f.Description},
+		{"Remediation", f.Remediation},
+	} {
+		if s[1] != "" {
+			fmt.Printf("%s:\n%s\n\n", s[0], s[1])
+		}
+	}
+	return nil
+}
+
+// promoteLibraryExecutor adds a project finding to the library.
+func promoteLibraryExecutor(args prompter.CmdArgs) error {
+	prj, f, _, err := findingArgs(args)
+	if err != nil {
+		return err
+	}
+	id, _ := args.GetFirstValue("-id")
+	e, err := prj.PromoteFinding(f.ID, id, args.Contains("-overwrite"))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Promoted %s to %s in the library: %s\n", f.ID, e.ID, e.Path())
+	return nil
+}
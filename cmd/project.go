@@ -3,6 +3,8 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/parsiya/borrowedtime/shared"
 
@@ -22,6 +24,29 @@ func ProjectCmd() prompter.Command {
 		Description: "list all projects in the workspace",
 		Executor:    listProjectExecutor,
 	}
+	listProjectsCmd.AddArguments(
+		prompter.Argument{
+			Name:              "-status",
+			Description:       "(optional) only show projects with this status",
+			ArgumentCompleter: metaCompleter,
+		},
+		prompter.Argument{
+			Name:        "-tag",
+			Description: "(optional) only show projects with this tag",
+		},
+		prompter.Argument{
+			Name:        "-client",
+			Description: "(optional) only show projects for this client",
+		},
+		prompter.Argument{
+			Name:              "-sort",
+			Description:       "(optional) sort by name, client, type, status, start, end or archived",
+			ArgumentCompleter: metaCompleter,
+		},
+		switchArgument("-desc", "(optional) sort in descending order"),
+		switchArgument("-all", "(optional) also show directories that are not projects"),
+		switchArgument("-archived", "(optional) show archived projects"),
+	)
 
 	projectCmd := prompter.Command{
 		Name:        "project",
@@ -44,19 +69,145 @@ func ProjectCmd() prompter.Command {
 		Description:       "(optional) project template name",
 		ArgumentCompleter: templateCompleter,
 	}
-	overwriteArgument := prompter.Argument{
-		Name:        "-overwrite",
-		Description: "(optional) overwrite flag",
-	}
+	overwriteArgument := switchArgument("-overwrite", "(optional) overwrite flag")
+	dryRunArgument := switchArgument("-dry-run", "(optional) print the project tree without creating it")
+	contentArgument := switchArgument("-content", "(optional) print rendered file contents with -dry-run")
 	// Hacky way to display a suggestion for project name.
 	emptyArgument := prompter.Argument{
 		Name:              " ",
 		Description:       "project name - use \" for names with spaces",
 		ArgumentCompleter: createProjectCompleter,
 	}
-	createProjectsCmd.AddArguments(templateArgument, overwriteArgument, emptyArgument)
+	createProjectsCmd.AddArguments(templateArgument, overwriteArgument,
+		dryRunArgument, contentArgument, varArgument(), emptyArgument)
+	createProjectsCmd.AddArguments(metaArguments()...)
+
+	metaProjectCmd := prompter.Command{
+		Name:        "meta",
+		Description: "show or update the client, dates, status and tags of a project",
+		Executor:    metaProjectExecutor,
+	}
+	metaProjectCmd.AddArguments(metaArguments()...)
+	metaProjectCmd.AddArguments(
+		prompter.Argument{
+			Name:        "-untag",
+			Description: "(optional) remove a tag, can be repeated",
+			Repeatable:  true,
+		},
+		prompter.Argument{
+			Name:              " ",
+			Description:       "project name",
+			ArgumentCompleter: openProjectCompleter,
+		},
+	)
+
+	syncProjectCmd := prompter.Command{
+		Name:        "sync",
+		Description: "apply project template changes to an existing project",
+		Executor:    syncProjectExecutor,
+	}
+	syncProjectCmd.AddArguments(
+		switchArgument("-apply", "(optional) update files that were not edited since creation"),
+		prompter.Argument{
+			Name:              " ",
+			Description:       "project name",
+			ArgumentCompleter: openProjectCompleter,
+		},
+	)
+
+	archiveProjectCmd := prompter.Command{
+		Name:        "archive",
+		Description: "compress a project to the archive directory and remove it from the workspace",
+		Executor:    archiveProjectExecutor,
+	}
+	archiveProjectCmd.AddArguments(prompter.Argument{
+		Name:              " ",
+		Description:       "project name",
+		ArgumentCompleter: openProjectCompleter,
+	})
+
+	unarchiveProjectCmd := prompter.Command{
+		Name:        "unarchive",
+		Description: "restore an archived project to the workspace",
+		Executor:    unarchiveProjectExecutor,
+	}
+	unarchiveProjectCmd.AddArguments(prompter.Argument{
+		Name:              " ",
+		Description:       "archived project name",
+		ArgumentCompleter: archivedProjectCompleter,
+	})
+
+	renameProjectCmd := prompter.Command{
+		Name:        "rename",
+		Description: "rename a project and update the paths in its config",
+		Executor:    renameProjectExecutor,
+	}
+	renameProjectCmd.AddArguments(prompter.Argument{
+		Name:              " ",
+		Description:       "old and new project names",
+		ArgumentCompleter: openProjectCompleter,
+	})
+
+	deleteProjectCmd := prompter.Command{
+		Name:        "delete",
+		Description: "move a project to the trash",
+		Executor:    deleteProjectExecutor,
+	}
+	deleteProjectCmd.AddArguments(prompter.Argument{
+		Name:              " ",
+		Description:       "project name",
+		ArgumentCompleter: openProjectCompleter,
+	})
+
+	cloneProjectCmd := prompter.Command{
+		Name:        "clone",
+		Description: "create a new project from an existing project",
+		Executor:    cloneProjectExecutor,
+	}
+	cloneProjectCmd.AddArguments(
+		switchArgument("-retest", "(optional) mark the copied findings as to be retested"),
+		switchArgument("-evidence", "(optional) also copy the evidence directories"),
+		prompter.Argument{
+			Name:              " ",
+			Description:       "source and new project names",
+			ArgumentCompleter: openProjectCompleter,
+		},
+	)
+
+	infoProjectCmd := prompter.Command{
+		Name:        "info",
+		Description: "show project metadata, template, config, size and last activity",
+		Executor:    infoProjectExecutor,
+	}
+	infoProjectCmd.AddArguments(prompter.Argument{
+		Name:              " ",
+		Description:       "project name",
+		ArgumentCompleter: openProjectCompleter,
+	})
+
+	reportProjectCmd := prompter.Command{
+		Name:        "report",
+		Description: "render the findings and report.json with the report templates",
+		Executor:    reportProjectExecutor,
+	}
+	reportProjectCmd.AddArguments(
+		prompter.Argument{
+			Name:              "-template",
+			Description:       "(optional) report template, can be repeated, all report templates are rendered by default",
+			ArgumentCompleter: reportTemplateCompleter,
+			Repeatable:        true,
+		},
+		prompter.Argument{
+			Name:              " ",
+			Description:       "project name",
+			ArgumentCompleter: openProjectCompleter,
+		},
+	)
 
-	projectCmd.AddSubCommands(listProjectsCmd, createProjectsCmd)
+	projectCmd.AddSubCommands(listProjectsCmd, createProjectsCmd, syncProjectCmd,
+		metaProjectCmd, archiveProjectCmd, unarchiveProjectCmd, renameProjectCmd,
+		deleteProjectCmd, trashCmd(), cloneProjectCmd, infoProjectCmd,
+		projectConfigCmd(), reportProjectCmd)
 	return projectCmd
 }
 
@@ -76,6 +227,7 @@ func projectExecutor(args prompter.CmdArgs) error {
 }
 
 // openProjectCompleter lists all top-level directories in the workspace.
+// Archived projects are not in the workspace.
 func openProjectCompleter(_ string, _ []string) []prompt.Suggest {
 	// Create an empty list of suggestions.
 	sugs := []prompt.Suggest{}
@@ -99,7 +251,7 @@ func openProjectCompleter(_ string, _ []string) []prompt.Suggest {
 	return sugs
 }
 
-// listProjectExecutor lists all projects.
+// listProjectExecutor lists the projects in the registry.
 func listProjectExecutor(args prompter.CmdArgs) error {
 	// Get workspace path.
 	workspace, err := workspacePath()
@@ -120,11 +272,157 @@ func listProjectExecutor(args prompter.CmdArgs) error {
 		}
 	}
 
-	dirs, err := TopDirs(workspace)
+	// Pick up changes to the project configs.
+	reg, err := project.RefreshRegistry()
+	if err != nil {
+		return err
+	}
+	opts := project.ListOptions{
+		Desc:     args.Contains("-desc"),
+		Archived: args.Contains("-archived"),
+	}
+	opts.Status, _ = args.GetFirstValue("-status")
+	opts.Tag, _ = args.GetFirstValue("-tag")
+	opts.Client, _ = args.GetFirstValue("-client")
+	opts.SortBy, _ = args.GetFirstValue("-sort")
+	entries, err := reg.List(opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.Archived {
+		rows := [][]string{{"NAME", "CLIENT", "TYPE", "STATUS", "TAGS", "ARCHIVED", "SIZE"}}
+		for _, e := range entries {
+			rows = append(rows, []string{e.Name, e.Client, e.Type, e.Status,
+				strings.Join(e.Tags, ","), e.Archived, formatSize(e.Size)})
+		}
+		fmt.Println(Table(rows, false))
+		return nil
+	}
+
+	rows := [][]string{{"NAME", "CLIENT", "TYPE", "STATUS", "START", "END", "TAGS", "TEMPLATE"}}
+	for _, e := range entries {
+		rows = append(rows, []string{e.Name, e.Client, e.Type, e.Status, e.Start,
+			e.End, strings.Join(e.Tags, ","), e.Template})
+	}
+	// Directories without a project config.
+	if args.Contains("-all") {
+		dirs, err := TopDirs(workspace)
+		if err != nil {
+			return err
+		}
+		for _, dir := range dirs {
+			if _, ok := reg.Projects[dir[0]]; !ok {
+				rows = append(rows, []string{dir[0], "", "", "-", "", "", "", ""})
+			}
+		}
+	}
+	fmt.Println(Table(rows, false))
+	return nil
+}
+
+// metaArguments returns the arguments that set project metadata.
+func metaArguments() []prompter.Argument {
+	return []prompter.Argument{
+		{
+			Name:        "-client",
+			Description: "(optional) client name",
+		},
+		{
+			Name:        "-type",
+			Description: "(optional) engagement type (e.g., web, mobile, network)",
+		},
+		{
+			Name:              "-start",
+			Description:       "(optional) start date as YYYY-MM-DD",
+			ArgumentCompleter: metaCompleter,
+		},
+		{
+			Name:              "-end",
+			Description:       "(optional) end date as YYYY-MM-DD",
+			ArgumentCompleter: metaCompleter,
+		},
+		{
+			Name:              "-status",
+			Description:       "(optional) planned, active, reporting or closed",
+			ArgumentCompleter: metaCompleter,
+		},
+		{
+			Name:        "-tag",
+			Description: "(optional) tag, can be repeated or comma separated",
+			Repeatable:  true,
+		},
+	}
+}
+
+// metaCompleter shows suggestions for the metadata arguments.
+func metaCompleter(optName string, _ []string) []prompt.Suggest {
+	sugs := []prompt.Suggest{}
+	switch optName {
+	case "-status":
+		for _, st := range project.Statuses {
+			sugs = append(sugs, prompt.Suggest{Text: st})
+		}
+	case "-sort":
+		for _, f := range project.SortFields {
+			sugs = append(sugs, prompt.Suggest{Text: f})
+		}
+	case "-start", "-end":
+		sugs = append(sugs, prompt.Suggest{
+			Text:        time.Now().Format("2006-01-02"),
+			Description: "today",
+		})
+	}
+	return sugs
+}
+
+// parseMeta updates m with the metadata arguments.
+func parseMeta(args prompter.CmdArgs, m *project.Metadata) {
+	for name, field := range map[string]*string{
+		"-client": &m.Client,
+		"-type":   &m.Type,
+		"-start":  &m.Start,
+		"-end":    &m.End,
+		"-status": &m.Status,
+	} {
+		if v, err := args.GetFirstValue(name); err == nil {
+			*field = v
+		}
+	}
+	m.Tags = project.ParseTags(append(m.Tags, args["-tag"]...)...)
+}
+
+// metaProjectExecutor prints the metadata of a project or updates it.
+func metaProjectExecutor(args prompter.CmdArgs) error {
+	projectName, err := args.GetFirstValue("_")
 	if err != nil {
+		return fmt.Errorf("project.metaProjectExecutor: please provide project name")
+	}
+	prj, err := project.Load(projectName)
+	if err != nil {
+		return err
+	}
+	parseMeta(args, &prj.Meta)
+	for _, untag := range project.ParseTags(args["-untag"]...) {
+		tags := prj.Meta.Tags[:0]
+		for _, t := range prj.Meta.Tags {
+			if !strings.EqualFold(t, untag) {
+				tags = append(tags, t)
+			}
+		}
+		prj.Meta.Tags = tags
+	}
+	if err := prj.SaveMeta(); err != nil {
 		return err
 	}
-	fmt.Println(Table(dirs, false))
+	fmt.Println(Table([][]string{
+		{"client", prj.Meta.Client},
+		{"type", prj.Meta.Type},
+		{"start", prj.Meta.Start},
+		{"end", prj.Meta.End},
+		{"status", prj.Meta.Status},
+		{"tags", strings.Join(prj.Meta.Tags, ",")},
+	}, false))
 	return nil
 }
 
@@ -218,9 +516,493 @@ func createProjectExecutor(args prompter.CmdArgs) (err error) {
 
 	// Create project.
 	prj := project.New(projectName)
+	prj.Vars, err = parseVars(args["-var"])
+	if err != nil {
+		return err
+	}
+	parseMeta(args, &prj.Meta)
+
+	// Only print the project tree in a dry-run. Nothing is created and the
+	// editor is not opened.
+	if args.Contains("-dry-run") {
+		tree, err := prj.DryRun(templateName, overwrite, args.Contains("-content"))
+		if err != nil {
+			return err
+		}
+		fmt.Print(tree)
+		return nil
+	}
+
 	err = prj.Create(templateName, overwrite)
 	if err != nil {
 		return err
 	}
 	return OpenProject(projectName)
 }
+
+// syncProjectExecutor creates missing nodes in a project from its template and
+// reports the files that are different.
+func syncProjectExecutor(args prompter.CmdArgs) error {
+	projectName, err := args.GetFirstValue("_")
+	if err != nil {
+		return fmt.Errorf("project.syncProjectExecutor: please provide project name")
+	}
+	prj, err := project.Load(projectName)
+	if err != nil {
+		return err
+	}
+	report, err := prj.Sync(args.Contains("-apply"))
+	if err != nil {
+		return err
+	}
+	if report == "" {
+		report = fmt.Sprintf("%s is in sync with its template.\n", projectName)
+	}
+	fmt.Print(report)
+	return nil
+}
+
+// archiveProjectExecutor archives a project.
+func archiveProjectExecutor(args prompter.CmdArgs) error {
+	projectName, err := args.GetFirstValue("_")
+	if err != nil {
+		return fmt.Errorf("project.archiveProjectExecutor: please provide project name")
+	}
+	prj, err := project.Load(projectName)
+	if err != nil {
+		return err
+	}
+	m, err := prj.Archive()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Archived %s: %d file(s), %s.\n", projectName, len(m.Files), formatSize(m.Size))
+	return nil
+}
+
+// unarchiveProjectExecutor restores an archived project to the workspace.
+func unarchiveProjectExecutor(args prompter.CmdArgs) error {
+	projectName, err := args.GetFirstValue("_")
+	if err != nil {
+		return fmt.Errorf("project.unarchiveProjectExecutor: please provide project name")
+	}
+	prj, err := project.Unarchive(projectName)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restored %s to %s.\n", projectName, prj.ProjectRoot)
+	return nil
+}
+
+// archivedProjectCompleter lists the archived projects.
+func archivedProjectCompleter(_ string, _ []string) []prompt.Suggest {
+	sugs := []prompt.Suggest{}
+	reg, err := project.ReadRegistry()
+	if err != nil {
+		return sugs
+	}
+	entries, err := reg.List(project.ListOptions{Archived: true})
+	if err != nil {
+		return sugs
+	}
+	for _, e := range entries {
+		sugs = append(sugs, prompt.Suggest{
+			Text:        e.Name,
+			Description: "archived " + e.Archived,
+		})
+	}
+	return sugs
+}
+
+// formatSize returns a human readable size.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// renameProjectExecutor renames a project.
+func renameProjectExecutor(args prompter.CmdArgs) error {
+	if len(args["_"]) != 2 {
+		return fmt.Errorf("project.renameProjectExecutor: please provide the old and new project names")
+	}
+	oldName, newName := args["_"][0], args["_"][1]
+	prj, err := project.Load(oldName)
+	if err != nil {
+		return err
+	}
+	renamed, err := prj.Rename(newName)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Renamed %s to %s.\n", oldName, renamed.ProjectRoot)
+	return nil
+}
+
+// deleteProjectExecutor moves a project to the trash.
+func deleteProjectExecutor(args prompter.CmdArgs) error {
+	projectName, err := args.GetFirstValue("_")
+	if err != nil {
+		return fmt.Errorf("project.deleteProjectExecutor: please provide project name")
+	}
+	prj, err := project.Load(projectName)
+	if err != nil {
+		return err
+	}
+	item, err := prj.Delete()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Moved %s to the trash, use \"project trash restore %s\" to undo.\n", projectName, item.ID)
+	return nil
+}
+
+// trashCmd returns the project trash command.
+func trashCmd() prompter.Command {
+	listTrashCmd := prompter.Command{
+		Name:        "list",
+		Description: "list deleted projects",
+		Executor:    listTrashExecutor,
+	}
+	restoreTrashCmd := prompter.Command{
+		Name:        "restore",
+		Description: "restore a deleted project to its original path",
+		Executor:    restoreTrashExecutor,
+	}
+	restoreTrashCmd.AddArguments(prompter.Argument{
+		Name:              " ",
+		Description:       "project name or trash ID",
+		ArgumentCompleter: trashCompleter,
+	})
+	emptyTrashCmd := prompter.Command{
+		Name:        "empty",
+		Description: "permanently delete all projects in the trash",
+		Executor:    emptyTrashExecutor,
+	}
+	emptyTrashCmd.AddArguments(
+		switchArgument("-force", "required, deleted projects cannot be restored"),
+	)
+
+	trashCmd := prompter.Command{
+		Name:        "trash",
+		Description: "list, restore or empty deleted projects",
+		Executor:    listTrashExecutor,
+	}
+	trashCmd.AddSubCommands(listTrashCmd, restoreTrashCmd, emptyTrashCmd)
+	return trashCmd
+}
+
+// listTrashExecutor purges expired projects and lists the trash.
+func listTrashExecutor(_ prompter.CmdArgs) error {
+	purged, err := project.PurgeTrash()
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		fmt.Printf("Purged %d expired project(s).\n", purged)
+	}
+	items, err := project.TrashItems()
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		fmt.Println("Trash is empty.")
+		return nil
+	}
+	rows := [][]string{{"ID", "NAME", "DELETED", "ORIGINAL PATH"}}
+	for _, item := range items {
+		rows = append(rows, []string{item.ID, item.Name, item.Deleted, item.Original})
+	}
+	fmt.Println(Table(rows, false))
+	return nil
+}
+
+// restoreTrashExecutor restores a deleted project.
+func restoreTrashExecutor(args prompter.CmdArgs) error {
+	name, err := args.GetFirstValue("_")
+	if err != nil {
+		return fmt.Errorf("project.restoreTrashExecutor: please provide project name or trash ID")
+	}
+	item, err := project.RestoreTrash(name)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restored %s to %s.\n", item.Name, item.Original)
+	return nil
+}
+
+// emptyTrashExecutor permanently deletes the projects in the trash.
+func emptyTrashExecutor(args prompter.CmdArgs) error {
+	if !args.Contains("-force") {
+		items, err := project.TrashItems()
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("project.emptyTrashExecutor: use -force to permanently delete %d project(s)", len(items))
+	}
+	n, err := project.EmptyTrash()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Permanently deleted %d project(s).\n", n)
+	return nil
+}
+
+// trashCompleter lists the deleted projects.
+func trashCompleter(_ string, _ []string) []prompt.Suggest {
+	sugs := []prompt.Suggest{}
+	items, err := project.TrashItems()
+	if err != nil {
+		return sugs
+	}
+	for _, item := range items {
+		sugs = append(sugs, prompt.Suggest{
+			Text:        item.ID,
+			Description: "deleted " + item.Deleted,
+		})
+	}
+	return sugs
+}
+
+// cloneProjectExecutor clones a project and opens the new project.
+func cloneProjectExecutor(args prompter.CmdArgs) error {
+	if len(args["_"]) != 2 {
+		return fmt.Errorf("project.cloneProjectExecutor: please provide the source and new project names")
+	}
+	srcName, dstName := args["_"][0], args["_"][1]
+	src, err := project.Load(srcName)
+	if err != nil {
+		return err
+	}
+	_, err = src.Clone(dstName, project.CloneOptions{
+		Retest:   args.Contains("-retest"),
+		Evidence: args.Contains("-evidence"),
+	})
+	if err != nil {
+		return err
+	}
+	return OpenProject(dstName)
+}
+
+// infoProjectExecutor prints the project information.
+func infoProjectExecutor(args prompter.CmdArgs) error {
+	projectName, err := args.GetFirstValue("_")
+	if err != nil {
+		return fmt.Errorf("project.infoProjectExecutor: please provide project name")
+	}
+	prj, err := project.Load(projectName)
+	if err != nil {
+		return err
+	}
+	info, err := prj.Info()
+	if err != nil {
+		return err
+	}
+	lastActivity := ""
+	if !info.LastActivity.IsZero() {
+		lastActivity = fmt.Sprintf("%s (%s)", info.LastActivity.Format("2006-01-02 15:04:05"), info.LastFile)
+	}
+	fmt.Println(Table([][]string{
+		{"name", info.Name},
+		{"root", info.Root},
+		{"template", info.Template},
+		{"client", info.Meta.Client},
+		{"type", info.Meta.Type},
+		{"start", info.Meta.Start},
+		{"end", info.Meta.End},
+		{"status", info.Meta.Status},
+		{"tags", strings.Join(info.Meta.Tags, ",")},
+		{"files", fmt.Sprintf("%d file(s), %d dir(s)", info.Files, info.Dirs)},
+		{"size", formatSize(info.Size)},
+		{"last activity", lastActivity},
+	}, false))
+	rows := [][]string{{"KEY", "VALUE"}}
+	for _, k := range shared.SortedKeys(info.Config) {
+		rows = append(rows, []string{k, info.Config[k]})
+	}
+	fmt.Println(Table(rows, false))
+	return nil
+}
+
+// projectConfigCmd returns the project config command.
+func projectConfigCmd() prompter.Command {
+	keyArgument := prompter.Argument{
+		Name:              "-key",
+		Description:       "config key, can also be passed after the project name",
+		ArgumentCompleter: projectConfigKeyCompleter,
+	}
+	nameArgument := prompter.Argument{
+		Name:              " ",
+		Description:       "project name, key and value",
+		ArgumentCompleter: openProjectCompleter,
+	}
+	getCmd := prompter.Command{
+		Name:        "get",
+		Description: "print a project config value, workspace values are used if it's not set",
+		Executor:    projectConfigGetExecutor,
+	}
+	getCmd.AddArguments(keyArgument, nameArgument)
+	setCmd := prompter.Command{
+		Name:        "set",
+		Description: "set a project config value",
+		Executor:    projectConfigSetExecutor,
+	}
+	setCmd.AddArguments(keyArgument, nameArgument, prompter.Argument{
+		Name:        "-value",
+		Description: "value, can also be passed after the key",
+	})
+	unsetCmd := prompter.Command{
+		Name:        "unset",
+		Description: "remove a key from the project config",
+		Executor:    projectConfigUnsetExecutor,
+	}
+	unsetCmd.AddArguments(keyArgument, nameArgument)
+
+	configCmd := prompter.Command{
+		Name:        "config",
+		Description: "get, set or unset per-project settings in .config.json",
+	}
+	configCmd.AddSubCommands(getCmd, setCmd, unsetCmd)
+	return configCmd
+}
+
+// projectConfigArgs returns the loaded project, the key and the value from
+// the positional arguments or -key and -value.
+func projectConfigArgs(args prompter.CmdArgs, needValue bool) (*project.Project, string, string, error) {
+	pos := args["_"]
+	if len(pos) == 0 {
+		return nil, "", "", fmt.Errorf("project.projectConfigArgs: please provide project name")
+	}
+	prj, err := project.Load(pos[0])
+	if err != nil {
+		return nil, "", "", err
+	}
+	pos = pos[1:]
+	key, err := args.GetFirstValue("-key")
+	if err != nil {
+		if len(pos) == 0 {
+			return nil, "", "", fmt.Errorf("project.projectConfigArgs: please provide a key")
+		}
+		key, pos = pos[0], pos[1:]
+	}
+	if !needValue {
+		return prj, key, "", nil
+	}
+	value, err := args.GetFirstValue("-value")
+	if err != nil {
+		if len(pos) == 0 {
+			return nil, "", "", fmt.Errorf("project.projectConfigArgs: please provide a value")
+		}
+		value = strings.Join(pos, " ")
+	}
+	return prj, key, value, nil
+}
+
+// projectConfigGetExecutor prints a project config value.
+func projectConfigGetExecutor(args prompter.CmdArgs) error {
+	prj, key, _, err := projectConfigArgs(args, false)
+	if err != nil {
+		return err
+	}
+	value, fromProject, err := prj.GetConfig(key)
+	if err != nil {
+		return err
+	}
+	if !fromProject {
+		value += "  (workspace)"
+	}
+	fmt.Println(value)
+	return nil
+}
+
+// projectConfigSetExecutor sets a project config value.
+func projectConfigSetExecutor(args prompter.CmdArgs) error {
+	prj, key, value, err := projectConfigArgs(args, true)
+	if err != nil {
+		return err
+	}
+	if err := prj.SetConfig(key, value); err != nil {
+		return err
+	}
+	fmt.Printf("%s = %s\n", key, prj.ProjectConfig[strings.ToLower(key)])
+	return nil
+}
+
+// projectConfigUnsetExecutor removes a key from the project config.
+func projectConfigUnsetExecutor(args prompter.CmdArgs) error {
+	prj, key, _, err := projectConfigArgs(args, false)
+	if err != nil {
+		return err
+	}
+	if err := prj.UnsetConfig(key); err != nil {
+		return err
+	}
+	fmt.Printf("Removed %s from %s.\n", key, prj.ProjectName)
+	return nil
+}
+
+// projectConfigKeyCompleter lists the config keys of the project that is
+// passed before -key.
+func projectConfigKeyCompleter(_ string, args []string) []prompt.Suggest {
+	sugs := []prompt.Suggest{}
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return sugs
+	}
+	prj, err := project.Load(args[0])
+	if err != nil {
+		return sugs
+	}
+	for _, k := range prj.ConfigKeys() {
+		desc := prj.Config[k]
+		if _, ok := prj.ProjectConfig[k]; !ok && desc != "" {
+			desc += " (workspace)"
+		}
+		sugs = append(sugs, prompt.Suggest{Text: k, Description: desc})
+	}
+	return sugs
+}
+
+// reportProjectExecutor renders the report of a project.
+func reportProjectExecutor(args prompter.CmdArgs) error {
+	projectName, err := args.GetFirstValue("_")
+	if err != nil {
+		return fmt.Errorf("project.reportProjectExecutor: please provide project name")
+	}
+	prj, err := project.Load(projectName)
+	if err != nil {
+		return err
+	}
+	report, err := prj.NewReport()
+	if err != nil {
+		return err
+	}
+	files, err := report.Write(project.ParseTags(args["-template"]...)...)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created %d report(s) with %d finding(s):\n", len(files), len(report.Findings))
+	for _, f := range files {
+		fmt.Println(f)
+	}
+	return nil
+}
+
+// reportTemplateCompleter lists the report templates.
+func reportTemplateCompleter(_ string, _ []string) []prompt.Suggest {
+	sugs := []prompt.Suggest{}
+	tmpls, err := config.ReportTemplates()
+	if err != nil {
+		return sugs
+	}
+	for _, name := range shared.SortedKeys(tmpls) {
+		sugs = append(sugs, prompt.Suggest{Text: name, Description: tmpls[name]})
+	}
+	return sugs
+}
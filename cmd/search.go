@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	prompt "github.com/c-bata/go-prompt"
+	"github.com/parsiya/borrowedtime/project"
+	"github.com/starkriedesel/prompter"
+)
+
+// Search command.
+
+// defaultMaxResults is the number of results printed without -max.
+const defaultMaxResults = 100
+
+// SearchCmd returns the search command.
+func SearchCmd() prompter.Command {
+	searchCmd := prompter.Command{
+		Name:        "search",
+		Description: "search the text files of all projects in the workspace",
+		Executor:    searchExecutor,
+	}
+	searchCmd.AddArguments(
+		prompter.Argument{
+			Name:        "-regex",
+			Description: "(optional) regular expression that lines must match",
+		},
+		prompter.Argument{
+			Name:              "-project",
+			Description:       "(optional) only search this project, supports globs, can be repeated",
+			ArgumentCompleter: openProjectCompleter,
+			Repeatable:        true,
+		},
+		prompter.Argument{
+			Name:        "-max",
+			Description: fmt.Sprintf("(optional) maximum number of results, default %d, 0 for all", defaultMaxResults),
+		},
+		switchArgument("-rebuild", "(optional) rebuild the search index"),
+		prompter.Argument{
+			Name:              " ",
+			Description:       "search terms - use \" for phrases",
+			ArgumentCompleter: searchCompleter,
+		},
+	)
+	return searchCmd
+}
+
+// searchCompleter shows a suggestion for the search terms.
+func searchCompleter(_ string, _ []string) []prompt.Suggest {
+	return []prompt.Suggest{
+		{
+			Text:        "terms",
+			Description: "lines must contain every term, use \" for phrases",
+		},
+	}
+}
+
+// searchExecutor searches the workspace and prints the matching lines.
+func searchExecutor(args prompter.CmdArgs) error {
+	opts := project.SearchOptions{
+		Terms:    args["_"],
+		Projects: args["-project"],
+		Rebuild:  args.Contains("-rebuild"),
+	}
+	opts.Regex, _ = args.GetFirstValue("-regex")
+	max := defaultMaxResults
+	if v, err := args.GetFirstValue("-max"); err == nil {
+		if max, err = strconv.Atoi(v); err != nil || max < 0 {
+			return fmt.Errorf("cmd.searchExecutor: invalid -max %q", v)
+		}
+	}
+
+	results, stats, err := project.Search(opts)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		fmt.Printf("No results in %d file(s).\n", stats.Files)
+		return nil
+	}
+	rows := [][]string{{"PROJECT", "FILE", "LINE", "SNIPPET"}}
+	for i, r := range results {
+		if max > 0 && i == max {
+			break
+		}
+		rows = append(rows, []string{r.Project, r.File, strconv.Itoa(r.Line), r.Snippet})
+	}
+	fmt.Println(Table(rows, false))
+	if max > 0 && len(results) > max {
+		fmt.Printf("Showing %d of %d results, use -max to see more.\n", max, len(results))
+	}
+	return nil
+}
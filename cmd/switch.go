@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"reflect"
+
+	prompt "github.com/c-bata/go-prompt"
+	"github.com/starkriedesel/prompter"
+)
+
+// Switches.
+
+// The prompter reads the word after an argument as its value. Switches (e.g.,
+// -dry-run) do not have a value. A switch at the end of the line would be
+// dropped and one before another argument would consume it. Switches are
+// removed from the parsed arguments when the commands are registered so the
+// prompter leaves them in the positional arguments. They are still suggested.
+
+// switchCompleter returns no suggestions because switches have no value. It
+// also marks an argument as a switch.
+func switchCompleter(_ string, _ []string) []prompt.Suggest {
+	return []prompt.Suggest{}
+}
+
+// switchArgument returns an argument without a value.
+func switchArgument(name, description string) prompter.Argument {
+	return prompter.Argument{
+		Name:              name,
+		Description:       description,
+		ArgumentCompleter: switchCompleter,
+	}
+}
+
+// isSwitch returns true if the argument was created by switchArgument.
+func isSwitch(a *prompter.Argument) bool {
+	return a.ArgumentCompleter != nil &&
+		reflect.ValueOf(a.ArgumentCompleter).Pointer() == reflect.ValueOf(switchCompleter).Pointer()
+}
+
+// NewCompleter registers the commands and their switches.
+func NewCompleter(commands ...prompter.Command) (*prompter.Completer, error) {
+	comp := prompter.NewCompleter()
+	if err := comp.RegisterCommands(commands...); err != nil {
+		return nil, err
+	}
+	registerSwitches(comp)
+	return comp, nil
+}
+
+// registerSwitches removes the switches of every command from the parsed
+// arguments and wraps the executors to read them from the positional
+// arguments.
+func registerSwitches(comp *prompter.Completer) {
+	for _, command := range comp.Commands {
+		if command.Completer == nil {
+			continue
+		}
+		var names []string
+		for name, opt := range command.Completer.Options {
+			if isSwitch(opt) {
+				names = append(names, name)
+				delete(command.Completer.Options, name)
+			}
+		}
+		if len(names) > 0 && command.Executor != nil {
+			command.Executor = withSwitches(command.Executor, names)
+		}
+		registerSwitches(command.Completer)
+	}
+}
+
+// withSwitches returns an executor that parses the switches before exec.
+func withSwitches(exec prompter.ExecutorFunc, names []string) prompter.ExecutorFunc {
+	return func(args prompter.CmdArgs) error {
+		return exec(parseSwitches(args, names))
+	}
+}
+
+// parseSwitches moves the switches in names from the positional arguments to
+// their own key so args.Contains works.
+func parseSwitches(args prompter.CmdArgs, names []string) prompter.CmdArgs {
+	var pos []string
+	for _, arg := range args["_"] {
+		if matchSwitch(names, arg) {
+			args[arg] = []string{}
+			continue
+		}
+		pos = append(pos, arg)
+	}
+	delete(args, "_")
+	if len(pos) > 0 {
+		args["_"] = pos
+	}
+	return args
+}
+
+// matchSwitch returns true if names contains arg.
+func matchSwitch(names []string, arg string) bool {
+	for _, n := range names {
+		if n == arg {
+			return true
+		}
+	}
+	return false
+}
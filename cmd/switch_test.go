@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/starkriedesel/prompter"
+)
+
+// recordExecutors replaces the executors of the command and its subcommands
+// with one that stores the arguments by command path.
+func recordExecutors(c *prompter.Command, path string, got map[string]prompter.CmdArgs) {
+	path = strings.TrimSpace(path + " " + c.Name)
+	if c.Executor != nil {
+		c.Executor = func(args prompter.CmdArgs) error {
+			got[path] = args
+			return nil
+		}
+	}
+	for i := range c.SubCommands {
+		recordExecutors(&c.SubCommands[i], path, got)
+	}
+}
+
+func TestSwitches(t *testing.T) {
+	got := make(map[string]prompter.CmdArgs)
+	commands := []prompter.Command{ProjectCmd(), TemplateCmd(), SearchCmd(), EvidenceCmd(), LibraryCmd()}
+	for i := range commands {
+		recordExecutors(&commands[i], "", got)
+	}
+	comp, err := NewCompleter(commands...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		line    string
+		command string
+		want    prompter.CmdArgs
+	}{
+		{
+			line:    "project create test1 -template template1 -dry-run",
+			command: "project create",
+			want: prompter.CmdArgs{
+				"_":         {"test1"},
+				"-template": {"template1"},
+				"-dry-run":  {},
+			},
+		},
+		{
+			line:    "project create -dry-run -content test1",
+			command: "project create",
+			want: prompter.CmdArgs{
+				"_":        {"test1"},
+				"-dry-run": {},
+				"-content": {},
+			},
+		},
+		{
+			line:    "project create test1 -overwrite",
+			command: "project create",
+			want: prompter.CmdArgs{
+				"_":          {"test1"},
+				"-overwrite": {},
+			},
+		},
+		{
+			line:    "project sync test1 -apply",
+			command: "project sync",
+			want: prompter.CmdArgs{
+				"_":      {"test1"},
+				"-apply": {},
+			},
+		},
+		{
+			line:    "project list -archived",
+			command: "project list",
+			want: prompter.CmdArgs{
+				"-archived": {},
+			},
+		},
+		{
+			line:    "project list -sort client -desc -all",
+			command: "project list",
+			want: prompter.CmdArgs{
+				"-sort": {"client"},
+				"-desc": {},
+				"-all":  {},
+			},
+		},
+		{
+			line:    "library promote -overwrite acme F-001",
+			command: "library promote",
+			want: prompter.CmdArgs{
+				"_":          {"acme", "F-001"},
+				"-overwrite": {},
+			},
+		},
+		{
+			line:    "library promote acme F-001 -overwrite -id sqli",
+			command: "library promote",
+			want: prompter.CmdArgs{
+				"_":          {"acme", "F-001"},
+				"-overwrite": {},
+				"-id":        {"sqli"},
+			},
+		},
+		{
+			line:    "evidence verify acme -all",
+			command: "evidence verify",
+			want: prompter.CmdArgs{
+				"_":    {"acme"},
+				"-all": {},
+			},
+		},
+		{
+			line:    "project trash empty -force",
+			command: "project trash empty",
+			want: prompter.CmdArgs{
+				"-force": {},
+			},
+		},
+		{
+			line:    "project clone -retest acme acme-retest -evidence",
+			command: "project clone",
+			want: prompter.CmdArgs{
+				"_":         {"acme", "acme-retest"},
+				"-retest":   {},
+				"-evidence": {},
+			},
+		},
+		{
+			line:    "project clone acme acme-retest -retest",
+			command: "project clone",
+			want: prompter.CmdArgs{
+				"_":       {"acme", "acme-retest"},
+				"-retest": {},
+			},
+		},
+		{
+			line:    "template add notes -kind file -overwrite",
+			command: "template add",
+			want: prompter.CmdArgs{
+				"_":          {"notes"},
+				"-kind":      {"file"},
+				"-overwrite": {},
+			},
+		},
+		{
+			line:    "template restore-defaults -all -diff",
+			command: "template restore-defaults",
+			want: prompter.CmdArgs{
+				"-all":  {},
+				"-diff": {},
+			},
+		},
+		{
+			line:    "template capture -files ~/old/acme web -overwrite",
+			command: "template capture",
+			want: prompter.CmdArgs{
+				"_":          {"~/old/acme", "web"},
+				"-files":     {},
+				"-overwrite": {},
+			},
+		},
+		{
+			line:    "template test -update notes",
+			command: "template test",
+			want: prompter.CmdArgs{
+				"_":       {"notes"},
+				"-update": {},
+			},
+		},
+		{
+			line:    "template remove -force notes",
+			command: "template remove",
+			want: prompter.CmdArgs{
+				"_":      {"notes"},
+				"-force": {},
+			},
+		},
+		{
+			line:    "search saml -rebuild bypass",
+			command: "search",
+			want: prompter.CmdArgs{
+				"_":        {"saml", "bypass"},
+				"-rebuild": {},
+			},
+		},
+	}
+	for _, tt := range tests {
+		delete(got, tt.command)
+		comp.Execute(tt.line)
+		if !reflect.DeepEqual(got[tt.command], tt.want) {
+			t.Errorf("%q: args = %v, want %v", tt.line, got[tt.command], tt.want)
+		}
+	}
+}
@@ -0,0 +1,547 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	prompt "github.com/c-bata/go-prompt"
+	"github.com/parsiya/borrowedtime/config"
+	"github.com/parsiya/borrowedtime/project"
+	"github.com/parsiya/borrowedtime/shared"
+	"github.com/starkriedesel/prompter"
+)
+
+// Template command.
+
+// TemplateCmd returns the template command.
+func TemplateCmd() prompter.Command {
+
+	captureCmd := prompter.Command{
+		Name:        "capture",
+		Description: "create a project template from an existing directory",
+		Executor:    captureTemplateExecutor,
+	}
+	captureCmd.AddArguments(
+		switchArgument("-files", "(optional) turn small text files into file templates"),
+		prompter.Argument{
+			Name:              "-maxsize",
+			Description:       "(optional) largest file in bytes captured with -files",
+			ArgumentCompleter: captureCompleter,
+		},
+		prompter.Argument{
+			Name:              "-ignore",
+			Description:       "(optional) comma separated patterns to skip",
+			ArgumentCompleter: captureCompleter,
+		},
+		switchArgument("-overwrite", "(optional) overwrite existing templates"),
+		prompter.Argument{
+			Name:              " ",
+			Description:       "directory and template name - use \" for paths with spaces",
+			ArgumentCompleter: captureCompleter,
+		},
+	)
+
+	lintCmd := prompter.Command{
+		Name:        "lint",
+		Description: "check a project template and show its conditions",
+		Executor:    lintTemplateExecutor,
+	}
+	lintCmd.AddArguments(varArgument(), prompter.Argument{
+		Name:              "-template",
+		Description:       "project template name",
+		ArgumentCompleter: templateCompleter,
+	})
+
+	listCmd := prompter.Command{
+		Name:        "list",
+		Description: "list file, project and report templates",
+		Executor:    listTemplatesExecutor,
+	}
+
+	showCmd := prompter.Command{
+		Name:        "show",
+		Description: "print a template",
+		Executor:    showTemplateExecutor,
+	}
+	showCmd.AddArguments(kindArgument(), templateNameArgument())
+
+	addCmd := prompter.Command{
+		Name:        "add",
+		Description: "add a template from a file or stdin",
+		Executor:    addTemplateExecutor,
+	}
+	addCmd.AddArguments(kindArgument(),
+		prompter.Argument{
+			Name:        "-from",
+			Description: "(optional) path to the template file, otherwise read from stdin",
+		},
+		switchArgument("-overwrite", "(optional) overwrite an existing template"),
+		prompter.Argument{
+			Name:              " ",
+			Description:       "template name",
+			ArgumentCompleter: newTemplateCompleter,
+		},
+	)
+
+	removeCmd := prompter.Command{
+		Name:        "remove",
+		Description: "remove a template",
+		Executor:    removeTemplateExecutor,
+	}
+	removeCmd.AddArguments(kindArgument(),
+		switchArgument("-force", "(optional) remove the template even if it's referenced"),
+		templateNameArgument(),
+	)
+
+	renameCmd := prompter.Command{
+		Name:        "rename",
+		Description: "rename a template and update its references",
+		Executor:    renameTemplateExecutor,
+	}
+	renameCmd.AddArguments(kindArgument(), prompter.Argument{
+		Name:              " ",
+		Description:       "old and new template names",
+		ArgumentCompleter: templateNameCompleter,
+	})
+
+	editCmd := prompter.Command{
+		Name:        "edit",
+		Description: "open a template with the editor",
+		Executor:    editTemplateExecutor,
+	}
+	editCmd.AddArguments(kindArgument(), templateNameArgument())
+
+	testCmd := prompter.Command{
+		Name:        "test",
+		Description: "render templates with fixtures and compare with golden files",
+		Executor:    testTemplatesExecutor,
+	}
+	testCmd.AddArguments(
+		switchArgument("-update", "(optional) write the golden files"),
+		prompter.Argument{
+			Name:              " ",
+			Description:       "(optional) template name, all templates are tested by default",
+			ArgumentCompleter: templateNameCompleter,
+		},
+	)
+
+	restoreCmd := prompter.Command{
+		Name:        "restore-defaults",
+		Description: "compare templates with the defaults and restore them",
+		Executor:    restoreDefaultsExecutor,
+	}
+	restoreCmd.AddArguments(
+		switchArgument("-diff", "(optional) only show the diff"),
+		switchArgument("-all", "(optional) restore all modified and missing default templates"),
+		prompter.Argument{
+			Name:              " ",
+			Description:       "(optional) default template name",
+			ArgumentCompleter: defaultTemplateCompleter,
+		},
+	)
+
+	templateCmd := prompter.Command{
+		Name:        "template",
+		Description: "manage file, project and report templates",
+	}
+	templateCmd.AddSubCommands(listCmd, showCmd, addCmd, removeCmd, renameCmd,
+		editCmd, captureCmd, lintCmd, testCmd, restoreCmd)
+	return templateCmd
+}
+
+// kindArgument returns the -kind argument.
+func kindArgument() prompter.Argument {
+	return prompter.Argument{
+		Name:              "-kind",
+		Description:       "(optional) file, project or report, needed if templates of different kinds have the same name",
+		ArgumentCompleter: kindCompleter,
+	}
+}
+
+// templateNameArgument returns the argument for an existing template name.
+func templateNameArgument() prompter.Argument {
+	return prompter.Argument{
+		Name:              " ",
+		Description:       "template name",
+		ArgumentCompleter: templateNameCompleter,
+	}
+}
+
+// kindCompleter shows the template kinds.
+func kindCompleter(_ string, _ []string) []prompt.Suggest {
+	return []prompt.Suggest{
+		prompt.Suggest{Text: config.FileKind, Description: "templates/file"},
+		prompt.Suggest{Text: config.ProjectKind, Description: "templates/project"},
+		prompt.Suggest{Text: config.ReportKind, Description: "templates/report"},
+	}
+}
+
+// templateNameCompleter lists all templates.
+func templateNameCompleter(_ string, _ []string) []prompt.Suggest {
+	sugs := []prompt.Suggest{}
+	tmpls, err := config.Templates()
+	if err != nil {
+		return sugs
+	}
+	for _, t := range tmpls {
+		sugs = append(sugs, prompt.Suggest{
+			Text:        t.Name,
+			Description: t.Kind + ": " + t.FullPath,
+		})
+	}
+	return sugs
+}
+
+// newTemplateCompleter shows a sample suggestion for a new template name.
+func newTemplateCompleter(_ string, _ []string) []prompt.Suggest {
+	return []prompt.Suggest{
+		prompt.Suggest{
+			Text:        "template name",
+			Description: "Must be unique, json is added to project templates and md to file templates without an extension.",
+		},
+	}
+}
+
+// findTemplate returns the template passed to _ using the optional -kind.
+func findTemplate(args prompter.CmdArgs) (config.Template, error) {
+	name, err := args.GetFirstValue("_")
+	if err != nil {
+		return config.Template{}, fmt.Errorf("please provide template name")
+	}
+	kind, _ := args.GetFirstValue("-kind")
+	return config.FindTemplate(name, kind)
+}
+
+// listTemplatesExecutor prints a table of all templates.
+func listTemplatesExecutor(_ prompter.CmdArgs) error {
+	tmpls, err := config.Templates()
+	if err != nil {
+		return err
+	}
+	rows := [][]string{}
+	for _, t := range tmpls {
+		rows = append(rows, []string{t.Kind, t.Name, t.FullPath})
+	}
+	fmt.Println(Table(rows, false))
+	return nil
+}
+
+// showTemplateExecutor prints a template.
+func showTemplateExecutor(args prompter.CmdArgs) error {
+	t, err := findTemplate(args)
+	if err != nil {
+		return err
+	}
+	content, err := shared.ReadFileString(t.FullPath)
+	if err != nil {
+		return err
+	}
+	fmt.Println(content)
+	return nil
+}
+
+// addTemplateExecutor adds a template from -from or stdin. Stdin is read until
+// EOF or a line with only ".".
+func addTemplateExecutor(args prompter.CmdArgs) error {
+	name, err := args.GetFirstValue("_")
+	if err != nil {
+		return fmt.Errorf("template.addTemplateExecutor: please provide template name")
+	}
+	kind := config.FileKind
+	if args.Contains("-kind") {
+		if kind, err = args.GetFirstValue("-kind"); err != nil {
+			return err
+		}
+	}
+
+	var content string
+	if args.Contains("-from") {
+		from, err := args.GetFirstValue("-from")
+		if err != nil {
+			return err
+		}
+		if content, err = shared.ReadFileString(from); err != nil {
+			return err
+		}
+		// Keep the extension of the file if name does not have one.
+		if filepath.Ext(name) == "" {
+			name += filepath.Ext(from)
+		}
+	} else {
+		fmt.Println("Enter the template, end with a line containing only \".\" or EOF:")
+		var sb strings.Builder
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if scanner.Text() == "." {
+				break
+			}
+			sb.WriteString(scanner.Text() + "\n")
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+		content = sb.String()
+	}
+
+	pth, err := config.AddTemplate(kind, name, content, args.Contains("-overwrite"))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Added %s template %s.\n", kind, pth)
+	return nil
+}
+
+// removeTemplateExecutor removes a template. Templates referenced by project
+// templates or the config file are only removed with -force.
+func removeTemplateExecutor(args prompter.CmdArgs) error {
+	t, err := findTemplate(args)
+	if err != nil {
+		return err
+	}
+	refs, err := config.TemplateReferences(t)
+	if err != nil {
+		return err
+	}
+	if len(refs) > 0 && !args.Contains("-force") {
+		return fmt.Errorf("template.removeTemplateExecutor: %s is used by %s, use -force to remove it",
+			t.Name, strings.Join(refs, ", "))
+	}
+	if err := config.RemoveTemplate(t); err != nil {
+		return err
+	}
+	fmt.Printf("Removed %s template %s.\n", t.Kind, t.FullPath)
+	for _, ref := range refs {
+		fmt.Printf("Still referenced by: %s\n", ref)
+	}
+	return nil
+}
+
+// renameTemplateExecutor renames a template and updates its references.
+func renameTemplateExecutor(args prompter.CmdArgs) error {
+	if len(args["_"]) < 2 {
+		return fmt.Errorf("template.renameTemplateExecutor: please provide old and new template names")
+	}
+	oldName, newName := args["_"][0], args["_"][1]
+	kind, _ := args.GetFirstValue("-kind")
+	t, err := config.FindTemplate(oldName, kind)
+	if err != nil {
+		return err
+	}
+	refs, err := config.RenameTemplate(t, newName)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Renamed %s template %s to %s.\n", t.Kind, t.Name, newName)
+	for _, ref := range refs {
+		fmt.Printf("Updated: %s\n", ref)
+	}
+	// Projects record their template for sync.
+	if t.Kind == config.ProjectKind {
+		prjs, err := project.RenameTemplate(t.Name, newName)
+		if err != nil {
+			return err
+		}
+		for _, prj := range prjs {
+			fmt.Printf("Updated project: %s\n", prj)
+		}
+	}
+	return nil
+}
+
+// editTemplateExecutor opens a template with the editor in the config file.
+func editTemplateExecutor(args prompter.CmdArgs) error {
+	t, err := findTemplate(args)
+	if err != nil {
+		return err
+	}
+	return OpenWith(t.FullPath)
+}
+
+// captureCompleter shows sample suggestions for the capture command.
+func captureCompleter(optName string, _ []string) []prompt.Suggest {
+	sugs := []prompt.Suggest{}
+	switch optName {
+	case "-maxsize":
+		sugs = append(sugs, prompt.Suggest{
+			Text:        strconv.Itoa(project.DefaultCaptureMaxSize),
+			Description: "default",
+		})
+	case "-ignore":
+		sugs = append(sugs, prompt.Suggest{
+			Text:        strings.Join(captureIgnore(), ","),
+			Description: "default",
+		})
+	default:
+		sugs = append(sugs, prompt.Suggest{
+			Text:        "directory template-name",
+			Description: "Template name must be unique.",
+		})
+	}
+	return sugs
+}
+
+// captureIgnore returns the ignore list from the config file or the default
+// list if it's not set.
+func captureIgnore() []string {
+	cfg, err := config.Read()
+	if err != nil || !cfg.Has("captureignore") {
+		return project.DefaultCaptureIgnore
+	}
+	return strings.Split(cfg.Key("captureignore"), ",")
+}
+
+// captureTemplateExecutor captures a directory as a project template.
+func captureTemplateExecutor(args prompter.CmdArgs) error {
+	// Directory and template name are passed to _.
+	if len(args["_"]) < 2 {
+		return fmt.Errorf("template.captureTemplateExecutor: please provide directory and template name")
+	}
+	dir, name := args["_"][0], args["_"][1]
+
+	opts := project.CaptureOptions{
+		Files:     args.Contains("-files"),
+		Ignore:    captureIgnore(),
+		Overwrite: args.Contains("-overwrite"),
+	}
+	if args.Contains("-maxsize") {
+		size, err := args.GetFirstValue("-maxsize")
+		if err != nil {
+			return err
+		}
+		opts.MaxSize, err = strconv.ParseInt(size, 10, 64)
+		if err != nil {
+			return fmt.Errorf("template.captureTemplateExecutor: invalid -maxsize - %s", err.Error())
+		}
+	}
+	if args.Contains("-ignore") {
+		ignore, err := args.GetFirstValue("-ignore")
+		if err != nil {
+			return err
+		}
+		opts.Ignore = append(opts.Ignore, strings.Split(ignore, ",")...)
+	}
+
+	cpt, err := project.CaptureTemplate(dir, name, opts)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created project template %s with %d file template(s).\n", name, len(cpt.FileTemplates))
+	for _, ig := range cpt.Ignored {
+		fmt.Printf("Ignored: %s\n", ig)
+	}
+	return nil
+}
+
+// lintTemplateExecutor checks a project template. Conditions are evaluated for
+// a sample project with the variables passed with -var.
+func lintTemplateExecutor(args prompter.CmdArgs) error {
+	templateName, err := args.GetFirstValue("-template")
+	if err != nil {
+		return fmt.Errorf("template.lintTemplateExecutor: please provide -template")
+	}
+	prj := project.New("lint")
+	prj.Vars, err = parseVars(args["-var"])
+	if err != nil {
+		return err
+	}
+	report, errs, err := prj.Lint(templateName)
+	if err != nil {
+		return err
+	}
+	fmt.Print(report)
+	if errs > 0 {
+		return fmt.Errorf("template.lintTemplateExecutor: %s has %d error(s)", templateName, errs)
+	}
+	return nil
+}
+
+// testTemplatesExecutor tests templates against their golden files.
+func testTemplatesExecutor(args prompter.CmdArgs) error {
+	// Template name is optional.
+	name, _ := args.GetFirstValue("_")
+	results, err := project.RunTemplateTests(name, args.Contains("-update"))
+	if err != nil {
+		return err
+	}
+	failed := 0
+	rows := [][]string{}
+	for _, res := range results {
+		rows = append(rows, []string{"[" + res.Status + "]", res.Fixture, res.Kind, res.Name})
+		switch res.Status {
+		case project.TestFail, project.TestMissing, project.TestError:
+			failed++
+		}
+	}
+	fmt.Println(Table(rows, false))
+	for _, res := range results {
+		if res.Details != "" {
+			fmt.Printf("%s %s/%s/%s:\n%s\n", res.Status, res.Fixture, res.Kind, res.Name, res.Details)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("template.testTemplatesExecutor: %d of %d test(s) failed, use -update to accept the output", failed, len(results))
+	}
+	return nil
+}
+
+// defaultTemplateCompleter lists the default templates.
+func defaultTemplateCompleter(_ string, _ []string) []prompt.Suggest {
+	sugs := []prompt.Suggest{}
+	tmpls, err := config.DefaultTemplates()
+	if err != nil {
+		return sugs
+	}
+	for _, t := range tmpls {
+		sugs = append(sugs, prompt.Suggest{Text: t.Name, Description: t.Kind})
+	}
+	return sugs
+}
+
+// restoreDefaultsExecutor shows the difference between the default templates
+// and the user's copies. Templates passed by name or all with -all are
+// restored after a backup is created.
+func restoreDefaultsExecutor(args prompter.CmdArgs) error {
+	name, _ := args.GetFirstValue("_")
+	diffs, err := config.DiffDefaults(name)
+	if err != nil {
+		return err
+	}
+	restore := (name != "" || args.Contains("-all")) && !args.Contains("-diff")
+
+	var changed []config.DefaultDiff
+	for _, d := range diffs {
+		switch {
+		case d.Path == "":
+			fmt.Printf("[missing]  %s %s\n", d.Default.Kind, d.Default.Name)
+		case d.Diff == "":
+			fmt.Printf("[default]  %s %s\n", d.Default.Kind, d.Default.Name)
+			continue
+		default:
+			fmt.Printf("[modified] %s %s - %s\n%s", d.Default.Kind, d.Default.Name, d.Path, d.Diff)
+		}
+		changed = append(changed, d)
+	}
+	if !restore || len(changed) == 0 {
+		return nil
+	}
+
+	// Modified templates are overwritten, keep a copy.
+	backupFile := time.Now().Format("2006-01-02-15-04-05") + "-restore-defaults"
+	if err := config.Backup(backupFile); err != nil {
+		return err
+	}
+	fmt.Printf("Created backup %s.\n", backupFile)
+	for _, d := range changed {
+		pth, err := config.RestoreDefault(d)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Restored %s.\n", pth)
+	}
+	return nil
+}
@@ -7,9 +7,12 @@ import (
 	"strings"
 
 	xj "github.com/basgys/goxml2json"
+	prompt "github.com/c-bata/go-prompt"
 	"github.com/olekukonko/tablewriter"
 	"github.com/parsiya/borrowedtime/config"
+	"github.com/parsiya/borrowedtime/project"
 	"github.com/parsiya/borrowedtime/shared"
+	"github.com/starkriedesel/prompter"
 )
 
 // Utilities.
@@ -60,7 +63,24 @@ func OpenProject(projectName string) error {
 	if err != nil {
 		return err
 	}
-	return OpenWith(prjPath)
+	if err := OpenWith(prjPath); err != nil {
+		return err
+	}
+	// Projects without a project config only run the hook in the workspace
+	// config.
+	prj := project.New(projectName)
+	hasConfig, err := prj.HasConfig()
+	if err != nil {
+		return err
+	}
+	if hasConfig {
+		if prj, err = project.Load(projectName); err != nil {
+			return err
+		}
+	}
+	out, err := prj.PostOpen()
+	fmt.Print(out)
+	return err
 }
 
 // projectPath returns the path to projectName's directory.
@@ -87,6 +107,39 @@ func workspacePath() (string, error) {
 	return cfg.Key("workspace"), nil
 }
 
+// parseVars converts "key=value" pairs passed with -var to a map.
+func parseVars(values []string) (map[string]string, error) {
+	vars := make(map[string]string)
+	for _, v := range values {
+		kv := strings.SplitN(v, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return vars, fmt.Errorf("invalid variable %q, use key=value", v)
+		}
+		vars[kv[0]] = kv[1]
+	}
+	return vars, nil
+}
+
+// varArgument returns the -var argument.
+func varArgument() prompter.Argument {
+	return prompter.Argument{
+		Name:              "-var",
+		Description:       "(optional) template variable as key=value, can be repeated",
+		ArgumentCompleter: varCompleter,
+		Repeatable:        true,
+	}
+}
+
+// varCompleter shows a sample suggestion for -var.
+func varCompleter(_ string, _ []string) []prompt.Suggest {
+	return []prompt.Suggest{
+		prompt.Suggest{
+			Text:        "key=value",
+			Description: "available in templates as .Vars.key",
+		},
+	}
+}
+
 // XMLToJSON converts an xml file to JSON.
 func XMLToJSON(xmlString string) (string, error) {
 	xml := strings.NewReader(xmlString)
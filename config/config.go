@@ -74,20 +74,10 @@ func initiateConfig(overwrite bool) error {
 		return fmt.Errorf("config.initiateConfig: create backups directory - %s", err.Error())
 	}
 
-	// Copy default templates and configurations to populate templates.
-	// Add everything from defaultFileTemplates.
-	for name, content := range defaultFileTemplates {
-		err = addFileTemplate(name, content, true)
-		if err != nil {
-			return fmt.Errorf("config.initiateConfig: add template %s - %s", name, err.Error())
-		}
-	}
-	// Add everything from defaultProjectTemplates.
-	for name, content := range defaultProjectTemplates {
-		err = addFileTemplate(name, content, true)
-		if err != nil {
-			return fmt.Errorf("config.initiateConfig: add template %s - %s", name, err.Error())
-		}
+	// Copy default templates to populate templates. File and project templates
+	// are created in their own directories.
+	if err := writeDefaults(); err != nil {
+		return fmt.Errorf("config.initiateConfig: %s", err.Error())
 	}
 
 	// Create data directory and copy data files if any.
@@ -329,6 +319,26 @@ func backupDir() (string, error) {
 	return filepath.Join(configDir, "backups"), nil
 }
 
+// archiveDir returns the directory of archived projects.
+// "homedir/borrowedtime/archives" or "ConfigDir/archives"
+func archiveDir() (string, error) {
+	configDir, err := configDir()
+	if err != nil {
+		return "", fmt.Errorf("config.archiveDir: %s", err.Error())
+	}
+	return filepath.Join(configDir, "archives"), nil
+}
+
+// trashDir returns the directory of deleted projects.
+// "homedir/borrowedtime/trash" or "ConfigDir/trash"
+func trashDir() (string, error) {
+	configDir, err := configDir()
+	if err != nil {
+		return "", fmt.Errorf("config.trashDir: %s", err.Error())
+	}
+	return filepath.Join(configDir, "trash"), nil
+}
+
 // DataDir returns the data directory.
 // "homedir/borrowedtime/data" or "ConfigDir/data"
 func dataDir() (string, error) {
@@ -378,7 +388,32 @@ func Edit(editor string) error {
 	return shared.OpenWithEditor(editor, cfg, cfgDir)
 }
 
+// DataDir is the exported version of dataDir.
+func DataDir() (string, error) {
+	return dataDir()
+}
+
+// DataFiles returns map[filename]fullpath of all files inside the data
+// directory. Similar to templates, filenames do not have extensions.
+func DataFiles() (map[string]string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return nil, err
+	}
+	return templateMap(dir, "*")
+}
+
 // ConfigDir is the exported version of configDir.
 func ConfigDir() (string, error) {
 	return configDir()
 }
+
+// ArchiveDir is the exported version of archiveDir.
+func ArchiveDir() (string, error) {
+	return archiveDir()
+}
+
+// TrashDir is the exported version of trashDir.
+func TrashDir() (string, error) {
+	return trashDir()
+}
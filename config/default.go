@@ -1,5 +1,16 @@
 package config
 
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/parsiya/borrowedtime/shared"
+)
+
 // Default config.
 const (
 	// TODO: Convert this to template and pass a config struct.
@@ -9,7 +20,12 @@ const (
 	"workspace":"",
 	"projectstructure":"",
 	"burppath":"",
-	"yourname":""
+	"yourname":"",
+	"post-create":"",
+	"post-open":"",
+	"hookfailure":"keep",
+	"trashdays":"30",
+	"evidencedirs":"@pix,@clientFiles"
 }`
 
 	// Default workspace config template.
@@ -17,18 +33,129 @@ const (
 )
 
 // Default templates.
-// Create content as a const string to "defaultTemplates.go" and add the names
-// to this map to get them created in initConfig.
-
-// Default file templates.
-var defaultFileTemplates = map[string]string{
-	"notes.md":          defaultNoteTemplate,
-	"creds.md":          defaultCredsTemplate,
-	"project-config.md": defaultProjectConfig,
-	"todo.md":           defaultTODOTemplate,
+// Add new default templates to "defaults/file", "defaults/project" or
+// "defaults/report" and they are created in initConfig.
+
+//go:embed defaults
+var defaults embed.FS
+
+// defaultsDir is the root of the default templates in defaults.
+const defaultsDir = "defaults"
+
+// DefaultTemplates returns the templates shipped with borrowed time sorted by
+// kind and name. FullPath is the path inside the embedded defaults.
+func DefaultTemplates() ([]Template, error) {
+	var tmpls []Template
+	for _, kind := range Kinds {
+		entries, err := fs.ReadDir(defaults, path.Join(defaultsDir, kind))
+		if err != nil {
+			return nil, fmt.Errorf("config.DefaultTemplates: %s", err.Error())
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			tmpls = append(tmpls, Template{
+				Name:     shared.RemoveExtension(e.Name()),
+				FullPath: path.Join(defaultsDir, kind, e.Name()),
+				Kind:     kind,
+			})
+		}
+	}
+	return tmpls, nil
+}
+
+// DefaultContent returns the content of a default template.
+func DefaultContent(t Template) (string, error) {
+	content, err := defaults.ReadFile(t.FullPath)
+	if err != nil {
+		return "", fmt.Errorf("config.DefaultContent: %s", err.Error())
+	}
+	return string(content), nil
+}
+
+// DefaultDiff compares a default template with the user's copy.
+type DefaultDiff struct {
+	Default Template
+	// Path is the user's copy. It's empty if the template is missing.
+	Path string
+	// Diff is the line diff from the user's copy to the default. It's empty
+	// if they are the same.
+	Diff string
+}
+
+// DiffDefaults compares the default templates with the user's copies. If name
+// is not empty, only templates with that name are compared.
+func DiffDefaults(name string) ([]DefaultDiff, error) {
+	tmpls, err := DefaultTemplates()
+	if err != nil {
+		return nil, fmt.Errorf("config.DiffDefaults: %s", err.Error())
+	}
+	var diffs []DefaultDiff
+	for _, t := range tmpls {
+		if name != "" && t.Name != shared.RemoveExtension(name) {
+			continue
+		}
+		content, err := DefaultContent(t)
+		if err != nil {
+			return nil, fmt.Errorf("config.DiffDefaults: %s", err.Error())
+		}
+		d := DefaultDiff{Default: t}
+		// The user's copy can be anywhere in the kind's directory.
+		if user, err := FindTemplate(t.Name, t.Kind); err == nil {
+			userContent, err := shared.ReadFileString(user.FullPath)
+			if err != nil {
+				return nil, fmt.Errorf("config.DiffDefaults: %s", err.Error())
+			}
+			d.Path, d.Diff = user.FullPath, shared.Diff(userContent, content, 2)
+		}
+		diffs = append(diffs, d)
+	}
+	if name != "" && len(diffs) == 0 {
+		return nil, fmt.Errorf("config.DiffDefaults: %s is not a default template", name)
+	}
+	return diffs, nil
+}
+
+// RestoreDefault overwrites the user's copy of a default template or creates
+// it if it's missing. Returns the path to the restored template.
+func RestoreDefault(d DefaultDiff) (string, error) {
+	content, err := DefaultContent(d.Default)
+	if err != nil {
+		return "", fmt.Errorf("config.RestoreDefault: %s", err.Error())
+	}
+	pth := d.Path
+	if pth == "" {
+		dir, err := kindDir(d.Default.Kind)
+		if err != nil {
+			return "", fmt.Errorf("config.RestoreDefault: %s", err.Error())
+		}
+		pth = filepath.Join(dir, path.Base(d.Default.FullPath))
+	}
+	if err := shared.WriteFileString(pth, content, true); err != nil {
+		return "", fmt.Errorf("config.RestoreDefault: %s", err.Error())
+	}
+	return pth, nil
 }
 
-// Default project templates.
-var defaultProjectTemplates = map[string]string{
-	"project-structure.json": defaultProjectStructure,
+// writeDefaults creates all default templates in the templates directory and
+// overwrites existing files.
+func writeDefaults() error {
+	tmpls, err := DefaultTemplates()
+	if err != nil {
+		return err
+	}
+	for _, t := range tmpls {
+		dir, err := kindDir(t.Kind)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return err
+		}
+		if _, err := RestoreDefault(DefaultDiff{Default: t}); err != nil {
+			return fmt.Errorf("add template %s - %s", t.Name, err.Error())
+		}
+	}
+	return nil
 }
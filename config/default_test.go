@@ -0,0 +1,73 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/parsiya/borrowedtime/shared"
+)
+
+func TestDiffDefaults(t *testing.T) {
+	testHome(t)
+	notes, err := FindTemplate("notes", FileKind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shared.WriteFileString(notes.FullPath, "my notes\n", true); err != nil {
+		t.Fatal(err)
+	}
+	todo, err := FindTemplate("todo", FileKind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(todo.FullPath); err != nil {
+		t.Fatal(err)
+	}
+
+	diffs, err := DiffDefaults("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaults, err := DefaultTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != len(defaults) {
+		t.Errorf("DiffDefaults() returned %d templates, want %d", len(diffs), len(defaults))
+	}
+	for _, d := range diffs {
+		switch d.Default.Name {
+		case "notes":
+			if d.Path != notes.FullPath || !strings.Contains(d.Diff, "my notes") {
+				t.Errorf("DiffDefaults() did not report notes as modified: %+v", d)
+			}
+		case "todo":
+			if d.Path != "" {
+				t.Errorf("DiffDefaults() did not report todo as missing: %+v", d)
+			}
+		default:
+			if d.Path == "" || d.Diff != "" {
+				t.Errorf("DiffDefaults() reported %s as changed: %+v", d.Default.Name, d)
+			}
+		}
+	}
+
+	// Restore the modified template.
+	diffs, err = DiffDefaults("notes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 1 {
+		t.Fatalf("DiffDefaults(notes) returned %d templates, want 1", len(diffs))
+	}
+	if _, err := RestoreDefault(diffs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if diffs, err = DiffDefaults("notes"); err != nil || diffs[0].Diff != "" {
+		t.Errorf("RestoreDefault() did not restore notes: %+v - %v", diffs, err)
+	}
+	if _, err := DiffDefaults("not-a-default"); err == nil {
+		t.Error("DiffDefaults() did not return an error for a template that is not a default")
+	}
+}
@@ -2,15 +2,31 @@ package config
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"regexp"
 
 	"github.com/parsiya/borrowedtime/shared"
 )
 
+// Template kinds.
+const (
+	// FileKind templates are in "templates/file".
+	FileKind = "file"
+	// ProjectKind templates are in "templates/project".
+	ProjectKind = "project"
+	// ReportKind templates are in "templates/report".
+	ReportKind = "report"
+)
+
+// Kinds are the template kinds.
+var Kinds = []string{FileKind, ProjectKind, ReportKind}
+
 // Template represents one template.
 type Template struct {
 	Name     string `json:"name"`
 	FullPath string `json:"fullpath"`
+	Kind     string `json:"kind"`
 }
 
 // templateDir returns the templates directory.
@@ -44,6 +60,16 @@ func projectTemplateDir() (string, error) {
 	return filepath.Join(configDir, "templates/project"), nil
 }
 
+// reportTemplateDir returns the "templates/report" directory.
+// "homedir/borrowedtime/templates/report" or "configDir/templates/report"
+func reportTemplateDir() (string, error) {
+	configDir, err := configDir()
+	if err != nil {
+		return "", fmt.Errorf("config.TemplateDir: %s", err.Error())
+	}
+	return filepath.Join(configDir, "templates/report"), nil
+}
+
 // FileTemplates returns returns map[filename]fullpath of all files inside the
 // file template directory.
 func FileTemplates() (mp map[string]string, err error) {
@@ -66,6 +92,21 @@ func ProjectTemplates() (mp map[string]string, err error) {
 	return templateMap(dir, "*")
 }
 
+// ReportTemplates returns map[filename]fullpath of all files inside the
+// report template directory. It's empty if the directory does not exist.
+func ReportTemplates() (map[string]string, error) {
+	dir, err := reportTemplateDir()
+	if err != nil {
+		return nil, err
+	}
+	// Report templates were added later and older configs do not have the
+	// directory.
+	if exists, err := shared.PathExists(dir); err != nil || !exists {
+		return map[string]string{}, err
+	}
+	return templateMap(dir, "*")
+}
+
 // templateMap creates and returns a map[TemplateName]FullPath of files matching
 // pattern in root. Pattern is the typical "shell file name pattern" (e.g. *.exe
 // or * to list all files).
@@ -124,18 +165,19 @@ func addFileTemplate(name, content string, overwrite bool) error {
 	return shared.WriteFileString(filepath.Join(dir, name), content, overwrite)
 }
 
-// addTemplate adds a new template to the root of templates directory.
-func addTemplate(name, content string, overwrite bool) error {
+// AddFileTemplate is the exported version of addFileTemplate.
+func AddFileTemplate(name, content string, overwrite bool) error {
+	return addFileTemplate(name, content, overwrite)
+}
 
-	// Write template to file.
-	tmplDir, _ := templateDir()
-	pa := filepath.Join(tmplDir, name)
-	err := shared.WriteFileString(pa, content, true)
-	// Can replace with "return shared.WriteFileString" if we do not want the custom error message.
+// AddProjectTemplate adds a new project template to "templates/project".
+func AddProjectTemplate(name, content string, overwrite bool) error {
+	// Write the template to file.
+	dir, err := projectTemplateDir()
 	if err != nil {
-		return fmt.Errorf("config.AddTemplate: %s", err.Error())
+		return err
 	}
-	return nil
+	return shared.WriteFileString(filepath.Join(dir, name), content, overwrite)
 }
 
 // listTemplates creates a list of all files regardless of hierarchy under a
@@ -157,3 +199,201 @@ func listTemplates(root string) (index []Template, err error) {
 	}
 	return index, err
 }
+
+// kindDir returns the template directory for a kind.
+func kindDir(kind string) (string, error) {
+	switch kind {
+	case FileKind:
+		return fileTemplateDir()
+	case ProjectKind:
+		return projectTemplateDir()
+	case ReportKind:
+		return reportTemplateDir()
+	}
+	return "", fmt.Errorf("config.kindDir: invalid template kind %q, use %s, %s or %s",
+		kind, FileKind, ProjectKind, ReportKind)
+}
+
+// Templates returns all file, project and report templates sorted by kind and
+// name.
+func Templates() ([]Template, error) {
+	var tmpls []Template
+	for _, kind := range Kinds {
+		var mp map[string]string
+		var err error
+		if kind == ReportKind {
+			mp, err = ReportTemplates()
+		} else {
+			var dir string
+			if dir, err = kindDir(kind); err == nil {
+				mp, err = templateMap(dir, "*")
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("config.Templates: %s", err.Error())
+		}
+		for _, name := range shared.SortedKeys(mp) {
+			tmpls = append(tmpls, Template{Name: name, FullPath: mp[name], Kind: kind})
+		}
+	}
+	return tmpls, nil
+}
+
+// FindTemplate returns the template with name. If kind is empty, templates of
+// all kinds are searched and an error is returned if more than one has that
+// name.
+func FindTemplate(name, kind string) (Template, error) {
+	name = shared.RemoveExtension(name)
+	tmpls, err := Templates()
+	if err != nil {
+		return Template{}, fmt.Errorf("config.FindTemplate: %s", err.Error())
+	}
+	var found []Template
+	for _, t := range tmpls {
+		if t.Name == name && (kind == "" || t.Kind == kind) {
+			found = append(found, t)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Template{}, fmt.Errorf("config.FindTemplate: template %s not found", name)
+	case 1:
+		return found[0], nil
+	}
+	return Template{}, fmt.Errorf("config.FindTemplate: more than one template is named %s, pass the kind", name)
+}
+
+// AddTemplate adds a new template of kind. If name does not have an extension,
+// "json" is used for project templates and "md" for file templates. Returns
+// the path to the new template.
+func AddTemplate(kind, name, content string, overwrite bool) (string, error) {
+	dir, err := kindDir(kind)
+	if err != nil {
+		return "", fmt.Errorf("config.AddTemplate: %s", err.Error())
+	}
+	if filepath.Ext(name) == "" {
+		if kind == ProjectKind {
+			name = shared.AddExtension(name, "json")
+		} else {
+			name = shared.AddExtension(name, "md")
+		}
+	}
+	// Template names are unique per kind regardless of the extension.
+	if t, err := FindTemplate(name, kind); err == nil && !overwrite {
+		return "", fmt.Errorf("config.AddTemplate: template %s already exists at %s", t.Name, t.FullPath)
+	}
+	pth := filepath.Join(dir, name)
+	// The report directory does not exist in configs deployed before reports.
+	if err := os.MkdirAll(filepath.Dir(pth), os.ModePerm); err != nil {
+		return "", fmt.Errorf("config.AddTemplate: %s", err.Error())
+	}
+	if err := shared.WriteFileString(pth, content, overwrite); err != nil {
+		return "", fmt.Errorf("config.AddTemplate: %s", err.Error())
+	}
+	return pth, nil
+}
+
+// RemoveTemplate deletes the template file.
+func RemoveTemplate(t Template) error {
+	if err := os.Remove(t.FullPath); err != nil {
+		return fmt.Errorf("config.RemoveTemplate: %s", err.Error())
+	}
+	return nil
+}
+
+// templateReference matches "template": "name" in a project template.
+func templateReference(name string) *regexp.Regexp {
+	return regexp.MustCompile(`("template"\s*:\s*)"` + regexp.QuoteMeta(name) + `"`)
+}
+
+// TemplateReferences returns the names of project templates that reference
+// the template. File templates are referenced by nodes in project templates.
+// Project templates are referenced by "projectstructure" in the config file
+// which is returned as "config.json". Report templates are not referenced.
+func TemplateReferences(t Template) ([]string, error) {
+	var refs []string
+	if t.Kind == ReportKind {
+		return refs, nil
+	}
+	if t.Kind == ProjectKind {
+		cfg, err := Read()
+		if err != nil {
+			return nil, fmt.Errorf("config.TemplateReferences: %s", err.Error())
+		}
+		if shared.RemoveExtension(cfg.Key("projectstructure")) == t.Name {
+			refs = append(refs, defaultConfigFilename)
+		}
+		return refs, nil
+	}
+	prjTmpls, err := ProjectTemplates()
+	if err != nil {
+		return nil, fmt.Errorf("config.TemplateReferences: %s", err.Error())
+	}
+	re := templateReference(t.Name)
+	for _, name := range shared.SortedKeys(prjTmpls) {
+		content, err := shared.ReadFileString(prjTmpls[name])
+		if err != nil {
+			return nil, fmt.Errorf("config.TemplateReferences: %s", err.Error())
+		}
+		if re.MatchString(content) {
+			refs = append(refs, name)
+		}
+	}
+	return refs, nil
+}
+
+// RenameTemplate renames the template and keeps its extension. References to a
+// file template in project templates and a project template in
+// "projectstructure" are updated. Returns the updated references.
+func RenameTemplate(t Template, newName string) ([]string, error) {
+	newName = shared.RemoveExtension(newName)
+	if newName == "" {
+		return nil, fmt.Errorf("config.RenameTemplate: empty template name")
+	}
+	if _, err := FindTemplate(newName, t.Kind); err == nil {
+		return nil, fmt.Errorf("config.RenameTemplate: template %s already exists", newName)
+	}
+	refs, err := TemplateReferences(t)
+	if err != nil {
+		return nil, fmt.Errorf("config.RenameTemplate: %s", err.Error())
+	}
+	newPath := filepath.Join(filepath.Dir(t.FullPath), newName+filepath.Ext(t.FullPath))
+	if err := os.Rename(t.FullPath, newPath); err != nil {
+		return nil, fmt.Errorf("config.RenameTemplate: %s", err.Error())
+	}
+
+	if t.Kind == ProjectKind {
+		if len(refs) > 0 {
+			cfg, err := Read()
+			if err != nil {
+				return nil, fmt.Errorf("config.RenameTemplate: %s", err.Error())
+			}
+			cfg.Set("projectstructure", newName)
+			if err := Write(cfg); err != nil {
+				return nil, fmt.Errorf("config.RenameTemplate: %s", err.Error())
+			}
+		}
+		return refs, nil
+	}
+	prjTmpls, err := ProjectTemplates()
+	if err != nil {
+		return nil, fmt.Errorf("config.RenameTemplate: %s", err.Error())
+	}
+	re := templateReference(t.Name)
+	for _, name := range refs {
+		content, err := shared.ReadFileString(prjTmpls[name])
+		if err != nil {
+			return nil, fmt.Errorf("config.RenameTemplate: %s", err.Error())
+		}
+		content = re.ReplaceAllString(content, `${1}"`+newName+`"`)
+		if err := shared.WriteFileString(prjTmpls[name], content, true); err != nil {
+			return nil, fmt.Errorf("config.RenameTemplate: %s", err.Error())
+		}
+	}
+	return refs, nil
+}
+
+// TemplateDir is the exported version of templateDir.
+func TemplateDir() (string, error) {
+	return templateDir()
+}
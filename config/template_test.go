@@ -0,0 +1,165 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/parsiya/borrowedtime/shared"
+)
+
+// testHome deploys the config to a temporary home directory.
+func testHome(t *testing.T) string {
+	home := t.TempDir()
+	old, cache := os.Getenv("HOME"), homedir.DisableCache
+	os.Setenv("HOME", home)
+	homedir.DisableCache = true
+	t.Cleanup(func() {
+		os.Setenv("HOME", old)
+		// Dir caches the temporary home even if the cache is disabled.
+		homedir.Dir()
+		homedir.DisableCache = cache
+	})
+	if err := Deploy(); err != nil {
+		t.Fatal(err)
+	}
+	return home
+}
+
+func TestTemplates(t *testing.T) {
+	testHome(t)
+	tmpls, err := Templates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaults, err := DefaultTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tmpls) != len(defaults) {
+		t.Errorf("Templates() returned %d templates, want %d", len(tmpls), len(defaults))
+	}
+	for _, want := range []struct{ name, kind string }{
+		{"project-structure", ProjectKind},
+		{"notes", FileKind},
+		{"markdown", ReportKind},
+	} {
+		if _, err := FindTemplate(want.name, want.kind); err != nil {
+			t.Errorf("FindTemplate(%q, %q) error = %v", want.name, want.kind, err)
+		}
+	}
+}
+
+func TestTemplateReferences(t *testing.T) {
+	testHome(t)
+	// "notes-old" must not be a reference to "notes".
+	if _, err := AddTemplate(ProjectKind, "other",
+		`{"name": "x", "children": [{"name": "a", "template":"notes"}, {"name": "b", "template": "notes-old"}]}`, false); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, kind string
+		want       []string
+	}{
+		{"notes", FileKind, []string{"other", "project-structure"}},
+		{"todo", FileKind, []string{"project-structure"}},
+		{"creds", FileKind, []string{"project-structure"}},
+		{"project-structure", ProjectKind, []string{defaultConfigFilename}},
+		{"other", ProjectKind, nil},
+		{"markdown", ReportKind, nil},
+	}
+	for _, tt := range tests {
+		tmpl, err := FindTemplate(tt.name, tt.kind)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := TemplateReferences(tmpl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("TemplateReferences(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRenameTemplate(t *testing.T) {
+	testHome(t)
+	if _, err := AddTemplate(ProjectKind, "other",
+		`{"name": "x", "children": [{"name": "a", "template":"notes"}, {"name": "b", "template": "notes-old"}]}`, false); err != nil {
+		t.Fatal(err)
+	}
+	notes, err := FindTemplate("notes", FileKind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RenameTemplate(notes, "todo"); err == nil {
+		t.Error("RenameTemplate() did not return an error for an existing name")
+	}
+	refs, err := RenameTemplate(notes, "notes2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"other", "project-structure"}; !reflect.DeepEqual(refs, want) {
+		t.Errorf("RenameTemplate() = %q, want %q", refs, want)
+	}
+	renamed, err := FindTemplate("notes2", FileKind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Ext(renamed.FullPath) != filepath.Ext(notes.FullPath) {
+		t.Errorf("RenameTemplate() changed the extension to %s", renamed.FullPath)
+	}
+	other, err := FindTemplate("other", ProjectKind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := shared.ReadFileString(other.FullPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(content, `"template":"notes2"`) || !strings.Contains(content, `"template": "notes-old"`) {
+		t.Errorf("RenameTemplate() did not update the reference: %s", content)
+	}
+
+	// Renaming the project structure updates the config.
+	prj, err := FindTemplate("project-structure", ProjectKind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RenameTemplate(prj, "web"); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Key("projectstructure"); got != "web" {
+		t.Errorf("projectstructure = %q, want web", got)
+	}
+}
+
+func TestAddTemplate(t *testing.T) {
+	testHome(t)
+	// Configs deployed before report templates do not have the directory.
+	dir, err := kindDir(ReportKind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	pth, err := AddTemplate(ReportKind, "summary", "{{ .Title }}", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "summary.md"); pth != want {
+		t.Errorf("AddTemplate() = %s, want %s", pth, want)
+	}
+	if _, err := AddTemplate(ReportKind, "summary", "", false); err == nil {
+		t.Error("AddTemplate() did not return an error for an existing template")
+	}
+}
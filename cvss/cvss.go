@@ -0,0 +1,257 @@
+package cvss
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Supported CVSS versions.
+const (
+	Version31 = "3.1"
+	Version40 = "4.0"
+)
+
+// metric is one metric in a vector string. Values are in the order of the
+// specification. Optional metrics start with X (not defined).
+type metric struct {
+	name     string
+	values   []string
+	required bool
+}
+
+// metrics31 are the v3.1 metrics in the order of the specification.
+var metrics31 = []metric{
+	{"AV", []string{"N", "A", "L", "P"}, true},
+	{"AC", []string{"L", "H"}, true},
+	{"PR", []string{"N", "L", "H"}, true},
+	{"UI", []string{"N", "R"}, true},
+	{"S", []string{"U", "C"}, true},
+	{"C", []string{"H", "L", "N"}, true},
+	{"I", []string{"H", "L", "N"}, true},
+	{"A", []string{"H", "L", "N"}, true},
+	// Temporal.
+	{"E", []string{"X", "H", "F", "P", "U"}, false},
+	{"RL", []string{"X", "U", "W", "T", "O"}, false},
+	{"RC", []string{"X", "C", "R", "U"}, false},
+	// Environmental.
+	{"CR", []string{"X", "H", "M", "L"}, false},
+	{"IR", []string{"X", "H", "M", "L"}, false},
+	{"AR", []string{"X", "H", "M", "L"}, false},
+	{"MAV", []string{"X", "N", "A", "L", "P"}, false},
+	{"MAC", []string{"X", "L", "H"}, false},
+	{"MPR", []string{"X", "N", "L", "H"}, false},
+	{"MUI", []string{"X", "N", "R"}, false},
+	{"MS", []string{"X", "U", "C"}, false},
+	{"MC", []string{"X", "H", "L", "N"}, false},
+	{"MI", []string{"X", "H", "L", "N"}, false},
+	{"MA", []string{"X", "H", "L", "N"}, false},
+}
+
+// metrics40 are the v4.0 metrics in the order of the specification.
+var metrics40 = []metric{
+	{"AV", []string{"N", "A", "L", "P"}, true},
+	{"AC", []string{"L", "H"}, true},
+	{"AT", []string{"N", "P"}, true},
+	{"PR", []string{"N", "L", "H"}, true},
+	{"UI", []string{"N", "P", "A"}, true},
+	{"VC", []string{"H", "L", "N"}, true},
+	{"VI", []string{"H", "L", "N"}, true},
+	{"VA", []string{"H", "L", "N"}, true},
+	{"SC", []string{"H", "L", "N"}, true},
+	{"SI", []string{"H", "L", "N"}, true},
+	{"SA", []string{"H", "L", "N"}, true},
+	// Threat.
+	{"E", []string{"X", "A", "P", "U"}, false},
+	// Environmental.
+	{"CR", []string{"X", "H", "M", "L"}, false},
+	{"IR", []string{"X", "H", "M", "L"}, false},
+	{"AR", []string{"X", "H", "M", "L"}, false},
+	{"MAV", []string{"X", "N", "A", "L", "P"}, false},
+	{"MAC", []string{"X", "L", "H"}, false},
+	{"MAT", []string{"X", "N", "P"}, false},
+	{"MPR", []string{"X", "N", "L", "H"}, false},
+	{"MUI", []string{"X", "N", "P", "A"}, false},
+	{"MVC", []string{"X", "H", "L", "N"}, false},
+	{"MVI", []string{"X", "H", "L", "N"}, false},
+	{"MVA", []string{"X", "H", "L", "N"}, false},
+	{"MSC", []string{"X", "H", "L", "N"}, false},
+	{"MSI", []string{"X", "S", "H", "L", "N"}, false},
+	{"MSA", []string{"X", "S", "H", "L", "N"}, false},
+	// Supplemental metrics do not change the score.
+	{"S", []string{"X", "N", "P"}, false},
+	{"AU", []string{"X", "N", "Y"}, false},
+	{"R", []string{"X", "A", "U", "I"}, false},
+	{"V", []string{"X", "D", "C"}, false},
+	{"RE", []string{"X", "L", "M", "H"}, false},
+	{"U", []string{"X", "Clear", "Green", "Amber", "Red"}, false},
+}
+
+// Vector is a parsed CVSS vector string.
+type Vector struct {
+	// Version is Version31 or Version40.
+	Version string
+	// metrics contains the values by metric name.
+	metrics map[string]string
+}
+
+// Parse parses and validates a CVSS v3.1 or v4.0 vector string (e.g.,
+// "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"). Every base metric is
+// required. v3.1 metrics can be in any order and v4.0 metrics must be in the
+// order of the specification.
+func Parse(vector string) (*Vector, error) {
+	parts := strings.Split(strings.TrimSpace(vector), "/")
+	var defs []metric
+	switch parts[0] {
+	case "CVSS:" + Version31:
+		defs = metrics31
+	case "CVSS:" + Version40:
+		defs = metrics40
+	default:
+		return nil, fmt.Errorf("cvss.Parse: unsupported vector %q, it must start with CVSS:3.1/ or CVSS:4.0/", vector)
+	}
+	v := &Vector{
+		Version: strings.TrimPrefix(parts[0], "CVSS:"),
+		metrics: make(map[string]string),
+	}
+	last := -1
+	for _, part := range parts[1:] {
+		kv := strings.SplitN(part, ":", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("cvss.Parse: invalid metric %q, use name:value", part)
+		}
+		i := metricIndex(defs, kv[0])
+		switch {
+		case i < 0:
+			return nil, fmt.Errorf("cvss.Parse: unknown metric %s in CVSS v%s", kv[0], v.Version)
+		case v.metrics[kv[0]] != "":
+			return nil, fmt.Errorf("cvss.Parse: duplicate metric %s", kv[0])
+		case !contains(defs[i].values, kv[1]):
+			return nil, fmt.Errorf("cvss.Parse: invalid value %s for %s, use one of %s", kv[1], kv[0], strings.Join(defs[i].values, ", "))
+		case v.Version == Version40 && i < last:
+			return nil, fmt.Errorf("cvss.Parse: %s is out of order", kv[0])
+		}
+		v.metrics[kv[0]] = kv[1]
+		last = i
+	}
+	for _, m := range defs {
+		if m.required && v.metrics[m.name] == "" {
+			return nil, fmt.Errorf("cvss.Parse: missing base metric %s", m.name)
+		}
+	}
+	return v, nil
+}
+
+// metricIndex returns the index of the metric name in defs or -1.
+func metricIndex(defs []metric, name string) int {
+	for i, m := range defs {
+		if m.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// contains returns true if values contains s.
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// defs returns the metrics of the vector version.
+func (v *Vector) defs() []metric {
+	if v.Version == Version40 {
+		return metrics40
+	}
+	return metrics31
+}
+
+// Get returns the value of a metric. Metrics that are not in the vector
+// return X.
+func (v *Vector) Get(name string) string {
+	if value, ok := v.metrics[name]; ok {
+		return value
+	}
+	return "X"
+}
+
+// String returns the vector string with the metrics in the order of the
+// specification. Metrics with the X value are omitted.
+func (v *Vector) String() string {
+	parts := []string{"CVSS:" + v.Version}
+	for _, m := range v.defs() {
+		if value := v.Get(m.name); value != "X" {
+			parts = append(parts, m.name+":"+value)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// Scores contains the scores of a vector. In v4.0, Temporal is the CVSS-BT
+// (base and threat) score and Environmental is the CVSS-BTE score.
+type Scores struct {
+	Base          float64
+	Temporal      float64
+	Environmental float64
+}
+
+// Scores calculates the scores of the vector.
+func (v *Vector) Scores() Scores {
+	if v.Version == Version40 {
+		return scores40(v)
+	}
+	return scores31(v)
+}
+
+// Score returns the score that uses every metric in the vector. It's the
+// environmental score because it's equal to the temporal score if the
+// environmental metrics are not defined and to the base score if the temporal
+// metrics are also not defined.
+func (v *Vector) Score() float64 {
+	return v.Scores().Environmental
+}
+
+// Nomenclature returns the v4.0 nomenclature of Score (e.g., CVSS-BT for base
+// and threat metrics). It's empty for v3.1.
+func (v *Vector) Nomenclature() string {
+	if v.Version != Version40 {
+		return ""
+	}
+	threat, env := false, false
+	for name := range v.metrics {
+		switch i := metricIndex(metrics40, name); {
+		case v.metrics[name] == "X" || i < metricIndex(metrics40, "E"):
+		case name == "E":
+			threat = true
+		case i < metricIndex(metrics40, "S"):
+			env = true
+		}
+	}
+	n := "CVSS-B"
+	if threat {
+		n += "T"
+	}
+	if env {
+		n += "E"
+	}
+	return n
+}
+
+// Severity returns the qualitative severity rating of a score: none, low,
+// medium, high or critical.
+func Severity(score float64) string {
+	switch {
+	case score >= 9:
+		return "critical"
+	case score >= 7:
+		return "high"
+	case score >= 4:
+		return "medium"
+	case score > 0:
+		return "low"
+	}
+	return "none"
+}
@@ -0,0 +1,94 @@
+package cvss
+
+import "math"
+
+// weights31 are the v3.1 metric values. PR depends on the scope and is in
+// prWeight31.
+var weights31 = map[string]map[string]float64{
+	"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+	"AC": {"L": 0.77, "H": 0.44},
+	"UI": {"N": 0.85, "R": 0.62},
+	"C":  {"H": 0.56, "L": 0.22, "N": 0},
+	"I":  {"H": 0.56, "L": 0.22, "N": 0},
+	"A":  {"H": 0.56, "L": 0.22, "N": 0},
+	"E":  {"X": 1, "H": 1, "F": 0.97, "P": 0.94, "U": 0.91},
+	"RL": {"X": 1, "U": 1, "W": 0.97, "T": 0.96, "O": 0.95},
+	"RC": {"X": 1, "C": 1, "R": 0.96, "U": 0.92},
+	"CR": {"X": 1, "H": 1.5, "M": 1, "L": 0.5},
+	"IR": {"X": 1, "H": 1.5, "M": 1, "L": 0.5},
+	"AR": {"X": 1, "H": 1.5, "M": 1, "L": 0.5},
+}
+
+// prWeight31 returns the value of PR. Privileges have more impact if the
+// scope is changed.
+func prWeight31(pr string, changed bool) float64 {
+	switch {
+	case pr == "N":
+		return 0.85
+	case pr == "L" && changed:
+		return 0.68
+	case pr == "L":
+		return 0.62
+	case changed:
+		return 0.5
+	}
+	return 0.27
+}
+
+// scores31 calculates the v3.1 scores.
+func scores31(v *Vector) Scores {
+	w := func(name string) float64 { return weights31[name][v.Get(name)] }
+	// mod returns the modified metric or the base metric if it's not defined.
+	mod := func(name string) string {
+		if m := v.Get("M" + name); m != "X" {
+			return m
+		}
+		return v.Get(name)
+	}
+	var s Scores
+
+	changed := v.Get("S") == "C"
+	iss := 1 - (1-w("C"))*(1-w("I"))*(1-w("A"))
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	exploitability := 8.22 * w("AV") * w("AC") * prWeight31(v.Get("PR"), changed) * w("UI")
+	if impact > 0 {
+		if changed {
+			s.Base = roundUp31(math.Min(1.08*(impact+exploitability), 10))
+		} else {
+			s.Base = roundUp31(math.Min(impact+exploitability, 10))
+		}
+	}
+	temporal := w("E") * w("RL") * w("RC")
+	s.Temporal = roundUp31(s.Base * temporal)
+
+	mChanged := mod("S") == "C"
+	mw := func(name string) float64 { return weights31[name][mod(name)] }
+	miss := math.Min(1-(1-w("CR")*mw("C"))*(1-w("IR")*mw("I"))*(1-w("AR")*mw("A")), 0.915)
+	mImpact := 6.42 * miss
+	if mChanged {
+		mImpact = 7.52*(miss-0.029) - 3.25*math.Pow(miss*0.9731-0.02, 13)
+	}
+	mExploitability := 8.22 * mw("AV") * mw("AC") * prWeight31(mod("PR"), mChanged) * mw("UI")
+	if mImpact > 0 {
+		if mChanged {
+			s.Environmental = roundUp31(roundUp31(math.Min(1.08*(mImpact+mExploitability), 10)) * temporal)
+		} else {
+			s.Environmental = roundUp31(roundUp31(math.Min(mImpact+mExploitability, 10)) * temporal)
+		}
+	}
+	return s
+}
+
+// roundUp31 returns the smallest number with one decimal that is equal to or
+// higher than x. It's the Roundup function in Appendix A of the v3.1
+// specification that avoids floating point errors.
+func roundUp31(x float64) float64 {
+	i := int64(math.Round(x * 100000))
+	if i%10000 == 0 {
+		return float64(i) / 100000
+	}
+	return (math.Floor(float64(i)/10000) + 1) / 10
+}
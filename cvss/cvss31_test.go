@@ -0,0 +1,77 @@
+package cvss
+
+import "testing"
+
+func TestScores31(t *testing.T) {
+	tests := []struct {
+		name   string
+		vector string
+		want   Scores
+	}{
+		// Examples in the CVSS v3.1 examples document.
+		{"CVE-2013-1937", "AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", Scores{6.1, 6.1, 6.1}},
+		{"CVE-2013-0375", "AV:N/AC:L/PR:L/UI:N/S:C/C:L/I:L/A:N", Scores{6.4, 6.4, 6.4}},
+		{"CVE-2014-3566", "AV:N/AC:H/PR:N/UI:R/S:U/C:L/I:N/A:N", Scores{3.1, 3.1, 3.1}},
+		{"CVE-2012-1516", "AV:N/AC:L/PR:L/UI:N/S:C/C:H/I:H/A:H", Scores{9.9, 9.9, 10}},
+		{"CVE-2009-0783", "AV:L/AC:L/PR:H/UI:N/S:U/C:L/I:L/A:L", Scores{4.2, 4.2, 4.2}},
+		{"CVE-2012-0384", "AV:N/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H", Scores{8.8, 8.8, 8.8}},
+		{"CVE-2015-1098", "AV:L/AC:L/PR:N/UI:R/S:U/C:H/I:H/A:H", Scores{7.8, 7.8, 7.8}},
+		{"CVE-2014-0160", "AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N", Scores{7.5, 7.5, 7.5}},
+		{"CVE-2014-6271", "AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", Scores{9.8, 9.8, 9.8}},
+		{"CVE-2008-1447", "AV:N/AC:H/PR:N/UI:N/S:C/C:N/I:H/A:N", Scores{6.8, 6.8, 6.8}},
+		{"CVE-2014-2005", "AV:P/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", Scores{6.8, 6.8, 6.8}},
+		{"CVE-2010-0467", "AV:N/AC:L/PR:N/UI:N/S:C/C:L/I:N/A:N", Scores{5.8, 5.8, 5.8}},
+		{"CVE-2013-6014", "AV:A/AC:L/PR:N/UI:N/S:C/C:H/I:N/A:H", Scores{9.3, 9.3, 9.3}},
+		{"CVE-2014-9253", "AV:N/AC:L/PR:L/UI:R/S:C/C:L/I:L/A:N", Scores{5.4, 5.4, 5.4}},
+		{"CVE-2011-1265", "AV:A/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", Scores{8.8, 8.8, 8.8}},
+		{"CVE-2014-2019", "AV:P/AC:L/PR:N/UI:N/S:U/C:N/I:H/A:N", Scores{4.6, 4.6, 4.6}},
+		{"CVE-2014-0224", "AV:N/AC:H/PR:N/UI:N/S:U/C:H/I:H/A:N", Scores{7.4, 7.4, 7.4}},
+		{"CVE-2012-5376", "AV:N/AC:L/PR:N/UI:R/S:C/C:H/I:H/A:H", Scores{9.6, 9.6, 9.7}},
+		{"CVE-2016-0128", "AV:N/AC:H/PR:N/UI:R/S:U/C:H/I:H/A:N", Scores{6.8, 6.8, 6.8}},
+		{"local-privilege-escalation", "AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H", Scores{7.8, 7.8, 7.8}},
+		// Boundaries.
+		{"max", "AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", Scores{10, 10, 10}},
+		{"no-impact", "AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N", Scores{0, 0, 0}},
+		{"pr-high", "AV:N/AC:L/PR:H/UI:N/S:U/C:H/I:H/A:H", Scores{7.2, 7.2, 7.2}},
+		{"csrf", "AV:N/AC:L/PR:N/UI:R/S:U/C:N/I:H/A:N", Scores{6.5, 6.5, 6.5}},
+		// Temporal.
+		{"temporal-official-fix", "AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/E:P/RL:O/RC:C", Scores{9.8, 8.8, 8.8}},
+		{"temporal-unproven", "AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/E:U/RL:W/RC:R", Scores{9.8, 8.4, 8.4}},
+		{"temporal-scope-changed", "AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N/E:F/RL:T/RC:U", Scores{6.1, 5.3, 5.3}},
+		// Environmental.
+		{"requirements-low", "AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/CR:L/IR:L/AR:L", Scores{9.8, 9.8, 8}},
+		{"modified-base", "AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N/CR:H/MAV:A/MPR:L", Scores{7.5, 7.5, 7.5}},
+		{"all-metrics", "AV:N/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H/E:F/RL:O/RC:C/CR:H/IR:H/AR:M/MAV:N/MAC:H/MPR:N/MUI:R/MS:C/MC:H/MI:L/MA:N", Scores{8.8, 8.2, 7.8}},
+		{"modified-scope-changed", "AV:L/AC:H/PR:H/UI:R/S:U/C:L/I:N/A:N/MS:C/MC:H", Scores{1.8, 1.8, 5}},
+		{"modified-scope-unchanged", "AV:N/AC:L/PR:L/UI:N/S:C/C:H/I:H/A:H/MS:U/MPR:H", Scores{9.9, 9.9, 7.2}},
+		{"modified-no-impact", "AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H/MC:N/MI:N/MA:N", Scores{10, 10, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := Parse("CVSS:3.1/" + tt.vector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := v.Scores(); got != tt.want {
+				t.Errorf("Scores() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundUp31(t *testing.T) {
+	tests := map[float64]float64{
+		4.02:               4.1,
+		4.00:               4.0,
+		4.000000000000001:  4.0,
+		3.9999999999999996: 4.0,
+		0:                  0,
+		9.71:               9.8,
+		10:                 10,
+	}
+	for in, want := range tests {
+		if got := roundUp31(in); got != want {
+			t.Errorf("roundUp31(%v) = %v, want %v", in, got, want)
+		}
+	}
+}
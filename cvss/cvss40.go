@@ -0,0 +1,272 @@
+package cvss
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// levels40 are the severity levels of the v4.0 metrics used to calculate the
+// distance from the highest severity vector of a macro vector. 0 is the most
+// severe.
+var levels40 = map[string]map[string]int{
+	"AV": {"N": 0, "A": 1, "L": 2, "P": 3},
+	"PR": {"N": 0, "L": 1, "H": 2},
+	"UI": {"N": 0, "P": 1, "A": 2},
+	"AC": {"L": 0, "H": 1},
+	"AT": {"N": 0, "P": 1},
+	"VC": {"H": 0, "L": 1, "N": 2},
+	"VI": {"H": 0, "L": 1, "N": 2},
+	"VA": {"H": 0, "L": 1, "N": 2},
+	"SC": {"H": 1, "L": 2, "N": 3},
+	"SI": {"S": 0, "H": 1, "L": 2, "N": 3},
+	"SA": {"S": 0, "H": 1, "L": 2, "N": 3},
+	"CR": {"H": 0, "M": 1, "L": 2},
+	"IR": {"H": 0, "M": 1, "L": 2},
+	"AR": {"H": 0, "M": 1, "L": 2},
+}
+
+// maxVectors40 are the highest severity vectors of each level of the
+// equivalence sets. EQ3 and EQ6 are combined and indexed by EQ3 and EQ6.
+var maxVectors40 = struct {
+	eq1, eq2, eq4 [][]string
+	eq3eq6        [][][]string
+}{
+	eq1: [][]string{
+		{"AV:N/PR:N/UI:N"},
+		{"AV:A/PR:N/UI:N", "AV:N/PR:L/UI:N", "AV:N/PR:N/UI:P"},
+		{"AV:P/PR:N/UI:N", "AV:A/PR:L/UI:P"},
+	},
+	eq2: [][]string{
+		{"AC:L/AT:N"},
+		{"AC:H/AT:N", "AC:L/AT:P"},
+	},
+	eq3eq6: [][][]string{
+		{
+			{"VC:H/VI:H/VA:H/CR:H/IR:H/AR:H"},
+			{"VC:H/VI:H/VA:L/CR:M/IR:M/AR:H", "VC:H/VI:H/VA:H/CR:M/IR:M/AR:M"},
+		},
+		{
+			{"VC:L/VI:H/VA:H/CR:H/IR:H/AR:H", "VC:H/VI:L/VA:H/CR:H/IR:H/AR:H"},
+			{"VC:L/VI:H/VA:L/CR:H/IR:M/AR:H", "VC:L/VI:H/VA:H/CR:H/IR:M/AR:M",
+				"VC:H/VI:L/VA:H/CR:M/IR:H/AR:M", "VC:H/VI:L/VA:L/CR:M/IR:H/AR:H",
+				"VC:L/VI:L/VA:H/CR:H/IR:H/AR:M"},
+		},
+		{
+			nil,
+			{"VC:L/VI:L/VA:L/CR:H/IR:H/AR:H"},
+		},
+	},
+	eq4: [][]string{
+		{"SC:H/SI:S/SA:S"},
+		{"SC:H/SI:H/SA:H"},
+		{"SC:L/SI:L/SA:L"},
+	},
+}
+
+// maxDepths40 are the number of severity levels in each level of the
+// equivalence sets. EQ5 only has E and its distance is always 0.
+var maxDepths40 = struct {
+	eq1, eq2, eq4, eq5 []int
+	eq3eq6             [][]int
+}{
+	eq1:    []int{1, 4, 5},
+	eq2:    []int{1, 2},
+	eq3eq6: [][]int{{7, 6}, {8, 8}, {0, 10}},
+	eq4:    []int{6, 5, 4},
+	eq5:    []int{1, 1, 1},
+}
+
+// scores40 calculates the v4.0 scores. The base score ignores the threat and
+// environmental metrics and the temporal score ignores the environmental
+// metrics.
+func scores40(v *Vector) Scores {
+	return Scores{
+		Base:          score40(effective40(v, false, false)),
+		Temporal:      score40(effective40(v, true, false)),
+		Environmental: score40(effective40(v, true, true)),
+	}
+}
+
+// effective40 returns the values used in the score. Modified metrics replace
+// the base metrics and metrics that are not defined use the most severe value.
+func effective40(v *Vector, threat, env bool) map[string]string {
+	m := make(map[string]string)
+	for _, name := range []string{"AV", "AC", "AT", "PR", "UI", "VC", "VI", "VA", "SC", "SI", "SA"} {
+		m[name] = v.Get(name)
+		if mod := v.Get("M" + name); env && mod != "X" {
+			m[name] = mod
+		}
+	}
+	m["E"] = "A"
+	if e := v.Get("E"); threat && e != "X" {
+		m["E"] = e
+	}
+	for _, name := range []string{"CR", "IR", "AR"} {
+		m[name] = "H"
+		if r := v.Get(name); env && r != "X" {
+			m[name] = r
+		}
+	}
+	return m
+}
+
+// macroVector40 returns the levels of the equivalence sets EQ1 to EQ6.
+func macroVector40(m map[string]string) [6]int {
+	var eq [6]int
+	switch {
+	case m["AV"] == "N" && m["PR"] == "N" && m["UI"] == "N":
+		eq[0] = 0
+	case (m["AV"] == "N" || m["PR"] == "N" || m["UI"] == "N") && m["AV"] != "P":
+		eq[0] = 1
+	default:
+		eq[0] = 2
+	}
+	if m["AC"] != "L" || m["AT"] != "N" {
+		eq[1] = 1
+	}
+	switch {
+	case m["VC"] == "H" && m["VI"] == "H":
+		eq[2] = 0
+	case m["VC"] == "H" || m["VI"] == "H" || m["VA"] == "H":
+		eq[2] = 1
+	default:
+		eq[2] = 2
+	}
+	switch {
+	case m["SI"] == "S" || m["SA"] == "S":
+		eq[3] = 0
+	case m["SC"] == "H" || m["SI"] == "H" || m["SA"] == "H":
+		eq[3] = 1
+	default:
+		eq[3] = 2
+	}
+	switch m["E"] {
+	case "P":
+		eq[4] = 1
+	case "U":
+		eq[4] = 2
+	}
+	if !(m["CR"] == "H" && m["VC"] == "H" || m["IR"] == "H" && m["VI"] == "H" || m["AR"] == "H" && m["VA"] == "H") {
+		eq[5] = 1
+	}
+	return eq
+}
+
+// macroVectorScore returns the score of a macro vector. ok is false if the
+// macro vector does not exist.
+func macroVectorScore(eq [6]int) (score float64, ok bool) {
+	score, ok = macroVectorScores[fmt.Sprintf("%d%d%d%d%d%d", eq[0], eq[1], eq[2], eq[3], eq[4], eq[5])]
+	return score, ok
+}
+
+// score40 calculates the score of the effective values. The score of the
+// macro vector is lowered by the mean of the distances of each equivalence set
+// from its highest severity vector, in proportion to the score difference
+// with the next lower macro vector.
+func score40(m map[string]string) float64 {
+	none := true
+	for _, name := range []string{"VC", "VI", "VA", "SC", "SI", "SA"} {
+		none = none && m[name] == "N"
+	}
+	if none {
+		return 0
+	}
+	eq := macroVector40(m)
+	value, _ := macroVectorScore(eq)
+
+	// Scores of the next lower macro vector of each equivalence set.
+	lower := func(i int) (float64, bool) {
+		next := eq
+		next[i]++
+		return macroVectorScore(next)
+	}
+	eq1Lower, eq1OK := lower(0)
+	eq2Lower, eq2OK := lower(1)
+	eq4Lower, eq4OK := lower(3)
+	eq5Lower, eq5OK := lower(4)
+	// EQ3 and EQ6 are lowered together. 2,0 does not exist and 0,0 has two
+	// next lower macro vectors, the higher score is used.
+	var eq36Lower float64
+	var eq36OK bool
+	switch {
+	case eq[2] == 1 && eq[5] == 1, eq[2] == 0 && eq[5] == 1:
+		eq36Lower, eq36OK = lower(2)
+	case eq[2] == 1 && eq[5] == 0:
+		eq36Lower, eq36OK = lower(5)
+	case eq[2] == 0 && eq[5] == 0:
+		eq36Lower, eq36OK = lower(2)
+		if s, ok := lower(5); ok && s > eq36Lower {
+			eq36Lower = s
+		}
+	}
+
+	d := distances40(m, eq)
+
+	sum, n := 0.0, 0
+	for _, s := range []struct {
+		lower    float64
+		ok       bool
+		distance int
+		depth    int
+	}{
+		{eq1Lower, eq1OK, d[0], maxDepths40.eq1[eq[0]]},
+		{eq2Lower, eq2OK, d[1], maxDepths40.eq2[eq[1]]},
+		{eq36Lower, eq36OK, d[2], maxDepths40.eq3eq6[eq[2]][eq[5]]},
+		{eq4Lower, eq4OK, d[3], maxDepths40.eq4[eq[3]]},
+		{eq5Lower, eq5OK, d[4], maxDepths40.eq5[eq[4]]},
+	} {
+		if !s.ok {
+			continue
+		}
+		sum += (value - s.lower) * float64(s.distance) / float64(s.depth)
+		n++
+	}
+	if n > 0 {
+		value -= sum / float64(n)
+	}
+	return math.Round(math.Max(0, math.Min(value, 10))*10) / 10
+}
+
+// distances40 returns the distances of the equivalence sets from the first
+// highest severity vector of the macro vector that is not less severe in any
+// metric. EQ3 and EQ6 are combined in the third distance.
+func distances40(m map[string]string, eq [6]int) [5]int {
+	for _, eq1Max := range maxVectors40.eq1[eq[0]] {
+		for _, eq2Max := range maxVectors40.eq2[eq[1]] {
+			for _, eq36Max := range maxVectors40.eq3eq6[eq[2]][eq[5]] {
+				for _, eq4Max := range maxVectors40.eq4[eq[3]] {
+					max := parseMaxVector(eq1Max + "/" + eq2Max + "/" + eq36Max + "/" + eq4Max)
+					d := make(map[string]int)
+					valid := true
+					for name, levels := range levels40 {
+						d[name] = levels[m[name]] - levels[max[name]]
+						valid = valid && d[name] >= 0
+					}
+					if !valid {
+						continue
+					}
+					return [5]int{
+						d["AV"] + d["PR"] + d["UI"],
+						d["AC"] + d["AT"],
+						d["VC"] + d["VI"] + d["VA"] + d["CR"] + d["IR"] + d["AR"],
+						d["SC"] + d["SI"] + d["SA"],
+						// EQ5 only has E and it's always the highest severity.
+						0,
+					}
+				}
+			}
+		}
+	}
+	return [5]int{}
+}
+
+// parseMaxVector returns the metrics in a highest severity vector.
+func parseMaxVector(s string) map[string]string {
+	m := make(map[string]string)
+	for _, part := range strings.Split(s, "/") {
+		kv := strings.SplitN(part, ":", 2)
+		m[kv[0]] = kv[1]
+	}
+	return m
+}
@@ -0,0 +1,119 @@
+package cvss
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestScores40(t *testing.T) {
+	base := "AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N"
+	tests := []struct {
+		name   string
+		vector string
+		want   Scores
+	}{
+		// Examples in the CVSS v4.0 documentation.
+		{"network-high", base, Scores{9.3, 9.3, 9.3}},
+		{"max", "AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:H/SI:H/SA:H", Scores{10, 10, 10}},
+		{"CVE-2022-41741", "AV:L/AC:L/AT:P/PR:L/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", Scores{7.3, 7.3, 7.3}},
+		{"local-low-privileges", "AV:L/AC:L/AT:N/PR:L/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", Scores{8.5, 8.5, 8.5}},
+		{"network-low-privileges", "AV:N/AC:L/AT:N/PR:L/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", Scores{8.7, 8.7, 8.7}},
+		{"physical", "AV:P/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", Scores{7, 7, 7}},
+		{"xss-passive", "AV:N/AC:L/AT:N/PR:N/UI:P/VC:N/VI:N/VA:N/SC:L/SI:L/SA:N", Scores{5.3, 5.3, 5.3}},
+		{"xss-active", "AV:N/AC:L/AT:N/PR:N/UI:A/VC:N/VI:N/VA:N/SC:L/SI:L/SA:N", Scores{5.1, 5.1, 5.1}},
+		{"subsequent-only", "AV:A/AC:L/AT:N/PR:N/UI:N/VC:N/VI:L/VA:N/SC:H/SI:N/SA:H", Scores{6.4, 6.4, 6.4}},
+		{"low", "AV:N/AC:H/AT:P/PR:H/UI:A/VC:L/VI:L/VA:L/SC:L/SI:L/SA:L", Scores{1.8, 1.8, 1.8}},
+		{"no-impact", "AV:N/AC:L/AT:N/PR:N/UI:N/VC:N/VI:N/VA:N/SC:N/SI:N/SA:N", Scores{0, 0, 0}},
+		// Threat.
+		{"unreported", base + "/E:U", Scores{9.3, 8.1, 8.1}},
+		{"poc", base + "/E:P", Scores{9.3, 8.9, 8.9}},
+		{"attacked", base + "/E:A", Scores{9.3, 9.3, 9.3}},
+		// Environmental.
+		{"requirements-low", base + "/CR:L/IR:L/AR:L", Scores{9.3, 9.3, 8.9}},
+		{"threat-and-environmental", base + "/E:P/CR:M/IR:M/AR:L/MAV:A/MPR:L", Scores{9.3, 8.9, 6.1}},
+		{"safety", "AV:N/AC:L/AT:N/PR:N/UI:N/VC:L/VI:L/VA:L/SC:N/SI:N/SA:N/MSI:S/MSA:S", Scores{6.9, 6.9, 9.1}},
+		{"every-modified-metric", "AV:L/AC:H/AT:P/PR:H/UI:P/VC:L/VI:N/VA:N/SC:N/SI:N/SA:N/E:U/MAV:N/MAC:L/MAT:N/MPR:N/MUI:N/MVC:H/MVI:H/MVA:H/MSC:H/MSI:S/MSA:S", Scores{1, 0.1, 9.5}},
+		// The shortcut uses the modified impact metrics.
+		{"modified-no-impact", base + "/MVC:N/MVI:N/MVA:N", Scores{9.3, 9.3, 0}},
+		{"modified-impact", "AV:N/AC:L/AT:N/PR:N/UI:N/VC:N/VI:N/VA:N/SC:N/SI:N/SA:N/MVA:H", Scores{0, 0, 8.7}},
+		// Supplemental metrics do not change the score.
+		{"supplemental", base + "/S:P/AU:Y/R:U/V:C/RE:H/U:Red", Scores{9.3, 9.3, 9.3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := Parse("CVSS:4.0/" + tt.vector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := v.Scores(); got != tt.want {
+				t.Errorf("Scores() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestMacroVectors40 scores the highest severity vectors of every macro vector.
+// They must be in the macro vector and have its score.
+func TestMacroVectors40(t *testing.T) {
+	exploit := []string{"A", "P", "U"}
+	n := 0
+	for key, want := range macroVectorScores {
+		var eq [6]int
+		for i := range eq {
+			eq[i] = int(key[i] - '0')
+		}
+		for _, eq1Max := range maxVectors40.eq1[eq[0]] {
+			for _, eq2Max := range maxVectors40.eq2[eq[1]] {
+				for _, eq36Max := range maxVectors40.eq3eq6[eq[2]][eq[5]] {
+					for _, eq4Max := range maxVectors40.eq4[eq[3]] {
+						max := parseMaxVector(eq1Max + "/" + eq2Max + "/" + eq36Max + "/" + eq4Max)
+						vector := maxVectorString(max, exploit[eq[4]])
+						v, err := Parse(vector)
+						if err != nil {
+							t.Fatal(err)
+						}
+						if got := macroVector40(effective40(v, true, true)); got != eq {
+							t.Errorf("%s: macro vector %v, want %s", vector, got, key)
+						}
+						if got := v.Score(); got != want {
+							t.Errorf("%s: Score() = %v, want %v", vector, got, want)
+						}
+						n++
+					}
+				}
+			}
+		}
+	}
+	if len(macroVectorScores) != 270 {
+		t.Errorf("%d macro vectors, want 270", len(macroVectorScores))
+	}
+	t.Logf("scored %d highest severity vectors", n)
+}
+
+// maxVectorString returns the vector string of a highest severity vector.
+// Safety (S) is only valid in MSI and MSA.
+func maxVectorString(max map[string]string, e string) string {
+	parts := []string{"CVSS:4.0"}
+	for _, name := range []string{"AV", "AC", "AT", "PR", "UI", "VC", "VI", "VA", "SC", "SI", "SA"} {
+		value := max[name]
+		if value == "" {
+			// SC, SI and SA in EQ4 are always set, AT and AC in EQ2.
+			value = "N"
+		}
+		if value == "S" {
+			value = "H"
+		}
+		parts = append(parts, name+":"+value)
+	}
+	parts = append(parts, "E:"+e)
+	for _, name := range []string{"CR", "IR", "AR"} {
+		parts = append(parts, name+":"+max[name])
+	}
+	for _, name := range []string{"SI", "SA"} {
+		if max[name] == "S" {
+			parts = append(parts, fmt.Sprintf("M%s:S", name))
+		}
+	}
+	return strings.Join(parts, "/")
+}
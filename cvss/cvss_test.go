@@ -0,0 +1,82 @@
+package cvss
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		vector  string
+		want    string
+		wantErr bool
+	}{
+		{"v31", "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", false},
+		{"v31-any-order", " CVSS:3.1/S:U/AV:N/AC:L/PR:N/UI:N/C:H/I:H/A:H/E:X/RL:O ", "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/RL:O", false},
+		{"v40", "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N/E:P/MSI:S/U:Red", "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N/E:P/MSI:S/U:Red", false},
+		{"v30", "CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", "", true},
+		{"v2", "AV:N/AC:L/Au:N/C:P/I:P/A:P", "", true},
+		{"empty", "", "", true},
+		{"missing-base", "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H", "", true},
+		{"duplicate", "CVSS:3.1/AV:N/AV:L/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", "", true},
+		{"unknown", "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/AT:N", "", true},
+		{"invalid-value", "CVSS:3.1/AV:X/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", "", true},
+		{"lowercase", "CVSS:3.1/av:n/ac:l/pr:n/ui:n/s:u/c:h/i:h/a:h", "", true},
+		{"no-value", "CVSS:3.1/AV/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", "", true},
+		{"trailing-slash", "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/", "", true},
+		{"v40-out-of-order", "CVSS:4.0/AC:L/AV:N/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", "", true},
+		{"v40-safety-in-base", "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:S/SA:N", "", true},
+		{"v40-v31-metric", "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N/RL:O", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := Parse(tt.vector)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && v.String() != tt.want {
+				t.Errorf("Parse().String() = %s, want %s", v.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestNomenclature(t *testing.T) {
+	base := "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N"
+	tests := map[string]string{
+		base:                  "CVSS-B",
+		base + "/E:X/CR:X":    "CVSS-B",
+		base + "/S:P/U:Amber": "CVSS-B",
+		base + "/E:U":         "CVSS-BT",
+		base + "/CR:H":        "CVSS-BE",
+		base + "/MSA:S":       "CVSS-BE",
+		base + "/E:A/MAV:L":   "CVSS-BTE",
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/E:U": "",
+	}
+	for vector, want := range tests {
+		v, err := Parse(vector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := v.Nomenclature(); got != want {
+			t.Errorf("%s: Nomenclature() = %q, want %q", vector, got, want)
+		}
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	tests := map[float64]string{
+		0:   "none",
+		0.1: "low",
+		3.9: "low",
+		4:   "medium",
+		6.9: "medium",
+		7:   "high",
+		8.9: "high",
+		9:   "critical",
+		10:  "critical",
+	}
+	for score, want := range tests {
+		if got := Severity(score); got != want {
+			t.Errorf("Severity(%v) = %s, want %s", score, got, want)
+		}
+	}
+}
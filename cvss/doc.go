@@ -0,0 +1,9 @@
+/*
+	Package cvss parses CVSS v3.1 and v4.0 vector strings and calculates their
+	scores and severities.
+
+	v3.1 scores follow the formulas in section 7 of the CVSS v3.1
+	specification. v4.0 scores use the macro vectors and interpolation of the
+	FIRST reference calculator.
+*/
+package cvss
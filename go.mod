@@ -1,6 +1,6 @@
 module github.com/parsiya/borrowedtime
 
-go 1.14
+go 1.16
 
 require (
 	github.com/basgys/goxml2json v1.1.0
@@ -6,7 +6,6 @@ import (
 	prompt "github.com/c-bata/go-prompt"
 	"github.com/parsiya/borrowedtime/cmd"
 	"github.com/parsiya/borrowedtime/config"
-	"github.com/starkriedesel/prompter"
 )
 
 func main() {
@@ -14,10 +13,16 @@ func main() {
 	configCmd := cmd.ConfigCmd()
 	deployCmd := cmd.DeployCmd()
 	projectCmd := cmd.ProjectCmd()
+	templateCmd := cmd.TemplateCmd()
+	searchCmd := cmd.SearchCmd()
+	findingCmd := cmd.FindingCmd()
+	cvssCmd := cmd.CVSSCmd()
+	libraryCmd := cmd.LibraryCmd()
+	evidenceCmd := cmd.EvidenceCmd()
 	exitCmd := cmd.ExitCmd()
 
-	comp := prompter.NewCompleter()
-	err := comp.RegisterCommands(configCmd, deployCmd, projectCmd, exitCmd)
+	comp, err := cmd.NewCompleter(configCmd, deployCmd, projectCmd, templateCmd,
+		searchCmd, findingCmd, cvssCmd, libraryCmd, evidenceCmd, exitCmd)
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,367 @@
+package project
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mholt/archiver"
+	"github.com/parsiya/borrowedtime/config"
+	"github.com/parsiya/borrowedtime/shared"
+)
+
+// archiveTimeFormat is the format of the archive date.
+const archiveTimeFormat = "2006-01-02 15:04:05"
+
+// ArchiveManifest describes an archived project. It's stored next to the zip
+// file as "name.json".
+type ArchiveManifest struct {
+	Name string `json:"name"`
+	// Archived is the archive date.
+	Archived string `json:"archived"`
+	// Size is the size of the zip file in bytes.
+	Size int64 `json:"size"`
+	// Entry is the registry entry of the project before it was archived.
+	Entry RegistryEntry `json:"entry"`
+	// Files contains the SHA-256 hash of every file by relative path.
+	Files map[string]string `json:"files"`
+	// Links contains the target of every symbolic link by relative path. The
+	// zip file does not store symbolic links.
+	Links map[string]string `json:"links,omitempty"`
+}
+
+// archivePaths returns the paths to the zip file and manifest of an archived
+// project.
+func archivePaths(name string) (string, string, error) {
+	dir, err := config.ArchiveDir()
+	if err != nil {
+		return "", "", err
+	}
+	base := filepath.Join(dir, name)
+	return base + ".zip", base + ".json", nil
+}
+
+// Archive compresses the project into the archive directory, verifies the zip
+// file against the hashes in the manifest and removes the project from the
+// workspace. The registry entry is moved to the archived projects.
+func (p *Project) Archive() (*ArchiveManifest, error) {
+	zipPath, manifestPath, err := archivePaths(p.ProjectName)
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.Archive: %s", err.Error())
+	}
+	for _, pth := range []string{zipPath, manifestPath} {
+		exists, err := shared.PathExists(pth)
+		if err != nil {
+			return nil, fmt.Errorf("project.Project.Archive: %s", err.Error())
+		}
+		if exists {
+			return nil, fmt.Errorf("project.Project.Archive: %s already exists", pth)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(zipPath), os.ModePerm); err != nil {
+		return nil, fmt.Errorf("project.Project.Archive: %s", err.Error())
+	}
+
+	root := p.Root()
+	m, err := writeArchive(root, zipPath)
+	if err != nil {
+		os.Remove(zipPath)
+		return nil, fmt.Errorf("project.Project.Archive: %s", err.Error())
+	}
+	// Never remove the project before the archive is verified.
+	if err := verifyArchive(zipPath, m.Files); err != nil {
+		os.Remove(zipPath)
+		return nil, fmt.Errorf("project.Project.Archive: verify archive - %s", err.Error())
+	}
+	info, err := os.Stat(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.Archive: %s", err.Error())
+	}
+	m.Name = p.ProjectName
+	m.Archived = time.Now().Format(archiveTimeFormat)
+	m.Size = info.Size()
+	m.Entry = p.entry()
+	content, err := shared.StructToJSONString(m, true)
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.Archive: %s", err.Error())
+	}
+	if err := shared.WriteFileString(manifestPath, content, false); err != nil {
+		return nil, fmt.Errorf("project.Project.Archive: %s", err.Error())
+	}
+
+	if err := shared.DeletePath(root); err != nil {
+		return nil, fmt.Errorf("project.Project.Archive: remove project - %s", err.Error())
+	}
+	r, err := ReadRegistry()
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.Archive: %s", err.Error())
+	}
+	delete(r.Projects, p.ProjectName)
+	r.Archived[p.ProjectName] = m.registryEntry()
+	if err := r.Write(); err != nil {
+		return nil, fmt.Errorf("project.Project.Archive: %s", err.Error())
+	}
+	return m, nil
+}
+
+// Unarchive extracts an archived project to the workspace and verifies every
+// file against the manifest. The archive is removed after the project is
+// restored. Partially restored projects are removed.
+func Unarchive(name string) (*Project, error) {
+	m, err := ReadArchiveManifest(name)
+	if err != nil {
+		return nil, fmt.Errorf("project.Unarchive: %s", err.Error())
+	}
+	zipPath, manifestPath, _ := archivePaths(name)
+	p := New(name)
+	root := p.Root()
+	exists, err := shared.PathExists(root)
+	if err != nil {
+		return nil, fmt.Errorf("project.Unarchive: %s", err.Error())
+	}
+	if exists {
+		return nil, fmt.Errorf("project.Unarchive: %s already exists", root)
+	}
+	if err := extractArchive(zipPath, root, m); err != nil {
+		shared.DeletePath(root)
+		return nil, fmt.Errorf("project.Unarchive: %s", err.Error())
+	}
+
+	if p, err = Load(name); err != nil {
+		return nil, fmt.Errorf("project.Unarchive: %s", err.Error())
+	}
+	r, err := ReadRegistry()
+	if err != nil {
+		return nil, fmt.Errorf("project.Unarchive: %s", err.Error())
+	}
+	delete(r.Archived, name)
+	r.Projects[name] = p.entry()
+	if err := r.Write(); err != nil {
+		return nil, fmt.Errorf("project.Unarchive: %s", err.Error())
+	}
+	for _, pth := range []string{zipPath, manifestPath} {
+		if err := os.Remove(pth); err != nil {
+			return nil, fmt.Errorf("project.Unarchive: %s", err.Error())
+		}
+	}
+	return p, nil
+}
+
+// ReadArchiveManifest reads the manifest of an archived project.
+func ReadArchiveManifest(name string) (*ArchiveManifest, error) {
+	_, manifestPath, err := archivePaths(name)
+	if err != nil {
+		return nil, fmt.Errorf("project.ReadArchiveManifest: %s", err.Error())
+	}
+	exists, err := shared.PathExists(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("project.ReadArchiveManifest: %s", err.Error())
+	}
+	if !exists {
+		return nil, fmt.Errorf("project.ReadArchiveManifest: archived project %s not found", name)
+	}
+	content, err := shared.ReadFileByte(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("project.ReadArchiveManifest: %s", err.Error())
+	}
+	var m ArchiveManifest
+	if err := json.Unmarshal(content, &m); err != nil {
+		return nil, fmt.Errorf("project.ReadArchiveManifest: unmarshal manifest - %s", err.Error())
+	}
+	return &m, nil
+}
+
+// registryEntry returns the registry entry of the archived project.
+func (m ArchiveManifest) registryEntry() RegistryEntry {
+	e := m.Entry
+	e.Archived, e.Size = m.Archived, m.Size
+	return e
+}
+
+// Root returns the unescaped project root.
+func (p Project) Root() string {
+	// ProjectRoot is escaped for templates.
+	return strings.Replace(p.ProjectRoot, `\\`, `\`, -1)
+}
+
+// writeArchive writes the files and directories under root to a zip file and
+// returns a manifest with their hashes. Paths in the zip file are relative to
+// root.
+func writeArchive(root, zipPath string) (*ArchiveManifest, error) {
+	out, err := os.Create(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer out.Close()
+	z := archiver.Zip{CompressionLevel: flate.DefaultCompression}
+	if err := z.Create(out); err != nil {
+		return nil, err
+	}
+
+	m := &ArchiveManifest{
+		Files: make(map[string]string),
+		Links: make(map[string]string),
+	}
+	err = filepath.Walk(root, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, pth)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(pth)
+			if err != nil {
+				return err
+			}
+			m.Links[rel] = target
+			return nil
+		case info.IsDir():
+			return z.Write(archiver.File{
+				FileInfo: archiver.FileInfo{FileInfo: info, CustomName: rel},
+			})
+		case !info.Mode().IsRegular():
+			return fmt.Errorf("%s is not a regular file", pth)
+		}
+		f, err := os.Open(pth)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		// Hash the content while it's written.
+		h := sha256.New()
+		err = z.Write(archiver.File{
+			FileInfo:   archiver.FileInfo{FileInfo: info, CustomName: rel},
+			ReadCloser: readCloser{io.TeeReader(f, h), f},
+		})
+		m.Files[rel] = hex.EncodeToString(h.Sum(nil))
+		return err
+	})
+	if err != nil {
+		z.Close()
+		return nil, err
+	}
+	if err := z.Close(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// readCloser reads from Reader and closes Closer.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// verifyArchive checks that the zip file contains exactly the files in the
+// manifest with the same hashes.
+func verifyArchive(zipPath string, files map[string]string) error {
+	seen := make(map[string]bool)
+	z := archiver.Zip{}
+	err := z.Walk(zipPath, func(f archiver.File) error {
+		if f.IsDir() {
+			return nil
+		}
+		name := f.Header.(zip.FileHeader).Name
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return err
+		}
+		want, ok := files[name]
+		if !ok {
+			return fmt.Errorf("%s is not in the manifest", name)
+		}
+		if got := hex.EncodeToString(h.Sum(nil)); got != want {
+			return fmt.Errorf("%s hash mismatch, got %s, want %s", name, got, want)
+		}
+		seen[name] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for name := range files {
+		if !seen[name] {
+			return fmt.Errorf("%s is missing from the archive", name)
+		}
+	}
+	return nil
+}
+
+// extractArchive extracts the zip file to root and recreates the symbolic
+// links. Every file is checked against the manifest.
+func extractArchive(zipPath, root string, m *ArchiveManifest) error {
+	if err := os.MkdirAll(root, os.ModePerm); err != nil {
+		return err
+	}
+	z := archiver.Zip{}
+	err := z.Walk(zipPath, func(f archiver.File) error {
+		name := f.Header.(zip.FileHeader).Name
+		pth := filepath.Join(root, filepath.FromSlash(strings.TrimSuffix(name, "/")))
+		// Do not trust paths in the zip file.
+		if rel, err := filepath.Rel(root, pth); err != nil || strings.HasPrefix(rel, "..") {
+			return fmt.Errorf("%s is outside the project", name)
+		}
+		if f.IsDir() {
+			return os.MkdirAll(pth, f.Mode().Perm()|0700)
+		}
+		if err := os.MkdirAll(filepath.Dir(pth), os.ModePerm); err != nil {
+			return err
+		}
+		out, err := os.OpenFile(pth, os.O_CREATE|os.O_EXCL|os.O_WRONLY, f.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+		h := sha256.New()
+		if _, err := io.Copy(io.MultiWriter(out, h), f); err != nil {
+			return err
+		}
+		if got := hex.EncodeToString(h.Sum(nil)); got != m.Files[name] {
+			return fmt.Errorf("%s hash mismatch, got %s, want %s", name, got, m.Files[name])
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := verifyTree(root, m.Files); err != nil {
+		return err
+	}
+	for _, rel := range shared.SortedKeys(m.Links) {
+		pth := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(pth), os.ModePerm); err != nil {
+			return err
+		}
+		if err := os.Symlink(m.Links[rel], pth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyTree checks that every file in the manifest exists under root with
+// the same hash.
+func verifyTree(root string, files map[string]string) error {
+	for _, rel := range shared.SortedKeys(files) {
+		got, err := hashFile(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil {
+			return err
+		}
+		if got != files[rel] {
+			return fmt.Errorf("%s hash mismatch, got %s, want %s", rel, got, files[rel])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	files := map[string]string{
+		"notes.md":          "# notes\n",
+		"@report/report.md": "report",
+		"empty.txt":         "",
+	}
+	for name, content := range files {
+		pth := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(pth), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(pth, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(src, "@pix"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	zipPath := filepath.Join(t.TempDir(), "acme.zip")
+	m, err := writeArchive(src, zipPath)
+	if err != nil {
+		t.Fatalf("writeArchive() error = %v", err)
+	}
+	if len(m.Files) != len(files) {
+		t.Fatalf("writeArchive() hashed %d files, want %d", len(m.Files), len(files))
+	}
+	if err := verifyArchive(zipPath, m.Files); err != nil {
+		t.Errorf("verifyArchive() error = %v", err)
+	}
+
+	// Wrong and extra hashes must fail.
+	bad := map[string]string{"missing.md": ""}
+	for k, v := range m.Files {
+		bad[k] = v
+	}
+	if err := verifyArchive(zipPath, bad); err == nil {
+		t.Error("verifyArchive() with a missing file should fail")
+	}
+	bad = make(map[string]string)
+	for k := range m.Files {
+		bad[k] = "00"
+	}
+	if err := verifyArchive(zipPath, bad); err == nil {
+		t.Error("verifyArchive() with wrong hashes should fail")
+	}
+
+	dst := filepath.Join(t.TempDir(), "acme")
+	if err := extractArchive(zipPath, dst, m); err != nil {
+		t.Fatalf("extractArchive() error = %v", err)
+	}
+	if err := verifyTree(dst, m.Files); err != nil {
+		t.Errorf("verifyTree() error = %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(dst, "@pix")); err != nil || !info.IsDir() {
+		t.Errorf("empty directory @pix was not restored")
+	}
+}
@@ -0,0 +1,328 @@
+package project
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/parsiya/borrowedtime/config"
+	"github.com/parsiya/borrowedtime/shared"
+)
+
+// DefaultCaptureIgnore is the list of patterns that are never captured unless
+// "captureignore" is set in the config file. Client data should never end up
+// in templates.
+var DefaultCaptureIgnore = []string{
+	".git",
+	"@creds.md",
+	"@clientFiles/*",
+	"@pix/*",
+	"@findings/*",
+	"@evidence.jsonl",
+}
+
+// captureKnown maps files created by borrowed time to the file template that
+// creates them. Their content is never captured.
+var captureKnown = map[string]string{
+	".config.json": "project-config",
+}
+
+// DefaultCaptureMaxSize is the largest file in bytes that is turned into a
+// file template.
+const DefaultCaptureMaxSize = 64 * 1024
+
+// CaptureOptions controls how an existing directory is captured.
+type CaptureOptions struct {
+	// Files turns small text files into file templates.
+	Files bool
+	// MaxSize is the largest file in bytes that is turned into a file
+	// template.
+	MaxSize int64
+	// Ignore is a list of shell file name patterns. Each pattern is matched
+	// against the path relative to the captured directory and the file name.
+	// Matched files and directories (with their children) are skipped.
+	Ignore []string
+	// Overwrite existing templates.
+	Overwrite bool
+}
+
+// Capture contains the result of capturing a directory.
+type Capture struct {
+	// Root is the project template.
+	Root *Node
+	// FileTemplates is a map of file template filename to content.
+	FileTemplates map[string]string
+	// Ignored contains the relative paths that were skipped.
+	Ignored []string
+}
+
+// CaptureTemplate walks dir and stores the result as a project template named
+// name. If opts.Files is set, small text files are stored as new file
+// templates.
+func CaptureTemplate(dir, name string, opts CaptureOptions) (*Capture, error) {
+	if name == "" {
+		return nil, fmt.Errorf("project.CaptureTemplate: empty template name")
+	}
+	cpt, err := CaptureDir(dir, name, opts)
+	if err != nil {
+		return nil, fmt.Errorf("project.CaptureTemplate: %s", err.Error())
+	}
+
+	// Check every name before writing anything so we do not end up with half
+	// of the templates.
+	if !opts.Overwrite {
+		prjTmpls, err := config.ProjectTemplates()
+		if err != nil {
+			return nil, fmt.Errorf("project.CaptureTemplate: %s", err.Error())
+		}
+		if _, exists := prjTmpls[name]; exists {
+			return nil, fmt.Errorf("project.CaptureTemplate: project template %s already exists", name)
+		}
+		fileTmpls, err := config.FileTemplates()
+		if err != nil {
+			return nil, fmt.Errorf("project.CaptureTemplate: %s", err.Error())
+		}
+		for filename := range cpt.FileTemplates {
+			if _, exists := fileTmpls[shared.RemoveExtension(filename)]; exists {
+				return nil, fmt.Errorf("project.CaptureTemplate: file template %s already exists", filename)
+			}
+		}
+	}
+
+	for _, filename := range shared.SortedKeys(cpt.FileTemplates) {
+		err := config.AddFileTemplate(filename, cpt.FileTemplates[filename], opts.Overwrite)
+		if err != nil {
+			return nil, fmt.Errorf("project.CaptureTemplate: add file template - %s", err.Error())
+		}
+	}
+	prjTmpl, err := shared.StructToJSONString(cpt.Root, true)
+	if err != nil {
+		return nil, fmt.Errorf("project.CaptureTemplate: marshal template - %s", err.Error())
+	}
+	err = config.AddProjectTemplate(shared.AddExtension(name, "json"), prjTmpl, opts.Overwrite)
+	if err != nil {
+		return nil, fmt.Errorf("project.CaptureTemplate: add project template - %s", err.Error())
+	}
+	return cpt, nil
+}
+
+// CaptureDir walks dir and creates a project template from it without writing
+// anything to disk. The root of the template is always
+// "{{ .Workspace }}/{{ .ProjectName }}". name is used as the prefix of file
+// templates.
+func CaptureDir(dir, name string, opts CaptureOptions) (*Capture, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("project.CaptureDir: %s", err.Error())
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("project.CaptureDir: %s is not a directory", dir)
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultCaptureMaxSize
+	}
+
+	cpt := &Capture{
+		Root:          newNode("{{ .Workspace }}/{{ .ProjectName }}", filepath.Base(dir), true),
+		FileTemplates: make(map[string]string),
+	}
+	// Relative directory path to node.
+	dirs := map[string]*Node{".": cpt.Root}
+	replacer := captureReplacer(dir)
+
+	err = filepath.Walk(dir, func(pth string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		rel, err := filepath.Rel(dir, pth)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			return nil
+		}
+		// Create writes the hashes of the new project.
+		if rel == hashesFilename {
+			return nil
+		}
+		if ignored(rel, opts.Ignore) {
+			cpt.Ignored = append(cpt.Ignored, rel)
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		parent, exists := dirs[filepath.ToSlash(filepath.Dir(rel))]
+		if !exists {
+			// Parent was skipped.
+			return nil
+		}
+		node := newNode(info.Name(), info.Name(), info.IsDir())
+		parent.Children = append(parent.Children, node)
+		if info.IsDir() {
+			dirs[rel] = node
+			return nil
+		}
+
+		if tmpl, known := captureKnown[rel]; known {
+			node.Info.Template = tmpl
+			return nil
+		}
+		if !opts.Files || info.Size() > opts.MaxSize || !info.Mode().IsRegular() {
+			return nil
+		}
+		content, err := shared.ReadFileByte(pth)
+		if err != nil {
+			return err
+		}
+		// Empty files do not need a template.
+		if len(content) == 0 || !isText(content) {
+			return nil
+		}
+		filename := captureTemplateName(name, rel, cpt.FileTemplates)
+		cpt.FileTemplates[filename] = replacer.Replace(string(content))
+		node.Info.Template = shared.RemoveExtension(filename)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("project.CaptureDir: %s", err.Error())
+	}
+	return cpt, nil
+}
+
+// newNode returns a node with an empty list of children. Empty children are
+// marshalled as [] similar to the default project structure.
+func newNode(pth, name string, isDir bool) *Node {
+	return &Node{
+		FullPath: pth,
+		Info: &FileInfo{
+			Name:  name,
+			IsDir: isDir,
+		},
+		Children: []*Node{},
+	}
+}
+
+// ignored returns true if the relative path or its name match any of the
+// patterns.
+func ignored(rel string, patterns []string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if m, _ := filepath.Match(pattern, rel); m {
+			return true
+		}
+		if m, _ := filepath.Match(pattern, filepath.Base(rel)); m {
+			return true
+		}
+	}
+	return false
+}
+
+// isText returns true if content looks like a text file.
+func isText(content []byte) bool {
+	return utf8.Valid(content) && !bytes.Contains(content, []byte{0})
+}
+
+// captureReplacer returns a replacer that escapes template actions in captured
+// files and replaces the old workspace and project name with placeholders.
+func captureReplacer(dir string) *wordReplacer {
+	dir, _ = filepath.Abs(dir)
+	workspace := filepath.Dir(dir)
+	return newWordReplacer([][2]string{
+		{filepath.ToSlash(workspace), "{{ .Workspace }}"},
+		{filepath.FromSlash(workspace), "{{ .Workspace }}"},
+		{filepath.Base(dir), "{{ .ProjectName }}"},
+	})
+}
+
+// wordReplacer escapes template actions and replaces whole words with
+// actions. A name is not replaced inside other words, e.g., the project "acme"
+// in "acmecorp".
+type wordReplacer struct {
+	re   *regexp.Regexp
+	repl map[string]string
+}
+
+// newWordReplacer returns a wordReplacer for pairs of old and new strings.
+// Longer strings are matched first, e.g., paths that contain the project name.
+func newWordReplacer(pairs [][2]string) *wordReplacer {
+	r := &wordReplacer{repl: map[string]string{
+		// Escape existing actions so they are printed verbatim.
+		"{{": "{{`{{`}}",
+		"}}": "{{`}}`}}",
+	}}
+	sorted := make([][2]string, 0, len(pairs))
+	for _, kv := range pairs {
+		if kv[0] != "" {
+			sorted = append(sorted, kv)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return len(sorted[i][0]) > len(sorted[j][0]) })
+
+	exprs := []string{`\{\{`, `\}\}`}
+	for _, kv := range sorted {
+		if _, exists := r.repl[kv[0]]; exists {
+			continue
+		}
+		r.repl[kv[0]] = kv[1]
+		expr := regexp.QuoteMeta(kv[0])
+		// \b only works next to word characters, e.g., not after "/".
+		if isWordChar(kv[0][0]) {
+			expr = `\b` + expr
+		}
+		if isWordChar(kv[0][len(kv[0])-1]) {
+			expr += `\b`
+		}
+		exprs = append(exprs, expr)
+	}
+	r.re = regexp.MustCompile(strings.Join(exprs, "|"))
+	return r
+}
+
+// Replace returns s with the replacements.
+func (r *wordReplacer) Replace(s string) string {
+	return r.re.ReplaceAllStringFunc(s, func(m string) string { return r.repl[m] })
+}
+
+// isWordChar returns true if c matches \w.
+func isWordChar(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+var nonSlug = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// captureTemplateName returns a unique file template filename for rel. E.g.,
+// "@report/notes.md" becomes "name-report-notes.md".
+func captureTemplateName(name, rel string, existing map[string]string) string {
+	ext := filepath.Ext(rel)
+	base := strings.TrimSuffix(rel, ext)
+	// Dot files (e.g., ".gitignore") have no base name.
+	if base == "" || strings.HasSuffix(base, "/") {
+		base, ext = rel, ""
+	}
+	slug := strings.Trim(nonSlug.ReplaceAllString(base, "-"), "-")
+	filename := name + "-" + slug + ext
+	// Template names are unique without the extension.
+	for i := 2; ; i++ {
+		taken := false
+		for fi := range existing {
+			if shared.RemoveExtension(fi) == shared.RemoveExtension(filename) {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			return filename
+		}
+		filename = fmt.Sprintf("%s-%s-%d%s", name, slug, i, ext)
+	}
+}
@@ -0,0 +1,125 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIgnored(t *testing.T) {
+	tests := []struct {
+		rel  string
+		want bool
+	}{
+		{".git", true},
+		{"@creds.md", true},
+		{"@clientFiles/scope.pdf", true},
+		{"@pix/login.png", true},
+		{"@pix", false},
+		{"@findings/F-001-xss.md", true},
+		{"@findings", false},
+		{"@evidence.jsonl", true},
+		{"notes/@creds.md", true},
+		{"notes/creds.md", false},
+		{"src/.git", true},
+		{"@report/report.json", false},
+	}
+	for _, tt := range tests {
+		if got := ignored(tt.rel, DefaultCaptureIgnore); got != tt.want {
+			t.Errorf("ignored(%q) = %v, want %v", tt.rel, got, tt.want)
+		}
+	}
+	if ignored("notes.md", []string{" ", ""}) {
+		t.Error("ignored() matched an empty pattern")
+	}
+}
+
+func TestCaptureReplacer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "acme")
+	ws := filepath.ToSlash(filepath.Dir(dir))
+	r := captureReplacer(dir)
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"project", "# acme notes", "# {{ .ProjectName }} notes"},
+		{"inside-words", "acmecorp and notacme", "acmecorp and notacme"},
+		{"punctuation", "acme-web (acme).", "{{ .ProjectName }}-web ({{ .ProjectName }})."},
+		{"workspace", "cd " + ws + "/acme/@pix", "cd {{ .Workspace }}/{{ .ProjectName }}/@pix"},
+		{"workspace-prefix", ws + "2/acme", ws + "2/{{ .ProjectName }}"},
+		{"actions", "{{ .Title }} and }}", "{{`{{`}} .Title {{`}}`}} and {{`}}`}}"},
+		{"actions-around-name", "{{acme}}", "{{`{{`}}{{ .ProjectName }}{{`}}`}}"},
+	}
+	for _, tt := range tests {
+		if got := r.Replace(tt.in); got != tt.want {
+			t.Errorf("%s: Replace(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCaptureDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "acme")
+	for name, content := range map[string]string{
+		"@notes.md":              "acme notes {{ x }}",
+		"@creds.md":              "admin:password",
+		"@pix/login.png":         "png",
+		"@report/report.json":    `{"title": "acme"}`,
+		"@findings.md":           "",
+		"bin/tool.exe":           "MZ\x00\x00",
+		".config.json":           "{}",
+		".hashes.json":           `{"@notes.md": "0000"}`,
+		"@clientFiles/scope.txt": "scope",
+		"@findings/F-001-xss.md": "---\ntitle: XSS in acme login\n---\n",
+		"@evidence.jsonl":        `{"action":"added","path":"@pix/login.png","capturedby":"tester"}`,
+	} {
+		pth := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(pth), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(pth, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cpt, err := CaptureDir(dir, "web", CaptureOptions{Files: true, Ignore: DefaultCaptureIgnore})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantIgnored := []string{"@clientFiles/scope.txt", "@creds.md", "@evidence.jsonl", "@findings/F-001-xss.md",
+		"@pix/login.png"}
+	if !reflect.DeepEqual(cpt.Ignored, wantIgnored) {
+		t.Errorf("CaptureDir() ignored = %q, want %q", cpt.Ignored, wantIgnored)
+	}
+	wantFiles := map[string]string{
+		"web-notes.md":           "{{ .ProjectName }} notes {{`{{`}} x {{`}}`}}",
+		"web-report-report.json": `{"title": "{{ .ProjectName }}"}`,
+	}
+	if !reflect.DeepEqual(cpt.FileTemplates, wantFiles) {
+		t.Errorf("CaptureDir() file templates = %q, want %q", cpt.FileTemplates, wantFiles)
+	}
+	templates := make(map[string]string)
+	var walk func(n *Node, prefix string)
+	walk = func(n *Node, prefix string) {
+		for _, c := range n.Children {
+			templates[prefix+c.FullPath] = c.Info.Template
+			walk(c, prefix+c.FullPath+"/")
+		}
+	}
+	walk(cpt.Root, "")
+	wantTemplates := map[string]string{
+		".config.json":        "project-config",
+		"@clientFiles":        "",
+		"@findings":           "",
+		"@findings.md":        "",
+		"@notes.md":           "web-notes",
+		"@pix":                "",
+		"@report":             "",
+		"@report/report.json": "web-report-report",
+		"bin":                 "",
+		"bin/tool.exe":        "",
+	}
+	if !reflect.DeepEqual(templates, wantTemplates) {
+		t.Errorf("CaptureDir() nodes = %q, want %q", templates, wantTemplates)
+	}
+}
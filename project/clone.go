@@ -0,0 +1,252 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/parsiya/borrowedtime/shared"
+)
+
+const (
+	// keyClonedFrom is the name of the project a project was cloned from.
+	keyClonedFrom = "clonedfrom"
+	// keyEvidenceDirs is a comma separated list of evidence directories in the
+	// workspace config. They are not copied when a project is cloned.
+	keyEvidenceDirs = "evidencedirs"
+	// keyFindings is the path to the findings file in the project config.
+	keyFindings = "findings"
+	// defaultFindingsFile is used if the project config has no findings file.
+	defaultFindingsFile = "@findings.md"
+	// RetestTag is added to retest projects.
+	RetestTag = "retest"
+	// retestMarker starts the line added under each finding in a retest.
+	retestMarker = "**Retest:**"
+)
+
+// defaultEvidenceDirs are used if evidencedirs is not in the workspace config.
+var defaultEvidenceDirs = []string{pixDir, clientFilesDir}
+
+// CloneOptions changes what is copied to the new project.
+type CloneOptions struct {
+	// Retest marks every copied finding as to be retested with a link to the
+	// original finding.
+	Retest bool
+	// Evidence copies the evidence directories.
+	Evidence bool
+}
+
+// Clone creates a new project from the template of the source project and
+// copies the source files except the evidence directories and hidden
+// directories. The client, type and tags are copied. If a step fails after the
+// new project is created, it is removed.
+func (p *Project) Clone(dstName string, opts CloneOptions) (*Project, error) {
+	if err := validProjectName(dstName); err != nil {
+		return nil, fmt.Errorf("project.Project.Clone: %s", err.Error())
+	}
+	dst := New(dstName)
+	exists, err := shared.PathExists(dst.Root())
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.Clone: %s", err.Error())
+	}
+	if exists {
+		return nil, fmt.Errorf("project.Project.Clone: %s already exists", dst.Root())
+	}
+	templateName := p.ProjectConfig[keyTemplate]
+	if templateName == "" {
+		templateName = p.Config["projectstructure"]
+	}
+	if templateName == "" {
+		return nil, fmt.Errorf("project.Project.Clone: %s has no project template", p.ProjectName)
+	}
+
+	for k, v := range p.Vars {
+		dst.Vars[k] = v
+	}
+	dst.Meta = Metadata{
+		Client: p.Meta.Client,
+		Type:   p.Meta.Type,
+		Tags:   ParseTags(p.Meta.Tags...),
+	}
+	if opts.Retest {
+		dst.Meta.Tags = ParseTags(append(dst.Meta.Tags, RetestTag)...)
+	}
+	if err := dst.Create(templateName, false); err != nil {
+		return nil, fmt.Errorf("project.Project.Clone: %s", err.Error())
+	}
+	if err := p.cloneTo(dst, opts); err != nil {
+		if delErr := shared.DeletePath(dst.Root()); delErr != nil {
+			return nil, fmt.Errorf("project.Project.Clone: %s, remove %s - %s", err.Error(), dst.Root(), delErr.Error())
+		}
+		if regErr := dst.unregister(); regErr != nil {
+			return nil, fmt.Errorf("project.Project.Clone: %s, %s was removed - %s", err.Error(), dstName, regErr.Error())
+		}
+		return nil, fmt.Errorf("project.Project.Clone: %s, %s was removed", err.Error(), dstName)
+	}
+	return dst, nil
+}
+
+// cloneTo copies the files to the new project, marks the findings for retest
+// and records the source in the project config.
+func (p *Project) cloneTo(dst *Project, opts CloneOptions) error {
+	skip := make(map[string]bool)
+	if !opts.Evidence {
+		for _, d := range evidenceDirs(p.Config[keyEvidenceDirs]) {
+			skip[d] = true
+		}
+		// The manifest would list missing evidence.
+		skip[evidenceManifestFilename] = true
+	}
+	if err := copyProjectFiles(p.Root(), dst.Root(), skip); err != nil {
+		return err
+	}
+
+	if opts.Retest {
+		findings := p.findingsFile()
+		pth := filepath.Join(dst.Root(), filepath.FromSlash(findings))
+		exists, err := shared.PathExists(pth)
+		if err != nil {
+			return err
+		}
+		if exists {
+			content, err := shared.ReadFileString(pth)
+			if err != nil {
+				return err
+			}
+			link := "../" + p.ProjectName + "/" + findings
+			marked, _ := markRetest(content, p.ProjectName, link)
+			if err := shared.WriteFileString(pth, marked, true); err != nil {
+				return err
+			}
+		}
+		if _, err := dst.markFindingsRetest(p); err != nil {
+			return err
+		}
+	}
+
+	// Create wrote the config and it's not copied.
+	if err := dst.readConfig(); err != nil {
+		// Templates without a project config.
+		dst.ProjectConfig = make(map[string]string)
+		dst.ProjectConfig[keyTemplate] = p.ProjectConfig[keyTemplate]
+	}
+	dst.ProjectConfig[keyClonedFrom] = p.ProjectName
+	dst.setVars()
+	dst.setMeta()
+	if err := dst.writeConfig(); err != nil {
+		return err
+	}
+	return dst.register()
+}
+
+// findingsFile returns the path of the findings file relative to the project
+// root in slash form.
+func (p Project) findingsFile() string {
+	pth := p.ProjectConfig[keyFindings]
+	if pth == "" {
+		return defaultFindingsFile
+	}
+	rel, err := filepath.Rel(p.Root(), filepath.FromSlash(pth))
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return defaultFindingsFile
+	}
+	return filepath.ToSlash(rel)
+}
+
+// evidenceDirs parses evidencedirs. An empty value returns the default.
+func evidenceDirs(value string) []string {
+	if strings.TrimSpace(value) == "" {
+		return defaultEvidenceDirs
+	}
+	return ParseTags(value)
+}
+
+// copyProjectFiles copies the files under src to dst and overwrites existing
+// files. The project config, the hashes file, hidden directories and the
+// top-level files and directories in skip are not copied.
+func copyProjectFiles(src, dst string, skip map[string]bool) error {
+	return filepath.Walk(src, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, pth)
+		if err != nil || rel == "." {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case info.IsDir():
+			if strings.HasPrefix(info.Name(), ".") || skip[filepath.ToSlash(rel)] {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case rel == configFilename || rel == hashesFilename || skip[filepath.ToSlash(rel)]:
+			return nil
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(pth)
+			if err != nil {
+				return err
+			}
+			os.Remove(target)
+			return os.Symlink(link, target)
+		default:
+			return shared.CopyFile(pth, target)
+		}
+	})
+}
+
+// findingHeading matches the title of a finding in the findings file.
+var findingHeading = regexp.MustCompile(`^##[ \t]+(.+?)[ \t]*$`)
+
+// markRetest adds a line under each finding heading that marks it as to be
+// retested with a link to the original finding. Existing retest lines are
+// replaced. Returns the new content and the number of findings.
+func markRetest(content, srcName, link string) (string, int) {
+	lines := strings.Split(content, "\n")
+	var out []string
+	n := 0
+	for i := 0; i < len(lines); i++ {
+		out = append(out, lines[i])
+		m := findingHeading.FindStringSubmatch(strings.TrimRight(lines[i], "\r"))
+		if m == nil {
+			continue
+		}
+		n++
+		// Skip the blank lines and the retest line of a previous retest.
+		j := skipBlank(lines, i+1)
+		if j < len(lines) && strings.HasPrefix(lines[j], retestMarker) {
+			j = skipBlank(lines, j+1)
+		}
+		out = append(out, "", fmt.Sprintf("%s to be retested, original finding: [%s: %s](%s#%s)",
+			retestMarker, srcName, m[1], link, headingAnchor(m[1])), "")
+		i = j - 1
+	}
+	return strings.Join(out, "\n"), n
+}
+
+// skipBlank returns the index of the first line from i that is not blank.
+func skipBlank(lines []string, i int) int {
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	return i
+}
+
+// headingAnchor returns the markdown anchor of a heading.
+func headingAnchor(heading string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ':
+			return '-'
+		case r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		case r > 127:
+			return r
+		}
+		return -1
+	}, heading)
+}
@@ -0,0 +1,161 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestMarkRetest(t *testing.T) {
+	link := "../acme/@findings.md"
+	tests := []struct {
+		name  string
+		in    string
+		want  string
+		wantN int
+	}{
+		{
+			name:  "no-findings",
+			in:    "# acme Findings\n",
+			want:  "# acme Findings\n",
+			wantN: 0,
+		},
+		{
+			name: "findings",
+			in:   "# Findings\n\n## SQL Injection\n\nDetails.\n## XSS\n",
+			want: "# Findings\n\n## SQL Injection\n\n" +
+				"**Retest:** to be retested, original finding: [acme: SQL Injection](../acme/@findings.md#sql-injection)\n\n" +
+				"Details.\n## XSS\n\n" +
+				"**Retest:** to be retested, original finding: [acme: XSS](../acme/@findings.md#xss)\n",
+			wantN: 2,
+		},
+		{
+			name: "previous-retest",
+			in: "## XSS\n\n**Retest:** to be retested, original finding: [old: XSS](../old/@findings.md#xss)\n\n" +
+				"Details.\n",
+			want: "## XSS\n\n" +
+				"**Retest:** to be retested, original finding: [acme: XSS](../acme/@findings.md#xss)\n\n" +
+				"Details.\n",
+			wantN: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, n := markRetest(tt.in, "acme", link)
+			if got != tt.want || n != tt.wantN {
+				t.Errorf("markRetest() = %q, %d, want %q, %d", got, n, tt.want, tt.wantN)
+			}
+		})
+	}
+}
+
+func TestHeadingAnchor(t *testing.T) {
+	tests := map[string]string{
+		"SQL Injection in Login": "sql-injection-in-login",
+		"CVE-2021-44228 (Log4j)": "cve-2021-44228-log4j",
+		"Weak TLS: RC4 & 3DES":   "weak-tls-rc4--3des",
+		"snake_case is kept":     "snake_case-is-kept",
+	}
+	for in, want := range tests {
+		if got := headingAnchor(in); got != want {
+			t.Errorf("headingAnchor(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCopyProjectFiles(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	for _, name := range []string{".config.json", ".hashes.json", "@notes.md", "@pix/shot.png", ".git/HEAD", "@report/report.json"} {
+		pth := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(pth), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(pth, []byte(name), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := copyProjectFiles(src, dst, map[string]bool{"@pix": true}); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{
+		".config.json":        false,
+		".hashes.json":        false,
+		"@notes.md":           true,
+		"@pix":                false,
+		".git":                false,
+		"@report/report.json": true,
+	} {
+		_, err := os.Stat(filepath.Join(dst, filepath.FromSlash(name)))
+		if got := err == nil; got != want {
+			t.Errorf("%s copied = %t, want %t", name, got, want)
+		}
+	}
+}
+
+func TestCloneRetestEvidence(t *testing.T) {
+	home := testHome(t)
+	src := New("acme")
+	if err := src.Create("project-structure", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, "ws", "acme", "@pix", "sqli.png"), []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.AddFinding(&Finding{Title: "SQL injection", Severity: "high",
+		Evidence: []string{"@pix/sqli.png", "https://acme.example/ticket/1"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		opts         CloneOptions
+		wantEvidence []string
+		wantNote     bool
+	}{
+		{"without-evidence", CloneOptions{Retest: true},
+			[]string{"https://acme.example/ticket/1"}, true},
+		{"with-evidence", CloneOptions{Retest: true, Evidence: true},
+			[]string{"@pix/sqli.png", "https://acme.example/ticket/1"}, false},
+	}
+	for _, tt := range tests {
+		dst, err := src.Clone("acme-"+tt.name, tt.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		findings, err := dst.Findings(FindingFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(findings) != 1 {
+			t.Fatalf("%s: cloned %d findings, want 1", tt.name, len(findings))
+		}
+		f := findings[0]
+		if f.Status != "retest" || !reflect.DeepEqual(f.Evidence, tt.wantEvidence) {
+			t.Errorf("%s: status = %q, evidence = %q, want retest, %q", tt.name, f.Status, f.Evidence, tt.wantEvidence)
+		}
+		m := regexp.MustCompile(`\[@pix/sqli\.png\]\(([^)]+)\)`).FindStringSubmatch(f.Notes)
+		if got := m != nil; got != tt.wantNote {
+			t.Errorf("%s: notes = %q", tt.name, f.Notes)
+		}
+		// The link is relative to the finding file and points to the source.
+		if m != nil {
+			target := filepath.Join(filepath.Dir(f.path), filepath.FromSlash(m[1]))
+			if target != filepath.Join(src.Root(), "@pix", "sqli.png") {
+				t.Errorf("%s: link %s points to %s", tt.name, m[1], target)
+			}
+			if _, err := os.Stat(target); err != nil {
+				t.Errorf("%s: link %s - %v", tt.name, m[1], err)
+			}
+		}
+		// The report does not reference missing evidence.
+		r, err := dst.NewReport()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.Write(); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+}
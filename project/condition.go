@@ -0,0 +1,29 @@
+package project
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// evalCondition evaluates a when expression against the project. The
+// expression is a template pipeline such as `eq .Vars.platform "mobile"` and
+// follows the rules of the if action. Empty expressions are always true.
+func evalCondition(p Project, expr string) (bool, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return true, nil
+	}
+	// Allow expressions wrapped in an action, e.g., "{{ .Vars.git }}".
+	expr = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(expr, "{{"), "}}"))
+
+	tmpl, err := template.New("when").Parse("{{ if " + expr + " }}true{{ end }}")
+	if err != nil {
+		return false, fmt.Errorf("project.evalCondition: parse %q - %s", expr, err.Error())
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, p); err != nil {
+		return false, fmt.Errorf("project.evalCondition: execute %q - %s", expr, err.Error())
+	}
+	return sb.String() == "true", nil
+}
@@ -0,0 +1,44 @@
+package project
+
+import (
+	"testing"
+)
+
+func TestEvalCondition(t *testing.T) {
+	p := Project{
+		ProjectName: "test1",
+		Config:      map[string]string{"git": "true"},
+		Vars:        map[string]string{"platform": "mobile"},
+	}
+	tests := []struct {
+		name    string
+		expr    string
+		want    bool
+		wantErr bool
+	}{
+		{"empty", "", true, false},
+		{"literal-true", "true", true, false},
+		{"literal-false", "false", false, false},
+		{"var-match", `eq .Vars.platform "mobile"`, true, false},
+		{"var-no-match", `eq .Vars.platform "web"`, false, false},
+		{"missing-var", `eq .Vars.missing "web"`, false, false},
+		{"config-set", `index .Config "git"`, true, false},
+		{"config-missing", `index .Config "burppath"`, false, false},
+		{"wrapped", `{{ eq .ProjectName "test1" }}`, true, false},
+		{"not", `not (eq .Vars.platform "mobile")`, false, false},
+		{"parse-error", `eq .Vars.platform "mobile`, false, true},
+		{"exec-error", `eq .Vars.platform`, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := evalCondition(p, tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("evalCondition() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("evalCondition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/parsiya/borrowedtime/config"
+	"github.com/parsiya/borrowedtime/shared"
+)
+
+// Project config keys set by borrowed time.
+const (
+	// configFilename is the project config file in the project root.
+	configFilename = ".config.json"
+	// keyTemplate is the project template used to create the project.
+	keyTemplate = "template"
+	// keyTemplateHash is the SHA-256 hash of the project template when the
+	// project was created or last synced.
+	keyTemplateHash = "templatehash"
+	// varPrefix is the prefix of template variables stored in the config.
+	varPrefix = "var."
+)
+
+// configPath returns the path to the project config file.
+func (p Project) configPath() string {
+	return filepath.Join(p.ProjectRoot, configFilename)
+}
+
+// HasConfig returns true if the project has a project config file.
+func (p Project) HasConfig() (bool, error) {
+	return shared.PathExists(p.configPath())
+}
+
+// readConfig reads the project config file into ProjectConfig.
+func (p *Project) readConfig() error {
+	cfgBytes, err := shared.ReadFileByte(p.configPath())
+	if err != nil {
+		return fmt.Errorf("project.Project.readConfig: read project config - %s", err.Error())
+	}
+	cfg := make(map[string]string)
+	if err := json.Unmarshal(cfgBytes, &cfg); err != nil {
+		return fmt.Errorf("project.Project.readConfig: unmarshal project config - %s", err.Error())
+	}
+	p.ProjectConfig = cfg
+	return nil
+}
+
+// writeConfig writes ProjectConfig to the project config file.
+func (p Project) writeConfig() error {
+	cfgString, err := shared.StructToJSONString(p.ProjectConfig, true)
+	if err != nil {
+		return fmt.Errorf("project.Project.writeConfig: %s", err.Error())
+	}
+	if err := shared.WriteFileString(p.configPath(), cfgString, true); err != nil {
+		return fmt.Errorf("project.Project.writeConfig: %s", err.Error())
+	}
+	return nil
+}
+
+// setVars stores the template variables in ProjectConfig.
+func (p *Project) setVars() {
+	for k, v := range p.Vars {
+		p.ProjectConfig[varPrefix+k] = v
+	}
+}
+
+// loadVars reads the template variables from ProjectConfig.
+func (p *Project) loadVars() {
+	for k, v := range p.ProjectConfig {
+		if strings.HasPrefix(k, varPrefix) {
+			p.Vars[strings.TrimPrefix(k, varPrefix)] = v
+		}
+	}
+}
+
+// RenameTemplate updates the project template recorded in the project config of
+// every project in the workspace that was created from oldName. Returns the
+// names of the updated projects.
+func RenameTemplate(oldName, newName string) ([]string, error) {
+	cfg, err := config.Read()
+	if err != nil {
+		return nil, fmt.Errorf("project.RenameTemplate: %s", err.Error())
+	}
+	dirs, err := ioutil.ReadDir(cfg.Key("workspace"))
+	if err != nil {
+		return nil, fmt.Errorf("project.RenameTemplate: %s", err.Error())
+	}
+	var updated []string
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		// Directories without a project config are not projects.
+		p, err := Load(dir.Name())
+		if err != nil || p.ProjectConfig[keyTemplate] != shared.RemoveExtension(oldName) {
+			continue
+		}
+		p.ProjectConfig[keyTemplate] = shared.RemoveExtension(newName)
+		if err := p.writeConfig(); err != nil {
+			return updated, fmt.Errorf("project.RenameTemplate: %s", err.Error())
+		}
+		updated = append(updated, p.ProjectName)
+	}
+	return updated, nil
+}
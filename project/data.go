@@ -0,0 +1,80 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/parsiya/borrowedtime/config"
+	"github.com/parsiya/borrowedtime/shared"
+)
+
+// readData reads the files in the data directory that can be used in
+// templates as .Data.filename. JSON files are decoded and txt files are split
+// into non-empty lines. Other files are ignored. Files that cannot be read are
+// skipped and the last error is returned.
+func readData() (data map[string]interface{}, err error) {
+	data = make(map[string]interface{})
+	// Configs deployed before data files do not have the directory.
+	dir, err := config.DataDir()
+	if err != nil {
+		return data, fmt.Errorf("project.readData: %s", err.Error())
+	}
+	if exists, err := shared.PathExists(dir); err != nil || !exists {
+		return data, err
+	}
+	files, err := config.DataFiles()
+	if err != nil {
+		return data, fmt.Errorf("project.readData: %s", err.Error())
+	}
+	for name, pth := range files {
+		if e := readDataFile(data, name, pth); e != nil {
+			err = e
+		}
+	}
+	return data, err
+}
+
+// loadData reads the data files into Data if they were not read before. Data
+// is only read by the functions that render templates.
+func (p *Project) loadData() error {
+	if p.Data != nil {
+		return nil
+	}
+	data, err := readData()
+	if err != nil {
+		return err
+	}
+	p.Data = data
+	return nil
+}
+
+// readDataFile reads one data file and adds it to data.
+func readDataFile(data map[string]interface{}, name, pth string) error {
+	switch strings.ToLower(filepath.Ext(pth)) {
+	case ".json":
+		content, err := shared.ReadFileByte(pth)
+		if err != nil {
+			return fmt.Errorf("project.readDataFile: %s", err.Error())
+		}
+		var v interface{}
+		if err := json.Unmarshal(content, &v); err != nil {
+			return fmt.Errorf("project.readDataFile: unmarshal %s - %s", pth, err.Error())
+		}
+		data[filepath.ToSlash(name)] = v
+	case ".txt":
+		content, err := shared.ReadFileString(pth)
+		if err != nil {
+			return fmt.Errorf("project.readDataFile: %s", err.Error())
+		}
+		var lines []string
+		for _, line := range strings.Split(content, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		data[filepath.ToSlash(name)] = lines
+	}
+	return nil
+}
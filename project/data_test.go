@@ -0,0 +1,52 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/parsiya/borrowedtime/config"
+)
+
+func TestLoadData(t *testing.T) {
+	testHome(t)
+	dir, err := config.DataDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("hosts.txt", "10.0.0.1\n\n 10.0.0.2 \n")
+	write("app.json", `{"name": "portal"}`)
+
+	p := New("acme")
+	if p.Data != nil {
+		t.Errorf("New() read the data files: %v", p.Data)
+	}
+	if err := p.loadData(); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"hosts": []string{"10.0.0.1", "10.0.0.2"},
+		"app":   map[string]interface{}{"name": "portal"},
+	}
+	if !reflect.DeepEqual(p.Data, want) {
+		t.Errorf("loadData() = %v, want %v", p.Data, want)
+	}
+
+	// Errors are returned by the functions that render templates.
+	write("app.json", `{"name": `)
+	p = New("acme")
+	err = p.Create("project-structure", false)
+	if err == nil || !strings.Contains(err.Error(), "app.json") {
+		t.Errorf("Create() error = %v, want an error for app.json", err)
+	}
+	if _, err := os.Stat(p.Root()); !os.IsNotExist(err) {
+		t.Errorf("Create() created the project with invalid data - %v", err)
+	}
+}
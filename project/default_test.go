@@ -0,0 +1,67 @@
+package project
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/parsiya/borrowedtime/config"
+)
+
+// TestDefaultTemplates checks that the default project templates only use
+// default file templates and every default file template is used.
+func TestDefaultTemplates(t *testing.T) {
+	tmpls, err := config.DefaultTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unused := make(map[string]bool)
+	for _, tm := range tmpls {
+		if tm.Kind == config.FileKind {
+			unused[tm.Name] = true
+		}
+	}
+	p := Project{ProjectName: "acme", Workspace: "/ws", ProjectRoot: "/ws/acme"}
+
+	for _, tm := range tmpls {
+		if tm.Kind != config.ProjectKind {
+			continue
+		}
+		content, err := config.DefaultContent(tm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tmpl, err := template.New(tm.Name).Parse(content)
+		if err != nil {
+			t.Fatalf("%s: %v", tm.Name, err)
+		}
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, p); err != nil {
+			t.Fatalf("%s: %v", tm.Name, err)
+		}
+		root, err := parseProjectTemplate(sb.String())
+		if err != nil {
+			t.Fatalf("%s: %v", tm.Name, err)
+		}
+		err = root.walk(p, func(n *Node, _ Project) error {
+			if err := n.Info.validate(); err != nil {
+				t.Errorf("%s: %s - %v", tm.Name, n.FullPath, err)
+			}
+			if name := n.Info.Template; name != "" {
+				if _, ok := unused[name]; !ok {
+					t.Errorf("%s: %s uses missing file template %s", tm.Name, n.FullPath, name)
+				}
+				unused[name] = false
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, ok := range unused {
+		if ok {
+			t.Errorf("default file template %s is not used by a default project template", name)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package project
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Default template delimiters.
+const (
+	defaultLeftDelim  = "{{"
+	defaultRightDelim = "}}"
+)
+
+// delims are the action delimiters of a template. The zero value uses the
+// default delimiters.
+type delims struct {
+	Left, Right string
+}
+
+// left returns the left delimiter.
+func (d delims) left() string {
+	if d.Left == "" {
+		return defaultLeftDelim
+	}
+	return d.Left
+}
+
+// right returns the right delimiter.
+func (d delims) right() string {
+	if d.Right == "" {
+		return defaultRightDelim
+	}
+	return d.Right
+}
+
+// delimsHeader matches the optional first line of a template that changes its
+// delimiters, e.g., {{/* delims [[ ]] */}}. The header is a comment so tools
+// that do not support it ignore it.
+var delimsHeader = regexp.MustCompile(`^[ \t]*\{\{-?[ \t]*/\*[ \t]*delims[ \t]+(\S+)[ \t]+(\S+)[ \t]*\*/[ \t]*-?\}\}[ \t]*(\r?\n|$)`)
+
+// parseDelims returns the template without the delimiters header and the
+// delimiters in the header. Templates without the header use the default
+// delimiters.
+func parseDelims(tmpl string) (string, delims) {
+	m := delimsHeader.FindStringSubmatch(tmpl)
+	if m == nil {
+		return tmpl, delims{}
+	}
+	return tmpl[len(m[0]):], delims{Left: m[1], Right: m[2]}
+}
+
+// hasAction returns true if s might contain an action.
+func (d delims) hasAction(s string) bool {
+	return strings.Contains(s, d.left())
+}
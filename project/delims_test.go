@@ -0,0 +1,31 @@
+package project
+
+import "testing"
+
+func TestParseDelims(t *testing.T) {
+	tests := []struct {
+		name       string
+		tmpl       string
+		wantTmpl   string
+		wantDelims delims
+	}{
+		{"header", "{{/* delims [[ ]] */}}\n# [[ .ProjectName ]] {{ .Values.name }}\n",
+			"# [[ .ProjectName ]] {{ .Values.name }}\n", delims{"[[", "]]"}},
+		{"crlf-trim-markers", "{{- /* delims <% %> */ -}}\r\nbody", "body", delims{"<%", "%>"}},
+		{"header-only", "{{/* delims [[ ]] */}}", "", delims{"[[", "]]"}},
+		{"no-header", "# {{ .ProjectName }}\n", "# {{ .ProjectName }}\n", delims{}},
+		{"not-first-line", "# notes\n{{/* delims [[ ]] */}}\n", "# notes\n{{/* delims [[ ]] */}}\n", delims{}},
+		{"other-comment", "{{/* notes template */}}\n", "{{/* notes template */}}\n", delims{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotTmpl, gotDelims := parseDelims(tt.tmpl)
+			if gotTmpl != tt.wantTmpl {
+				t.Errorf("parseDelims() template = %q, want %q", gotTmpl, tt.wantTmpl)
+			}
+			if gotDelims != tt.wantDelims {
+				t.Errorf("parseDelims() delims = %v, want %v", gotDelims, tt.wantDelims)
+			}
+		})
+	}
+}
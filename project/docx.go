@@ -0,0 +1,757 @@
+package project
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"image"
+	// Decoders of the images that are embedded in DOCX reports.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/parsiya/borrowedtime/config"
+	"github.com/parsiya/borrowedtime/shared"
+)
+
+// DOCX reports fill a skeleton created in Word. Placeholders are "{{name}}"
+// in the text of the document. Paragraphs with only "{{#findings}}" and
+// "{{/findings}}" mark a section that is repeated for each finding. Block
+// placeholders (e.g., "{{summary}}") must be alone in their paragraph and are
+// replaced with paragraphs, tables and images.
+
+const (
+	docxDocument      = "word/document.xml"
+	docxRels          = "word/_rels/document.xml.rels"
+	docxContentTypes  = "[Content_Types].xml"
+	docxSectionStart  = "#findings"
+	docxSectionEnd    = "/findings"
+	docxFindingPrefix = "finding."
+	docxConfigPrefix  = "config."
+	// docxMaxWidth is the maximum width of images in EMUs (6 inches).
+	docxMaxWidth = 5486400
+	// docxEMUPerPixel converts pixels to EMUs at 96 DPI.
+	docxEMUPerPixel = 9525
+
+	relImage     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/image"
+	relHyperlink = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink"
+	nsR          = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	nsWP         = "http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing"
+)
+
+// docxImageTypes are the content types of images that can be embedded.
+var docxImageTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+}
+
+var (
+	// Self-closing paragraphs (<w:p/>) are empty and not matched.
+	docxParagraphRE   = regexp.MustCompile(`(?s)<w:p(?:\s|\s[^>]*[^/>])?>.*?</w:p>`)
+	docxRunRE         = regexp.MustCompile(`(?s)<w:r(?:\s[^>]*)?>.*?</w:r>`)
+	docxTextRE        = regexp.MustCompile(`(?s)<w:t(?:\s[^>]*)?>(.*?)</w:t>|<w:t(?:\s[^>]*)?/>`)
+	docxPPrRE         = regexp.MustCompile(`(?s)<w:pPr>.*?</w:pPr>`)
+	docxRelIDRE       = regexp.MustCompile(`Id="rId(\d+)"`)
+	docxPlaceholderRE = regexp.MustCompile(`\{\{\s*([#/]?[A-Za-z][A-Za-z0-9._-]*)\s*\}\}`)
+	docxRootRE        = regexp.MustCompile(`<w:document(?:\s[^>]*)?>`)
+)
+
+// docxBlocks are the placeholders that are replaced with paragraphs.
+var docxBlocks = map[string]bool{
+	"summary":             true,
+	"findings-table":      true,
+	"finding.description": true,
+	"finding.remediation": true,
+	"finding.evidence":    true,
+	docxSectionStart:      true,
+	docxSectionEnd:        true,
+}
+
+// docx is a DOCX file that is being filled.
+type docx struct {
+	r *Report
+	// files are the parts of the zip file and names keeps their order.
+	files map[string][]byte
+	names []string
+	// rels are the relationships added to the document.
+	rels    []string
+	nextRel int
+	// images are the relationship IDs of embedded evidence.
+	images map[string]docxImage
+	// nextID is the ID of the next drawing.
+	nextID int
+}
+
+// docxImage is an embedded image.
+type docxImage struct {
+	rel           string
+	width, height int
+}
+
+// isDOCX returns true if the template is a DOCX skeleton.
+func isDOCX(pth string) bool {
+	return strings.ToLower(path.Ext(pth)) == ".docx"
+}
+
+// renderDOCX fills a DOCX skeleton.
+func (r *Report) renderDOCX(t config.Template) (*docx, error) {
+	d, err := readDOCX(t.FullPath)
+	if err != nil {
+		return nil, fmt.Errorf("read report template %s - %s", t.Name, err.Error())
+	}
+	d.r = r
+	if err := d.fill(); err != nil {
+		return nil, fmt.Errorf("execute report template %s - %s", t.Name, err.Error())
+	}
+	return d, nil
+}
+
+// readDOCX reads the parts of a DOCX file.
+func readDOCX(pth string) (*docx, error) {
+	zr, err := zip.OpenReader(pth)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	d := &docx{
+		files:  make(map[string][]byte),
+		images: make(map[string]docxImage),
+		nextID: 1000,
+	}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		d.files[f.Name] = content
+		d.names = append(d.names, f.Name)
+	}
+	for _, name := range []string{docxDocument, docxRels, docxContentTypes} {
+		if _, ok := d.files[name]; !ok {
+			return nil, fmt.Errorf("%s not found, not a DOCX file", name)
+		}
+	}
+	for _, m := range docxRelIDRE.FindAllStringSubmatch(string(d.files[docxRels]), -1) {
+		if n, _ := strconv.Atoi(m[1]); n >= d.nextRel {
+			d.nextRel = n + 1
+		}
+	}
+	return d, nil
+}
+
+// fill replaces the placeholders in the document, headers and footers and
+// adds the relationships and content types of the new parts.
+func (d *docx) fill() error {
+	doc, err := d.fillDocument(string(d.files[docxDocument]))
+	if err != nil {
+		return err
+	}
+	d.files[docxDocument] = []byte(addNamespaces(doc))
+
+	for _, name := range d.names {
+		if !strings.HasPrefix(name, "word/header") && !strings.HasPrefix(name, "word/footer") {
+			continue
+		}
+		// Only text placeholders are supported in headers and footers because
+		// they have their own relationships.
+		var err error
+		out := docxParagraphRE.ReplaceAllStringFunc(string(d.files[name]), func(p string) string {
+			if err != nil {
+				return p
+			}
+			if block := blockPlaceholder(p); docxBlocks[block] {
+				err = fmt.Errorf("{{%s}} cannot be used in headers and footers", block)
+				return p
+			}
+			var e error
+			p, e = d.fillText(p, nil)
+			err = e
+			return p
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %s", name, err.Error())
+		}
+		d.files[name] = []byte(out)
+	}
+
+	if len(d.rels) > 0 {
+		rels := string(d.files[docxRels])
+		i := strings.LastIndex(rels, "</Relationships>")
+		if i == -1 {
+			return fmt.Errorf("%s: Relationships not found", docxRels)
+		}
+		d.files[docxRels] = []byte(rels[:i] + strings.Join(d.rels, "") + rels[i:])
+	}
+
+	for name, content := range d.files {
+		if strings.HasSuffix(name, ".xml") || strings.HasSuffix(name, ".rels") {
+			if err := validateXML(content); err != nil {
+				return fmt.Errorf("%s is not valid XML - %s", name, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// addNamespaces declares the namespaces used by images and links in the root
+// of the document if they are missing.
+func addNamespaces(doc string) string {
+	loc := docxRootRE.FindStringIndex(doc)
+	if loc == nil {
+		return doc
+	}
+	root := doc[loc[0]:loc[1]]
+	var add string
+	if !strings.Contains(root, "xmlns:r=") {
+		add += ` xmlns:r="` + nsR + `"`
+	}
+	if !strings.Contains(root, "xmlns:wp=") {
+		add += ` xmlns:wp="` + nsWP + `"`
+	}
+	return doc[:loc[1]-1] + add + doc[loc[1]-1:]
+}
+
+// validateXML returns an error if content is not well-formed XML.
+func validateXML(content []byte) error {
+	dec := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		if _, err := dec.Token(); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// bytes returns the DOCX file.
+func (d *docx) bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range d.names {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(d.files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// addFile adds a new part.
+func (d *docx) addFile(name string, content []byte) {
+	if _, ok := d.files[name]; !ok {
+		d.names = append(d.names, name)
+	}
+	d.files[name] = content
+}
+
+// addRel adds a relationship to the document and returns its ID.
+func (d *docx) addRel(typ, target string, external bool) string {
+	id := fmt.Sprintf("rId%d", d.nextRel)
+	d.nextRel++
+	mode := ""
+	if external {
+		mode = ` TargetMode="External"`
+	}
+	d.rels = append(d.rels, fmt.Sprintf(`<Relationship Id="%s" Type="%s" Target="%s"%s/>`,
+		id, typ, xmlEscape(target), mode))
+	return id
+}
+
+// addContentType adds the content type of an extension if it's missing.
+func (d *docx) addContentType(ext, typ string) {
+	types := string(d.files[docxContentTypes])
+	if strings.Contains(strings.ToLower(types), `extension="`+ext+`"`) {
+		return
+	}
+	i := strings.LastIndex(types, "</Types>")
+	if i == -1 {
+		return
+	}
+	d.files[docxContentTypes] = []byte(types[:i] +
+		fmt.Sprintf(`<Default Extension="%s" ContentType="%s"/>`, ext, typ) + types[i:])
+}
+
+// fillDocument fills the paragraphs of the document and repeats the findings
+// sections. Each paragraph is only filled once so placeholders in the values
+// (e.g., the description of a finding) are not replaced.
+func (d *docx) fillDocument(doc string) (string, error) {
+	var sb strings.Builder
+	locs := docxParagraphRE.FindAllStringIndex(doc, -1)
+	last := 0
+	for i := 0; i < len(locs); i++ {
+		loc := locs[i]
+		sb.WriteString(doc[last:loc[0]])
+		last = loc[1]
+		p := doc[loc[0]:loc[1]]
+		if blockPlaceholder(p) != docxSectionStart {
+			out, err := d.fillParagraph(p, nil)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(out)
+			continue
+		}
+		// Find the end of the section.
+		end := -1
+		for j := i + 1; j < len(locs); j++ {
+			if blockPlaceholder(doc[locs[j][0]:locs[j][1]]) == docxSectionEnd {
+				end = j
+				break
+			}
+		}
+		if end == -1 {
+			return "", fmt.Errorf("{{%s}} is not closed with {{%s}}", docxSectionStart, docxSectionEnd)
+		}
+		section := doc[loc[1]:locs[end][0]]
+		for _, f := range d.r.Findings {
+			out, err := d.fillSection(section, f)
+			if err != nil {
+				return "", fmt.Errorf("%s: %s", f.ID, err.Error())
+			}
+			sb.WriteString(out)
+		}
+		i, last = end, locs[end][1]
+	}
+	sb.WriteString(doc[last:])
+	return sb.String(), nil
+}
+
+// fillSection fills the paragraphs of a findings section with a finding.
+func (d *docx) fillSection(section string, f *Finding) (string, error) {
+	var err error
+	out := docxParagraphRE.ReplaceAllStringFunc(section, func(p string) string {
+		if err != nil {
+			return p
+		}
+		var e error
+		p, e = d.fillParagraph(p, f)
+		err = e
+		return p
+	})
+	return out, err
+}
+
+// paragraphText returns the text of the runs in a paragraph.
+func paragraphText(p string) string {
+	var sb strings.Builder
+	for _, run := range docxRunRE.FindAllString(p, -1) {
+		sb.WriteString(runText(run))
+	}
+	return sb.String()
+}
+
+// runText returns the text of a run.
+func runText(run string) string {
+	var sb strings.Builder
+	for _, m := range docxTextRE.FindAllStringSubmatch(run, -1) {
+		sb.WriteString(html.UnescapeString(m[1]))
+	}
+	return sb.String()
+}
+
+// blockPlaceholder returns the name of the placeholder if it's the only text
+// in the paragraph.
+func blockPlaceholder(p string) string {
+	text := strings.TrimSpace(paragraphText(p))
+	m := docxPlaceholderRE.FindStringSubmatch(text)
+	if m == nil || m[0] != text {
+		return ""
+	}
+	return m[1]
+}
+
+// fillParagraph replaces the placeholders in a paragraph. f is nil outside of
+// findings sections.
+func (d *docx) fillParagraph(p string, f *Finding) (string, error) {
+	name := blockPlaceholder(p)
+	if !docxBlocks[name] {
+		return d.fillText(p, f)
+	}
+	if strings.HasPrefix(name, docxFindingPrefix) && f == nil {
+		return "", fmt.Errorf("{{%s}} is outside of {{%s}}", name, docxSectionStart)
+	}
+	pPr := docxPPrRE.FindString(p)
+	switch name {
+	case "summary":
+		rows := [][]string{{"Severity", "Findings", "Open"}}
+		for _, s := range d.r.Summary {
+			rows = append(rows, []string{strings.Title(s.Severity), strconv.Itoa(s.Total), strconv.Itoa(s.Open)})
+		}
+		return docxTable(rows), nil
+	case "findings-table":
+		rows := [][]string{{"ID", "Severity", "CVSS", "Status", "Title"}}
+		for _, f := range d.r.Findings {
+			rows = append(rows, []string{f.ID, strings.Title(f.Severity), findingScore(f), f.Status, f.Title})
+		}
+		return docxTable(rows), nil
+	case "finding.description":
+		return d.markdown(f.Description, pPr)
+	case "finding.remediation":
+		return d.markdown(f.Remediation, pPr)
+	case "finding.evidence":
+		var sb strings.Builder
+		for _, e := range f.Evidence {
+			if run, ok, err := d.imageRun(e, e); err != nil {
+				return "", err
+			} else if ok {
+				sb.WriteString("<w:p>" + pPr + run + "</w:p>")
+			}
+			sb.WriteString("<w:p>" + pPr + docxRun(e, "") + "</w:p>")
+		}
+		return sb.String(), nil
+	}
+	return "", fmt.Errorf("{{%s}} without {{%s}}", name, docxSectionStart)
+}
+
+// fillText replaces text placeholders in a paragraph. Word splits text into
+// runs so the text of a placeholder is moved into the run where it starts.
+func (d *docx) fillText(p string, f *Finding) (string, error) {
+	locs := docxRunRE.FindAllStringIndex(p, -1)
+	texts := make([]string, len(locs))
+	starts := make([]int, len(locs))
+	full := ""
+	for i, loc := range locs {
+		texts[i] = runText(p[loc[0]:loc[1]])
+		starts[i] = len(full)
+		full += texts[i]
+	}
+	matches := docxPlaceholderRE.FindAllStringSubmatchIndex(full, -1)
+	if len(matches) == 0 {
+		return p, nil
+	}
+	// runAt returns the run with the byte at pos in the original text.
+	runAt := func(pos int) int {
+		for i := range starts {
+			if pos < starts[i]+len(texts[i]) {
+				return i
+			}
+		}
+		return len(starts) - 1
+	}
+	// Runs are found before anything is replaced.
+	var spans [][2]int
+	for _, m := range matches {
+		spans = append(spans, [2]int{runAt(m[0]), runAt(m[1] - 1)})
+	}
+	changed := make([]bool, len(locs))
+	// Replace from the end so the offsets of earlier placeholders are valid.
+	for k := len(matches) - 1; k >= 0; k-- {
+		m := matches[k]
+		name := full[m[2]:m[3]]
+		if docxBlocks[name] {
+			return "", fmt.Errorf("{{%s}} must be alone in its paragraph", name)
+		}
+		value, err := d.value(name, f)
+		if err != nil {
+			return "", err
+		}
+		// Later placeholders only change the text after this one and never
+		// empty the run where this one ends.
+		i, j := spans[k][0], spans[k][1]
+		texts[i] = texts[i][:m[0]-starts[i]] + value + texts[j][m[1]-starts[j]:]
+		changed[i] = true
+		for x := i + 1; x <= j; x++ {
+			texts[x], changed[x] = "", true
+		}
+	}
+	var sb strings.Builder
+	last := 0
+	for i, loc := range locs {
+		sb.WriteString(p[last:loc[0]])
+		last = loc[1]
+		run := p[loc[0]:loc[1]]
+		if changed[i] {
+			run = setRunText(run, texts[i])
+		}
+		sb.WriteString(run)
+	}
+	sb.WriteString(p[last:])
+	return sb.String(), nil
+}
+
+// setRunText replaces the text elements of a run with text.
+func setRunText(run, text string) string {
+	first := true
+	return docxTextRE.ReplaceAllStringFunc(run, func(string) string {
+		if !first {
+			return ""
+		}
+		first = false
+		return docxText(text)
+	})
+}
+
+// value returns the value of a text placeholder.
+func (d *docx) value(name string, f *Finding) (string, error) {
+	r := d.r
+	if strings.HasPrefix(name, docxConfigPrefix) {
+		v, ok := r.Config[strings.TrimPrefix(name, docxConfigPrefix)]
+		if !ok {
+			return "", nil
+		}
+		return fmt.Sprint(v), nil
+	}
+	if strings.HasPrefix(name, docxFindingPrefix) {
+		if f == nil {
+			return "", fmt.Errorf("{{%s}} is outside of {{%s}}", name, docxSectionStart)
+		}
+		switch strings.TrimPrefix(name, docxFindingPrefix) {
+		case "id":
+			return f.ID, nil
+		case "title":
+			return f.Title, nil
+		case "severity":
+			return strings.Title(f.Severity), nil
+		case "status":
+			return f.Status, nil
+		case "cvss":
+			return f.CVSS, nil
+		case "score":
+			return findingScore(f), nil
+		case "assets":
+			return strings.Join(f.Assets, ", "), nil
+		case "references":
+			return strings.Join(f.References, ", "), nil
+		case "created":
+			return f.Created, nil
+		case "updated":
+			return f.Updated, nil
+		}
+		return "", fmt.Errorf("unknown placeholder {{%s}}", name)
+	}
+	switch name {
+	case "title":
+		return r.Title, nil
+	case "client":
+		return r.Client, nil
+	case "author":
+		return r.Author, nil
+	case "date":
+		return r.Date, nil
+	case "project":
+		return r.Project.ProjectName, nil
+	case "type":
+		return r.Project.Meta.Type, nil
+	case "start":
+		return r.Project.Meta.Start, nil
+	case "end":
+		return r.Project.Meta.End, nil
+	}
+	return "", fmt.Errorf("unknown placeholder {{%s}}", name)
+}
+
+// findingScore returns the CVSS score of a finding with one decimal or an
+// empty string.
+func findingScore(f *Finding) string {
+	if score, ok := f.CVSSScore(); ok {
+		return fmt.Sprintf("%.1f", score)
+	}
+	return ""
+}
+
+// markdown converts markdown to paragraphs. Paragraphs and headings use the
+// paragraph properties of the placeholder.
+func (d *docx) markdown(s, pPr string) (string, error) {
+	var sb strings.Builder
+	for _, b := range parseMarkdown(s) {
+		switch b.Kind {
+		case mdCode:
+			sb.WriteString(`<w:p><w:pPr><w:shd w:val="clear" w:color="auto" w:fill="F2F2F2"/></w:pPr>`)
+			for i, line := range b.Items {
+				if i > 0 {
+					sb.WriteString("<w:r><w:br/></w:r>")
+				}
+				sb.WriteString(docxRun(line, docxCodeRPr))
+			}
+			sb.WriteString("</w:p>")
+		case mdList, mdOrderedList:
+			for i, item := range b.Items {
+				bullet := "•"
+				if b.Kind == mdOrderedList {
+					bullet = strconv.Itoa(i+1) + "."
+				}
+				runs, err := d.inline(item, "")
+				if err != nil {
+					return "", err
+				}
+				sb.WriteString(`<w:p><w:pPr><w:ind w:left="720" w:hanging="360"/></w:pPr>` +
+					docxRun(bullet, "") + "<w:r><w:tab/></w:r>" + runs + "</w:p>")
+			}
+		default:
+			rPr := ""
+			if b.Kind == mdHeading {
+				rPr = "<w:b/>"
+			}
+			runs, err := d.inline(b.Items[0], rPr)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString("<w:p>" + pPr + runs + "</w:p>")
+		}
+	}
+	return sb.String(), nil
+}
+
+// docxCodeRPr is the font of code.
+const docxCodeRPr = `<w:rFonts w:ascii="Consolas" w:hAnsi="Consolas" w:cs="Consolas"/>`
+
+// inline converts a line of markdown to runs. rPr is added to every run.
+func (d *docx) inline(s, rPr string) (string, error) {
+	var sb strings.Builder
+	for _, sp := range parseInline(s) {
+		switch {
+		case sp.Image != "":
+			run, ok, err := d.imageRun(sp.Image, sp.Text)
+			if err != nil {
+				return "", err
+			}
+			if !ok {
+				run = docxRun(sp.Image, rPr)
+			}
+			sb.WriteString(run)
+		case sp.Link != "" && isRemote(sp.Link):
+			id := d.addRel(relHyperlink, sp.Link, true)
+			sb.WriteString(`<w:hyperlink r:id="` + id + `">` +
+				docxRun(sp.Text, rPr+`<w:color w:val="0563C1"/><w:u w:val="single"/>`) + "</w:hyperlink>")
+		case sp.Code:
+			sb.WriteString(docxRun(sp.Text, docxCodeRPr+rPr))
+		case sp.Bold:
+			sb.WriteString(docxRun(sp.Text, "<w:b/>"))
+		case sp.Italic:
+			sb.WriteString(docxRun(sp.Text, rPr+"<w:i/>"))
+		default:
+			sb.WriteString(docxRun(sp.Text, rPr))
+		}
+	}
+	return sb.String(), nil
+}
+
+// docxRun returns a run with text. rPr contains the run properties in schema
+// order.
+func docxRun(text, rPr string) string {
+	if rPr != "" {
+		rPr = "<w:rPr>" + rPr + "</w:rPr>"
+	}
+	return "<w:r>" + rPr + docxText(text) + "</w:r>"
+}
+
+// docxText returns the text elements of a run. Line breaks become w:br.
+func docxText(text string) string {
+	var parts []string
+	for _, line := range strings.Split(text, "\n") {
+		parts = append(parts, `<w:t xml:space="preserve">`+xmlEscape(line)+`</w:t>`)
+	}
+	return strings.Join(parts, "<w:br/>")
+}
+
+// xmlEscape escapes text for XML.
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// docxTable returns a table with borders. The first row is bold. An empty
+// paragraph is added after the table because table cells must end with a
+// paragraph.
+func docxTable(rows [][]string) string {
+	var sb strings.Builder
+	sb.WriteString(`<w:tbl><w:tblPr><w:tblW w:w="0" w:type="auto"/><w:tblBorders>`)
+	for _, side := range []string{"top", "left", "bottom", "right", "insideH", "insideV"} {
+		sb.WriteString(`<w:` + side + ` w:val="single" w:sz="4" w:space="0" w:color="auto"/>`)
+	}
+	sb.WriteString(`</w:tblBorders></w:tblPr><w:tblGrid>`)
+	for range rows[0] {
+		sb.WriteString(`<w:gridCol/>`)
+	}
+	sb.WriteString(`</w:tblGrid>`)
+	for i, row := range rows {
+		rPr := ""
+		if i == 0 {
+			rPr = "<w:b/>"
+		}
+		sb.WriteString("<w:tr>")
+		for _, cell := range row {
+			sb.WriteString(`<w:tc><w:tcPr><w:tcW w:w="0" w:type="auto"/></w:tcPr><w:p>` +
+				docxRun(cell, rPr) + "</w:p></w:tc>")
+		}
+		sb.WriteString("</w:tr>")
+	}
+	sb.WriteString("</w:tbl><w:p/>")
+	return sb.String()
+}
+
+// imageRun embeds an image and returns a run with the drawing. ok is false
+// if the file is not a png, jpeg or gif image.
+func (d *docx) imageRun(pth, descr string) (run string, ok bool, err error) {
+	ext := strings.ToLower(path.Ext(pth))
+	typ, ok := docxImageTypes[ext]
+	if !ok || isRemote(pth) {
+		return "", false, nil
+	}
+	img, ok := d.images[pth]
+	if !ok {
+		full, err := d.r.evidencePath(pth)
+		if err != nil {
+			return "", false, err
+		}
+		content, err := shared.ReadFileByte(full)
+		if err != nil {
+			return "", false, fmt.Errorf("read evidence %s - %s", pth, err.Error())
+		}
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(content))
+		if err != nil {
+			return "", false, fmt.Errorf("decode evidence %s - %s", pth, err.Error())
+		}
+		// The skeleton might have its own images, e.g., a previous report.
+		var name string
+		for i := len(d.images) + 1; ; i++ {
+			name = fmt.Sprintf("media/evidence%d%s", i, ext)
+			if _, exists := d.files["word/"+name]; !exists {
+				break
+			}
+		}
+		d.addFile("word/"+name, content)
+		d.addContentType(strings.TrimPrefix(ext, "."), typ)
+		img = docxImage{rel: d.addRel(relImage, name, false), width: cfg.Width, height: cfg.Height}
+		d.images[pth] = img
+	}
+	cx, cy := img.width*docxEMUPerPixel, img.height*docxEMUPerPixel
+	if cx > docxMaxWidth {
+		cx, cy = docxMaxWidth, cy*docxMaxWidth/cx
+	}
+	d.nextID++
+	descr = xmlEscape(descr)
+	return fmt.Sprintf(`<w:r><w:drawing><wp:inline distT="0" distB="0" distL="0" distR="0">`+
+		`<wp:extent cx="%[1]d" cy="%[2]d"/><wp:docPr id="%[3]d" name="Picture %[3]d" descr="%[4]s"/>`+
+		`<a:graphic xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main">`+
+		`<a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture">`+
+		`<pic:pic xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture">`+
+		`<pic:nvPicPr><pic:cNvPr id="%[3]d" name="Picture %[3]d" descr="%[4]s"/><pic:cNvPicPr/></pic:nvPicPr>`+
+		`<pic:blipFill><a:blip r:embed="%[5]s"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill>`+
+		`<pic:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%[1]d" cy="%[2]d"/></a:xfrm>`+
+		`<a:prstGeom prst="rect"><a:avLst/></a:prstGeom></pic:spPr></pic:pic>`+
+		`</a:graphicData></a:graphic></wp:inline></w:drawing></w:r>`,
+		cx, cy, d.nextID, descr, img.rel), true, nil
+}
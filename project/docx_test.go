@@ -0,0 +1,297 @@
+package project
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/parsiya/borrowedtime/config"
+)
+
+// docxPara returns a paragraph with one run per text.
+func docxPara(texts ...string) string {
+	var sb strings.Builder
+	sb.WriteString(`<w:p w:rsidR="00A1"><w:pPr><w:pStyle w:val="Normal"/></w:pPr>`)
+	for i, text := range texts {
+		if i == 1 {
+			sb.WriteString(`<w:proofErr w:type="spellStart"/>`)
+		}
+		sb.WriteString(`<w:r><w:rPr><w:i/></w:rPr><w:t xml:space="preserve">` + text + `</w:t></w:r>`)
+	}
+	sb.WriteString(`</w:p>`)
+	return sb.String()
+}
+
+// writeSkeleton creates a minimal DOCX file.
+func writeSkeleton(t *testing.T, pth string, body ...string) {
+	t.Helper()
+	parts := map[string]string{
+		docxContentTypes: `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+			`<Override PartName="/word/header1.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.header+xml"/>` +
+			`</Types>`,
+		"_rels/.rels": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>` +
+			`</Relationships>`,
+		docxRels: `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId7" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/header" Target="header1.xml"/>` +
+			`</Relationships>`,
+		docxDocument: `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+			strings.Join(body, "") + `<w:sectPr/></w:body></w:document>`,
+		"word/header1.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<w:hdr xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` +
+			docxPara("{{cli", "ent}} - {{title}}") + `</w:hdr>`,
+		// An image that is not referenced by the skeleton, e.g., from a
+		// report that was used as a skeleton.
+		"word/media/evidence1.png": "logo",
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{docxContentTypes, "_rels/.rels", docxDocument, docxRels, "word/header1.xml", "word/media/evidence1.png"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(parts[name]))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(pth, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// docxTestReport returns a report with two findings and a png in @pix.
+func docxTestReport(t *testing.T) *Report {
+	t.Helper()
+	root := t.TempDir()
+	p := &Project{ProjectName: "acme", ProjectRoot: root, Meta: Metadata{Client: "Acme & Co"}}
+	if err := os.MkdirAll(filepath.Join(root, "@pix"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 1280, 720))); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, "@pix", "sqli.png"), img.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []*Finding{
+		{Title: "Verbose errors", Severity: "low", Description: "Stack traces with {{title}}."},
+		{Title: "SQL <injection>", Severity: "critical", Evidence: []string{"@pix/sqli.png", "@clientFiles/dump.sql"},
+			Description: "The **id** parameter in `/api`:\n\n- one\n- two\n\n![query](@pix/sqli.png)\n\nSee [docs](https://example.com/?a=1&b=2).",
+			Remediation: "```\nSELECT 1;\n```",
+			CVSS:        "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"},
+	} {
+		if err := p.AddFinding(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	findings, err := p.Findings(FindingFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newReport(p, map[string]interface{}{"title": "Acme Pentest", "scope": "web"}, findings)
+}
+
+func TestDOCXRoundTrip(t *testing.T) {
+	r := docxTestReport(t)
+	skeleton := filepath.Join(t.TempDir(), "word.docx")
+	writeSkeleton(t, skeleton,
+		docxPara("{{ti", "tle}} for {{client}}"),
+		docxPara("Scope: {{config.scope}}"),
+		`<w:p w:rsidR="00A2"/>`,
+		docxPara("{{summary}}"),
+		docxPara("{{findings-table}}"),
+		docxPara("{{#findings}}"),
+		docxPara("{{finding.id}}: {{finding.", "title}} ({{finding.severity}} {{finding.score}})"),
+		docxPara("{{finding.description}}"),
+		docxPara("{{finding.evidence}}"),
+		docxPara("{{finding.remediation}}"),
+		docxPara("{{/findings}}"),
+		docxPara("End"),
+	)
+	d, err := r.renderDOCX(config.Template{Name: "word", FullPath: skeleton, Kind: config.ReportKind})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := d.bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Open the generated file again and check every part.
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[f.Name] = string(b)
+		if strings.HasSuffix(f.Name, ".xml") || strings.HasSuffix(f.Name, ".rels") {
+			if err := validateXML(b); err != nil {
+				t.Errorf("%s is not valid XML: %v", f.Name, err)
+			}
+		}
+	}
+	if zr.File[0].Name != docxContentTypes {
+		t.Errorf("first part is %s, want %s", zr.File[0].Name, docxContentTypes)
+	}
+	if cfg, err := png.DecodeConfig(strings.NewReader(parts["word/media/evidence2.png"])); err != nil || cfg.Width != 1280 {
+		t.Errorf("embedded image = %+v, %v", cfg, err)
+	}
+	if parts["word/media/evidence1.png"] != "logo" {
+		t.Errorf("existing image was replaced with %d bytes", len(parts["word/media/evidence1.png"]))
+	}
+
+	doc := parts[docxDocument]
+	text := paragraphText(doc)
+	for _, want := range []string{
+		"Acme Pentest for Acme & Co",
+		"Scope: web",
+		"Critical11", "Low11",
+		"F-002CriticalCVSS9.8openSQL <injection>",
+		"F-002: SQL <injection> (Critical 9.8)",
+		"F-001: Verbose errors (Low )",
+		"Stack traces with {{title}}.",
+		"@clientFiles/dump.sql",
+		"SELECT 1;",
+		"End",
+	} {
+		want = strings.Replace(want, "CVSS", "", 1)
+		if !strings.Contains(text, want) {
+			t.Errorf("document text does not contain %q:\n%s", want, text)
+		}
+	}
+	for _, want := range []string{
+		`xmlns:r="` + nsR + `"`,
+		`<w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve">id</w:t></w:r>`,
+		`<w:rFonts w:ascii="Consolas" w:hAnsi="Consolas" w:cs="Consolas"/></w:rPr><w:t xml:space="preserve">/api</w:t>`,
+		`<w:t xml:space="preserve">•</w:t></w:r><w:r><w:tab/></w:r><w:r><w:t xml:space="preserve">two</w:t>`,
+		`<wp:extent cx="5486400" cy="3086100"/>`,
+		`<w:hyperlink r:id="rId9">`,
+		// The empty paragraph before the summary is kept.
+		`<w:p w:rsidR="00A2"/>`,
+		// The properties of the first run are kept.
+		`<w:r><w:rPr><w:i/></w:rPr><w:t xml:space="preserve">Acme Pentest for Acme &amp; Co</w:t></w:r>`,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("document does not contain %s", want)
+		}
+	}
+	if strings.Contains(strings.Replace(text, "{{title}}", "", 1), "{{") {
+		t.Errorf("document has placeholders:\n%s", text)
+	}
+	// The image is embedded once and used twice.
+	if n := strings.Count(doc, `r:embed="rId8"`); n != 2 {
+		t.Errorf("image is used %d times, want 2", n)
+	}
+	ids := make(map[string]bool)
+	for _, m := range regexp.MustCompile(`<wp:docPr id="(\d+)"`).FindAllStringSubmatch(doc, -1) {
+		if ids[m[1]] {
+			t.Errorf("duplicate drawing id %s", m[1])
+		}
+		ids[m[1]] = true
+	}
+
+	for _, want := range []string{
+		`<Relationship Id="rId8" Type="` + relImage + `" Target="media/evidence2.png"/>`,
+		`<Relationship Id="rId9" Type="` + relHyperlink + `" Target="https://example.com/?a=1&amp;b=2" TargetMode="External"/>`,
+	} {
+		if !strings.Contains(parts[docxRels], want) {
+			t.Errorf("relationships do not contain %s", want)
+		}
+	}
+	if !strings.Contains(parts[docxContentTypes], `<Default Extension="png" ContentType="image/png"/>`) {
+		t.Error("content types do not contain png")
+	}
+	if got := paragraphText(parts["word/header1.xml"]); got != "Acme & Co - Acme Pentest" {
+		t.Errorf("header = %q", got)
+	}
+}
+
+func TestDOCXErrors(t *testing.T) {
+	r := docxTestReport(t)
+	tests := map[string][]string{
+		"not-closed":       {docxPara("{{#findings}}"), docxPara("{{finding.title}}")},
+		"not-opened":       {docxPara("{{/findings}}")},
+		"unknown":          {docxPara("{{titel}}")},
+		"unknown-finding":  {docxPara("{{#findings}}"), docxPara("{{finding.name}}"), docxPara("{{/findings}}")},
+		"outside-section":  {docxPara("{{finding.title}}")},
+		"block-outside":    {docxPara("{{finding.description}}")},
+		"block-not-alone":  {docxPara("Summary: {{summary}}")},
+		"missing-evidence": {docxPara("{{#findings}}"), docxPara("{{finding.evidence}}"), docxPara("{{/findings}}")},
+	}
+	r.Findings[1].Evidence = []string{"@pix/missing.png"}
+	for name, body := range tests {
+		skeleton := filepath.Join(t.TempDir(), name+".docx")
+		writeSkeleton(t, skeleton, body...)
+		if _, err := r.renderDOCX(config.Template{Name: name, FullPath: skeleton}); err == nil {
+			t.Errorf("%s: renderDOCX() did not return an error", name)
+		}
+	}
+
+	notDOCX := filepath.Join(t.TempDir(), "notes.docx")
+	if err := ioutil.WriteFile(notDOCX, []byte("notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.renderDOCX(config.Template{Name: "notes", FullPath: notDOCX}); err == nil {
+		t.Error("renderDOCX() did not return an error for a file that is not a zip")
+	}
+}
+
+func TestFillText(t *testing.T) {
+	d := &docx{r: &Report{Title: "T", Client: "C&C", Config: map[string]interface{}{}}}
+	tests := []struct {
+		name     string
+		runs     []string
+		want     string
+		wantRuns int
+	}{
+		{"one-run", []string{"{{title}}"}, "T", 1},
+		{"split", []string{"a {{ti", "tle}} b"}, "a T b", 2},
+		{"three-runs", []string{"{{", "client", "}}!"}, "C&C!", 3},
+		{"two-in-run", []string{"{{title}}{{client}}"}, "TC&C", 1},
+		{"shared-run", []string{"{{ti", "tle}}-{{cli", "ent}}"}, "T-C&C", 3},
+		{"no-placeholder", []string{"{{", "text"}, "{{text", 2},
+	}
+	for _, tt := range tests {
+		p := docxPara(tt.runs...)
+		got, err := d.fillText(p, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if text := paragraphText(got); text != tt.want {
+			t.Errorf("%s: fillText() text = %q, want %q", tt.name, text, tt.want)
+		}
+		if n := strings.Count(got, "<w:r>"); n != tt.wantRuns {
+			t.Errorf("%s: fillText() has %d runs, want %d", tt.name, n, tt.wantRuns)
+		}
+		if len(tt.runs) > 1 && !strings.Contains(got, "<w:proofErr") {
+			t.Errorf("%s: fillText() removed the elements between runs", tt.name)
+		}
+	}
+}
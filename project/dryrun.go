@@ -0,0 +1,192 @@
+package project
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/parsiya/borrowedtime/shared"
+)
+
+// Dry-run statuses. They show what Create would do with each path.
+const (
+	statusNew       = "new"
+	statusExists    = "exists"
+	statusOverwrite = "overwrite"
+	statusSkip      = "skip"
+)
+
+// DryRun renders a project template and returns the resulting directory tree
+// without touching the filesystem. Each path is marked as new, exists,
+// overwrite or skip (when condition is false). If content is set, the rendered
+// content of each file is included.
+func (p *Project) DryRun(templateName string, overwrite, content bool) (string, error) {
+	if p.ProjectName == "" || p.Workspace == "" {
+		return "", fmt.Errorf("project.Project.DryRun: empty project")
+	}
+	if err := p.loadData(); err != nil {
+		return "", fmt.Errorf("project.Project.DryRun: %s", err.Error())
+	}
+	// Generate template.
+	tmpl, err := p.generateTemplate(templateName, true)
+	if err != nil {
+		return "", fmt.Errorf("project.Project.DryRun: %s", err.Error())
+	}
+	out, err := previewProjectTemplate(*p, tmpl, overwrite, content)
+	if err != nil {
+		return "", fmt.Errorf("project.Project.DryRun: %s", err.Error())
+	}
+	return out, nil
+}
+
+// previewProjectTemplate returns the directory structure that
+// execProjectTemplate would create from a generated template.
+func previewProjectTemplate(p Project, tmpl string, overwrite, content bool) (string, error) {
+	root, err := parseProjectTemplate(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("project.previewProjectTemplate: %s", err.Error())
+	}
+	var sb strings.Builder
+	conflicts, err := root.preview(p, overwrite, content, root.FullPath, 0, &sb)
+	if err != nil {
+		return "", fmt.Errorf("project.previewProjectTemplate: %s", err.Error())
+	}
+	// Hooks are not run in a dry-run.
+	for _, hook := range hookNames {
+		for _, c := range p.hookCommands(hook, root) {
+			fmt.Fprintf(&sb, "%-12s %s\n", "["+hook+"]", c)
+		}
+	}
+	// Create fails on existing paths if overwrite is not set.
+	if conflicts > 0 {
+		fmt.Fprintf(&sb, "\n%d path(s) already exist, use -overwrite to create the project.\n", conflicts)
+	}
+	return sb.String(), nil
+}
+
+// preview writes the node and its children to w in the same order Create
+// would create them. name is the path displayed for the node. Returns the
+// number of existing paths that would make Create fail.
+func (n *Node) preview(p Project, overwrite, content bool, name string, depth int, w io.Writer) (int, error) {
+	ok, err := evalCondition(p, n.When)
+	if err != nil {
+		return 0, fmt.Errorf("project.Node.preview: %s", err.Error())
+	}
+	status, conflicts := statusSkip, 0
+	if ok {
+		status, conflicts, err = n.status(overwrite)
+		if err != nil {
+			return 0, fmt.Errorf("project.Node.preview: %s", err.Error())
+		}
+	}
+
+	indent := strings.Repeat("    ", depth)
+	line := indent + filepath.ToSlash(name)
+	if n.Info.IsDir {
+		line += "/"
+	}
+	if n.Info.Source != "" {
+		line += fmt.Sprintf("  (source: %s", n.Info.Source)
+		if n.Info.Render {
+			line += ", rendered"
+		}
+		line += ")"
+	} else if !n.Info.IsDir && n.Info.Template != "" {
+		line += fmt.Sprintf("  (template: %s)", n.Info.Template)
+	}
+	if n.Info.Symlink != "" {
+		line += fmt.Sprintf("  (symlink -> %s)", n.Info.Symlink)
+	}
+	if n.Info.Mode != "" {
+		line += fmt.Sprintf("  (mode: %s)", n.Info.Mode)
+	}
+	if n.When != "" {
+		line += fmt.Sprintf("  (when: %s = %t)", n.When, ok)
+	}
+	if err := n.Info.validate(); err != nil {
+		line += fmt.Sprintf("  (error: %s)", err.Error())
+	}
+	fmt.Fprintf(w, "%-12s %s\n", "["+status+"]", line)
+	// Children of skipped nodes are not created.
+	if !ok {
+		return 0, nil
+	}
+
+	// Print the rendered file content under the file.
+	if content && !n.Info.IsDir && n.Info.Symlink == "" && (n.Info.Template != "" || n.Info.Source != "") {
+		for _, l := range strings.Split(strings.TrimRight(n.previewContent(p), "\n"), "\n") {
+			fmt.Fprintf(w, "%-12s %s    | %s\n", "", indent, l)
+		}
+	}
+
+	for _, child := range n.Children {
+		insts, err := n.instances(child, p)
+		if err != nil {
+			return 0, fmt.Errorf("project.Node.preview: %s", err.Error())
+		}
+		if child.ForEach != "" {
+			fmt.Fprintf(w, "%-12s %s    %s  (foreach: %s = %d item(s))\n", "", indent,
+				filepath.ToSlash(child.FullPath), child.ForEach, len(insts))
+		}
+		for _, inst := range insts {
+			// Keep the relative path for display.
+			childName, _ := filepath.Rel(n.FullPath, inst.node.FullPath)
+			c, err := inst.node.preview(inst.p, overwrite, content, childName, depth+1, w)
+			if err != nil {
+				return 0, err
+			}
+			conflicts += c
+		}
+	}
+	return conflicts, nil
+}
+
+// previewContent returns the content of a file node for the dry-run. Errors
+// are returned as content because the file would be created regardless.
+func (n *Node) previewContent(p Project) string {
+	if n.Info.Source == "" {
+		// Same as Create, a missing template results in an empty file.
+		tmplString, err := p.generateTemplate(n.Info.Template, false)
+		if err != nil {
+			return fmt.Sprintf("error: %s", err.Error())
+		}
+		return tmplString
+	}
+	src, err := n.sourcePath(p)
+	if err != nil {
+		return fmt.Sprintf("error: %s", err.Error())
+	}
+	content, err := shared.ReadFileByte(src)
+	if err != nil {
+		return fmt.Sprintf("error: %s", err.Error())
+	}
+	if !n.Info.Render || !isText(content) {
+		return fmt.Sprintf("(%d bytes copied verbatim from %s)", len(content), filepath.ToSlash(src))
+	}
+	rendered, err := renderSource(p, filepath.Base(src), string(content))
+	if err != nil {
+		return fmt.Sprintf("error: %s", err.Error())
+	}
+	return rendered
+}
+
+// status returns what Create would do with the node's path. The returned int
+// is 1 if the path exists and Create would fail because overwrite is not set.
+func (n *Node) status(overwrite bool) (string, int, error) {
+	exists, err := n.exists()
+	if err != nil {
+		return "", 0, err
+	}
+	switch {
+	case !exists:
+		return statusNew, 0, nil
+	case !overwrite:
+		return statusExists, 1, nil
+	case n.Info.IsDir:
+		// Existing directories are reused.
+		return statusExists, 0, nil
+	default:
+		return statusOverwrite, 0, nil
+	}
+}
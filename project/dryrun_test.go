@@ -0,0 +1,61 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	home := testHome(t)
+	p := New("acme")
+	out, err := p.DryRun("project-structure", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(home, "ws", "acme")); !os.IsNotExist(err) {
+		t.Errorf("DryRun() created the project directory - %v", err)
+	}
+	for _, want := range []string{"[" + statusNew + "]", "@notes.md", "@pix/"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("DryRun() output does not contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "["+statusExists+"]") {
+		t.Errorf("DryRun() reported existing paths for a new project:\n%s", out)
+	}
+
+	// Existing paths are conflicts unless overwrite is set.
+	if err := p.Create("project-structure", false); err != nil {
+		t.Fatal(err)
+	}
+	notes := filepath.Join(home, "ws", "acme", "@notes.md")
+	if err := os.WriteFile(notes, []byte("edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out, err = p.DryRun("project-structure", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "["+statusExists+"]") || !strings.Contains(out, "use -overwrite") {
+		t.Errorf("DryRun() did not report the existing paths:\n%s", out)
+	}
+	out, err = p.DryRun("project-structure", true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "["+statusOverwrite+"]") || strings.Contains(out, "use -overwrite") {
+		t.Errorf("DryRun() with overwrite did not report overwritten paths:\n%s", out)
+	}
+	if !strings.Contains(out, "    | ") {
+		t.Errorf("DryRun() with content did not print the content:\n%s", out)
+	}
+	content, err := os.ReadFile(notes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "edited" {
+		t.Errorf("DryRun() modified %s", notes)
+	}
+}
@@ -0,0 +1,440 @@
+package project
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/parsiya/borrowedtime/shared"
+)
+
+const (
+	// evidenceManifestFilename is the append-only evidence log in the project
+	// root. Each line is one JSON encoded EvidenceRecord.
+	evidenceManifestFilename = "@evidence.jsonl"
+	// evidenceTimeFormat starts the names of evidence files.
+	evidenceTimeFormat = "20060102-150405"
+	// Default evidence directories for images and other files.
+	pixDir         = "@pix"
+	clientFilesDir = "@clientFiles"
+)
+
+// Evidence actions in the manifest.
+const (
+	EvidenceAdded    = "added"
+	EvidenceAttached = "attached"
+)
+
+// Evidence statuses returned by VerifyEvidence.
+const (
+	EvidenceOK        = "ok"
+	EvidenceChanged   = "changed"
+	EvidenceMissing   = "missing"
+	EvidenceUntracked = "untracked"
+	// EvidenceTampered is a manifest record that does not chain to the record
+	// before it. The manifest was edited.
+	EvidenceTampered = "tampered"
+)
+
+// EvidenceRecord is one entry in the evidence manifest. Records are never
+// changed or removed.
+type EvidenceRecord struct {
+	Action string `json:"action"`
+	// Path is relative to the project root in slash form.
+	Path string `json:"path"`
+	// SHA256 is the hash of the file when the record was written.
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+	// Source is the absolute path of the original file.
+	Source string `json:"source,omitempty"`
+	// Captured is the time of the record in RFC 3339.
+	Captured   string `json:"captured"`
+	CapturedBy string `json:"capturedby"`
+	// Finding is the ID of the finding the evidence is attached to.
+	Finding string `json:"finding,omitempty"`
+	Note    string `json:"note,omitempty"`
+	// Prev is the SHA-256 hash of the previous line in the manifest. It's
+	// empty for the first record. Editing or removing a record breaks the
+	// chain.
+	Prev string `json:"prev,omitempty"`
+}
+
+// EvidenceOptions changes how evidence is added.
+type EvidenceOptions struct {
+	// Dir is the evidence directory. It defaults to @pix for images and
+	// @clientFiles for other files.
+	Dir string
+	// Finding is the ID of a finding to attach the evidence to.
+	Finding string
+	// CapturedBy defaults to "yourname" in the config or the current user.
+	CapturedBy string
+	Note       string
+}
+
+// EvidenceStatus is the result of verifying one evidence file or manifest
+// record.
+type EvidenceStatus struct {
+	Path   string
+	Status string
+	// Want is the hash in the manifest and Got is the current hash. For
+	// tampered records, they are the expected and recorded Prev.
+	Want string
+	Got  string
+}
+
+// evidenceManifest returns the path to the evidence manifest.
+func (p Project) evidenceManifest() string {
+	return filepath.Join(p.Root(), evidenceManifestFilename)
+}
+
+// AddEvidence copies a file to an evidence directory with a timestamped name
+// and appends its hash to the manifest. If a finding is passed, the evidence
+// is added to the finding.
+func (p *Project) AddEvidence(src string, opts EvidenceOptions) (*EvidenceRecord, error) {
+	src, err := filepath.Abs(src)
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.AddEvidence: %s", err.Error())
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.AddEvidence: %s", err.Error())
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("project.Project.AddEvidence: %s is not a file", src)
+	}
+	dirs := evidenceDirs(p.Config[keyEvidenceDirs])
+	dir := opts.Dir
+	if dir == "" {
+		dir = defaultEvidenceDir(src, dirs)
+	}
+	if !matchAny(dirs, dir) {
+		return nil, fmt.Errorf("project.Project.AddEvidence: %s is not an evidence directory, use one of %s", dir, strings.Join(dirs, ", "))
+	}
+	// Check the finding before the file is copied.
+	var f *Finding
+	if opts.Finding != "" {
+		if f, err = p.Finding(opts.Finding); err != nil {
+			return nil, fmt.Errorf("project.Project.AddEvidence: %s", err.Error())
+		}
+	}
+	hash, err := hashFile(src)
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.AddEvidence: %s", err.Error())
+	}
+
+	now := time.Now()
+	dstDir := filepath.Join(p.Root(), filepath.FromSlash(dir))
+	if err := os.MkdirAll(dstDir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("project.Project.AddEvidence: %s", err.Error())
+	}
+	dst, err := uniqueEvidencePath(dstDir, evidenceFilename(now, filepath.Base(src)))
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.AddEvidence: %s", err.Error())
+	}
+	if err := shared.CopyFile(src, dst); err != nil {
+		return nil, fmt.Errorf("project.Project.AddEvidence: %s", err.Error())
+	}
+	// The copy must be identical to the source.
+	if got, err := hashFile(dst); err != nil || got != hash {
+		os.Remove(dst)
+		return nil, fmt.Errorf("project.Project.AddEvidence: hash of %s does not match %s", dst, src)
+	}
+
+	r := &EvidenceRecord{
+		Action:     EvidenceAdded,
+		Path:       relPath(p.Root(), dst),
+		SHA256:     hash,
+		Size:       info.Size(),
+		Source:     src,
+		Captured:   now.Format(time.RFC3339),
+		CapturedBy: p.evidenceUser(opts.CapturedBy),
+		Note:       opts.Note,
+	}
+	if f != nil {
+		r.Finding = f.ID
+	}
+	if err := p.appendEvidence(r); err != nil {
+		os.Remove(dst)
+		return nil, fmt.Errorf("project.Project.AddEvidence: %s", err.Error())
+	}
+	if f != nil {
+		if err := p.attachToFinding(f, r.Path); err != nil {
+			return r, fmt.Errorf("project.Project.AddEvidence: %s", err.Error())
+		}
+	}
+	return r, nil
+}
+
+// AttachEvidence adds evidence in the manifest to a finding and appends a
+// record with its current hash. Evidence that changed since it was added is
+// not attached.
+func (p *Project) AttachEvidence(pth, findingID string, opts EvidenceOptions) (*EvidenceRecord, error) {
+	records, err := p.EvidenceLog()
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.AttachEvidence: %s", err.Error())
+	}
+	pth = path.Clean(filepath.ToSlash(pth))
+	// The first record of a path has the original hash.
+	original := ""
+	for _, r := range records {
+		if r.Path == pth {
+			original = r.SHA256
+			break
+		}
+	}
+	if original == "" {
+		return nil, fmt.Errorf("project.Project.AttachEvidence: %s is not in the evidence manifest, use add", pth)
+	}
+	f, err := p.Finding(findingID)
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.AttachEvidence: %s", err.Error())
+	}
+	full := filepath.Join(p.Root(), filepath.FromSlash(pth))
+	info, err := os.Stat(full)
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.AttachEvidence: %s", err.Error())
+	}
+	hash, err := hashFile(full)
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.AttachEvidence: %s", err.Error())
+	}
+	if hash != original {
+		return nil, fmt.Errorf("project.Project.AttachEvidence: %s changed since it was added, got %s want %s", pth, hash, original)
+	}
+	r := &EvidenceRecord{
+		Action:     EvidenceAttached,
+		Path:       pth,
+		SHA256:     hash,
+		Size:       info.Size(),
+		Captured:   time.Now().Format(time.RFC3339),
+		CapturedBy: p.evidenceUser(opts.CapturedBy),
+		Finding:    f.ID,
+		Note:       opts.Note,
+	}
+	if err := p.appendEvidence(r); err != nil {
+		return nil, fmt.Errorf("project.Project.AttachEvidence: %s", err.Error())
+	}
+	if err := p.attachToFinding(f, pth); err != nil {
+		return r, fmt.Errorf("project.Project.AttachEvidence: %s", err.Error())
+	}
+	return r, nil
+}
+
+// attachToFinding adds the path to the evidence of the finding.
+func (p *Project) attachToFinding(f *Finding, pth string) error {
+	for _, e := range f.Evidence {
+		if e == pth {
+			return nil
+		}
+	}
+	f.Evidence = append(f.Evidence, pth)
+	return p.SaveFinding(f)
+}
+
+// EvidenceLog returns the records in the manifest in the order they were
+// written. A missing manifest returns no records.
+func (p Project) EvidenceLog() ([]EvidenceRecord, error) {
+	records, _, err := p.readEvidence()
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.EvidenceLog: %s", err.Error())
+	}
+	return records, nil
+}
+
+// readEvidence returns the records in the manifest and their lines.
+func (p Project) readEvidence() ([]EvidenceRecord, []string, error) {
+	in, err := os.Open(p.evidenceManifest())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	defer in.Close()
+	var records []EvidenceRecord
+	var lines []string
+	sc := bufio.NewScanner(in)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		var r EvidenceRecord
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			return nil, nil, fmt.Errorf("%s line %d - %s", evidenceManifestFilename, n, err.Error())
+		}
+		records = append(records, r)
+		lines = append(lines, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, nil, err
+	}
+	return records, lines, nil
+}
+
+// verifyChain returns the records whose Prev is not the hash of the line
+// before them.
+func verifyChain(records []EvidenceRecord, lines []string) []EvidenceStatus {
+	var results []EvidenceStatus
+	prev := ""
+	for i, r := range records {
+		if r.Prev != prev {
+			results = append(results, EvidenceStatus{
+				Path:   fmt.Sprintf("%s record %d", evidenceManifestFilename, i+1),
+				Status: EvidenceTampered,
+				Want:   prev,
+				Got:    r.Prev,
+			})
+		}
+		prev = hashBytes([]byte(lines[i]))
+	}
+	return results
+}
+
+// appendEvidence chains a record to the last record and appends it to the
+// manifest. Existing records are never rewritten.
+func (p Project) appendEvidence(r *EvidenceRecord) error {
+	_, lines, err := p.readEvidence()
+	if err != nil {
+		return err
+	}
+	r.Prev = ""
+	if len(lines) > 0 {
+		r.Prev = hashBytes([]byte(lines[len(lines)-1]))
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(p.evidenceManifest(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := out.Write(append(b, '\n')); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// VerifyEvidence checks the chain of the manifest, hashes every file in the
+// manifest and compares it with the hash when it was added. Files in the
+// evidence directories that are not in the manifest are untracked. Tampered
+// records are returned first, files are sorted by path.
+func (p Project) VerifyEvidence() ([]EvidenceStatus, error) {
+	records, lines, err := p.readEvidence()
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.VerifyEvidence: %s", err.Error())
+	}
+	// The first record of a path has the original hash.
+	want := make(map[string]string)
+	for _, r := range records {
+		if _, ok := want[r.Path]; !ok {
+			want[r.Path] = r.SHA256
+		}
+	}
+	var results []EvidenceStatus
+	for _, pth := range shared.SortedKeys(want) {
+		s := EvidenceStatus{Path: pth, Status: EvidenceOK, Want: want[pth]}
+		got, err := hashFile(filepath.Join(p.Root(), filepath.FromSlash(pth)))
+		switch {
+		case os.IsNotExist(err):
+			s.Status = EvidenceMissing
+		case err != nil:
+			return nil, fmt.Errorf("project.Project.VerifyEvidence: %s", err.Error())
+		case got != s.Want:
+			s.Status, s.Got = EvidenceChanged, got
+		default:
+			s.Got = got
+		}
+		results = append(results, s)
+	}
+
+	for _, dir := range evidenceDirs(p.Config[keyEvidenceDirs]) {
+		root := filepath.Join(p.Root(), filepath.FromSlash(dir))
+		err := filepath.Walk(root, func(pth string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) && pth == root {
+					return filepath.SkipDir
+				}
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			rel := relPath(p.Root(), pth)
+			if _, ok := want[rel]; !ok {
+				results = append(results, EvidenceStatus{Path: rel, Status: EvidenceUntracked})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("project.Project.VerifyEvidence: %s", err.Error())
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Path < results[j].Path })
+	return append(verifyChain(records, lines), results...), nil
+}
+
+// evidenceUser returns by, "yourname" in the config or the current user.
+func (p Project) evidenceUser(by string) string {
+	if by = strings.TrimSpace(by); by != "" {
+		return by
+	}
+	if name := strings.TrimSpace(p.Config["yourname"]); name != "" {
+		return name
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
+
+// defaultEvidenceDir returns @pix for images and @clientFiles for other files
+// or the first evidence directory if they are not evidence directories.
+func defaultEvidenceDir(pth string, dirs []string) string {
+	dir := clientFilesDir
+	if isImage(pth) {
+		dir = pixDir
+	}
+	if matchAny(dirs, dir) || len(dirs) == 0 {
+		return dir
+	}
+	return dirs[0]
+}
+
+// evidenceFilename returns "YYYYMMDD-HHMMSS-slug.ext" where slug is the name
+// in lowercase with other characters replaced with "-".
+func evidenceFilename(t time.Time, name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	slug := strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name))), "-"), "-")
+	if len(slug) > 40 {
+		slug = strings.TrimRight(slug[:40], "-")
+	}
+	if slug == "" {
+		slug = "evidence"
+	}
+	return t.Format(evidenceTimeFormat) + "-" + slug + ext
+}
+
+// uniqueEvidencePath returns the path to name in dir. A number is added if
+// the file exists.
+func uniqueEvidencePath(dir, name string) (string, error) {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		pth := filepath.Join(dir, name)
+		exists, err := shared.PathExists(pth)
+		if err != nil || !exists {
+			return pth, err
+		}
+		name = fmt.Sprintf("%s-%d%s", base, i+1, ext)
+	}
+}
@@ -0,0 +1,186 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvidenceFilename(t *testing.T) {
+	ts := time.Date(2026, 10, 19, 15, 4, 5, 0, time.UTC)
+	tests := map[string]string{
+		"Screenshot 2026-10-19.PNG": "20261019-150405-screenshot-2026-10-19.png",
+		"burp_export.xml":           "20261019-150405-burp_export.xml",
+		"..png":                     "20261019-150405-evidence.png",
+		"noext":                     "20261019-150405-noext",
+	}
+	for in, want := range tests {
+		if got := evidenceFilename(ts, in); got != want {
+			t.Errorf("evidenceFilename(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestDefaultEvidenceDir(t *testing.T) {
+	tests := []struct {
+		pth  string
+		dirs []string
+		want string
+	}{
+		{"shot.png", defaultEvidenceDirs, "@pix"},
+		{"dump.pcap", defaultEvidenceDirs, "@clientFiles"},
+		{"shot.png", []string{"@loot"}, "@loot"},
+	}
+	for _, tt := range tests {
+		if got := defaultEvidenceDir(tt.pth, tt.dirs); got != tt.want {
+			t.Errorf("defaultEvidenceDir(%q, %q) = %q, want %q", tt.pth, tt.dirs, got, tt.want)
+		}
+	}
+}
+
+func TestEvidence(t *testing.T) {
+	p := &Project{ProjectName: "acme", ProjectRoot: t.TempDir()}
+	if err := p.AddFinding(&Finding{Title: "XSS", Severity: "high"}); err != nil {
+		t.Fatal(err)
+	}
+	src := t.TempDir()
+	write := func(name, content string) string {
+		pth := filepath.Join(src, name)
+		if err := os.WriteFile(pth, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return pth
+	}
+
+	shot, err := p.AddEvidence(write("XSS Alert.png", "png"), EvidenceOptions{Finding: "1", CapturedBy: "tester"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(shot.Path, "@pix/") || !strings.HasSuffix(shot.Path, "-xss-alert.png") ||
+		shot.CapturedBy != "tester" || shot.Finding != "F-001" || shot.Size != 3 {
+		t.Errorf("AddEvidence() = %+v", shot)
+	}
+	dump, err := p.AddEvidence(write("dump.txt", "dump"), EvidenceOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.AddEvidence(write("other.txt", "x"), EvidenceOptions{Dir: "@notes"}); err == nil {
+		t.Error("AddEvidence() did not return an error for a directory that is not an evidence directory")
+	}
+	if _, err := p.AttachEvidence(dump.Path, "F-001", EvidenceOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.AttachEvidence("@pix/unknown.png", "F-001", EvidenceOptions{}); err == nil {
+		t.Error("AttachEvidence() did not return an error for untracked evidence")
+	}
+	if err := os.WriteFile(filepath.Join(p.Root(), filepath.FromSlash(shot.Path)), []byte("edited"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.AttachEvidence(shot.Path, "F-001", EvidenceOptions{}); err == nil {
+		t.Error("AttachEvidence() did not return an error for changed evidence")
+	}
+
+	f, err := p.Finding("F-001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(f.Evidence, ",") != shot.Path+","+dump.Path {
+		t.Errorf("finding evidence = %q, want %q", f.Evidence, []string{shot.Path, dump.Path})
+	}
+	log, err := p.EvidenceLog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log) != 3 || log[2].Action != EvidenceAttached || log[2].SHA256 != dump.SHA256 {
+		t.Errorf("EvidenceLog() = %+v", log)
+	}
+
+	// Change, remove and add files behind the manifest's back.
+	root := p.Root()
+	if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(dump.Path)), []byte("edited"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(root, filepath.FromSlash(shot.Path))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "@pix", "extra.png"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	results, err := p.VerifyEvidence()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, r := range results {
+		got[r.Path] = r.Status
+	}
+	want := map[string]string{
+		dump.Path:        EvidenceChanged,
+		shot.Path:        EvidenceMissing,
+		"@pix/extra.png": EvidenceUntracked,
+	}
+	if len(got) != len(want) {
+		t.Errorf("VerifyEvidence() = %v, want %v", got, want)
+	}
+	for pth, status := range want {
+		if got[pth] != status {
+			t.Errorf("VerifyEvidence() %s = %q, want %q", pth, got[pth], status)
+		}
+	}
+}
+
+func TestEvidenceChain(t *testing.T) {
+	p := &Project{ProjectName: "acme", ProjectRoot: t.TempDir()}
+	src := filepath.Join(t.TempDir(), "dump.txt")
+	if err := os.WriteFile(src, []byte("dump"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := p.AddEvidence(src, EvidenceOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	log, err := p.EvidenceLog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log[0].Prev != "" || log[1].Prev == "" || log[2].Prev == log[1].Prev {
+		t.Fatalf("EvidenceLog() = %+v", log)
+	}
+	results, err := p.VerifyEvidence()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if r.Status != EvidenceOK {
+			t.Errorf("VerifyEvidence() %s = %q, want %q", r.Path, r.Status, EvidenceOK)
+		}
+	}
+
+	// Remove the first record and edit the path of the second one.
+	b, err := os.ReadFile(p.evidenceManifest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	lines = []string{strings.Replace(lines[1], log[1].Path, log[0].Path, 1), lines[2]}
+	if err := os.WriteFile(p.evidenceManifest(), []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	results, err = p.VerifyEvidence()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{evidenceManifestFilename + " record 1", evidenceManifestFilename + " record 2"}
+	var got []string
+	for _, r := range results {
+		if r.Status == EvidenceTampered {
+			got = append(got, r.Path)
+		}
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("VerifyEvidence() tampered = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,624 @@
+package project
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parsiya/borrowedtime/cvss"
+	"github.com/parsiya/borrowedtime/shared"
+)
+
+const (
+	// findingsDirName is the directory in the project root that contains one
+	// markdown file per finding.
+	findingsDirName = "@findings"
+	// findingIDFormat is the format of finding IDs.
+	findingIDFormat = "F-%03d"
+	// frontMatterDelim starts and ends the front matter of a finding file.
+	frontMatterDelim = "---"
+	// Sections in the body of a finding file.
+	descriptionHeading = "## Description"
+	remediationHeading = "## Remediation"
+)
+
+// Severities are the valid finding severities from highest to lowest.
+var Severities = []string{"critical", "high", "medium", "low", "info"}
+
+// FindingStatuses are the valid finding statuses.
+var FindingStatuses = []string{"open", "fixed", "accepted", "retest"}
+
+// Finding is one finding in a project. It's stored in "@findings/ID-slug.md"
+// as front matter followed by the description and remediation sections.
+type Finding struct {
+	ID       string
+	Title    string
+	Severity string
+	Status   string
+	// Assets are the affected hosts, URLs or components.
+	Assets []string
+	// Evidence contains paths to evidence files relative to the project root.
+	Evidence []string
+	// CVSS is the CVSS v3.1 or v4.0 vector string.
+	CVSS string
+	// References are links to advisories and write-ups.
+	References []string
+	// Created and Updated are dates as YYYY-MM-DD.
+	Created string
+	Updated string
+	// Original is "project/ID" of the finding this one was cloned from for a
+	// retest.
+	Original    string
+	Description string
+	Remediation string
+	// Notes contains the rest of the body after the known sections.
+	Notes string
+	// Extra contains unknown front matter keys. They are kept when the finding
+	// is saved.
+	Extra map[string]string
+	// path is the finding file.
+	path string
+}
+
+// Validate checks the title, severity, status and CVSS vector.
+func (f Finding) Validate() error {
+	if f.CVSS != "" {
+		if _, err := cvss.Parse(f.CVSS); err != nil {
+			return err
+		}
+	}
+	switch {
+	case strings.TrimSpace(f.Title) == "":
+		return fmt.Errorf("empty finding title")
+	case !validSeverity(f.Severity):
+		return fmt.Errorf("invalid severity %q, use one of %s", f.Severity, strings.Join(Severities, ", "))
+	case !validFindingStatus(f.Status):
+		return fmt.Errorf("invalid status %q, use one of %s", f.Status, strings.Join(FindingStatuses, ", "))
+	}
+	return nil
+}
+
+// validSeverity returns true if s is a valid severity.
+func validSeverity(s string) bool {
+	return severityRank(s) < len(Severities)
+}
+
+// severityRank returns the index of s in Severities or len(Severities) if it's
+// not valid.
+func severityRank(s string) int {
+	for i, sev := range Severities {
+		if sev == s {
+			return i
+		}
+	}
+	return len(Severities)
+}
+
+// validFindingStatus returns true if s is a valid finding status.
+func validFindingStatus(s string) bool {
+	for _, st := range FindingStatuses {
+		if st == s {
+			return true
+		}
+	}
+	return false
+}
+
+// FindingFilter selects findings. Empty fields match every finding.
+type FindingFilter struct {
+	Severities []string
+	Statuses   []string
+}
+
+// match returns true if the finding passes the filter.
+func (ff FindingFilter) match(f *Finding) bool {
+	return matchAny(ff.Severities, f.Severity) && matchAny(ff.Statuses, f.Status)
+}
+
+// matchAny returns true if values is empty or contains s.
+func matchAny(values []string, s string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// findingsDir returns the path to the findings directory.
+func (p Project) findingsDir() string {
+	return filepath.Join(p.Root(), findingsDirName)
+}
+
+// Findings returns the findings that pass the filter sorted by severity and ID.
+func (p *Project) Findings(filter FindingFilter) ([]*Finding, error) {
+	all, err := p.readFindings()
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.Findings: %s", err.Error())
+	}
+	var findings []*Finding
+	for _, f := range all {
+		if filter.match(f) {
+			findings = append(findings, f)
+		}
+	}
+	sortFindings(findings)
+	return findings, nil
+}
+
+// readFindings reads every finding file in the findings directory.
+func (p *Project) readFindings() ([]*Finding, error) {
+	files, err := ioutil.ReadDir(p.findingsDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var findings []*Finding
+	for _, fi := range files {
+		if fi.IsDir() || filepath.Ext(fi.Name()) != ".md" {
+			continue
+		}
+		pth := filepath.Join(p.findingsDir(), fi.Name())
+		content, err := shared.ReadFileString(pth)
+		if err != nil {
+			return nil, err
+		}
+		f, err := ParseFinding(content)
+		if err != nil {
+			return nil, fmt.Errorf("%s - %s", fi.Name(), err.Error())
+		}
+		f.path = pth
+		findings = append(findings, f)
+	}
+	return findings, nil
+}
+
+// sortFindings sorts findings by severity and ID.
+func sortFindings(findings []*Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		ri, rj := severityRank(findings[i].Severity), severityRank(findings[j].Severity)
+		if ri != rj {
+			return ri < rj
+		}
+		return findingNumber(findings[i].ID) < findingNumber(findings[j].ID)
+	})
+}
+
+// findingNumber returns the number in a finding ID or 0 if the ID is not valid.
+func findingNumber(id string) int {
+	id = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(id)), "F-")
+	n, err := strconv.Atoi(id)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// Finding returns a finding by ID. "F-001", "f-1" and "1" are the same ID.
+func (p *Project) Finding(id string) (*Finding, error) {
+	findings, err := p.readFindings()
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.Finding: %s", err.Error())
+	}
+	n := findingNumber(id)
+	for _, f := range findings {
+		if n > 0 && findingNumber(f.ID) == n || strings.EqualFold(f.ID, id) {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("project.Project.Finding: finding %s not found in %s", id, p.ProjectName)
+}
+
+// AddFinding assigns the next ID to the finding and writes it to the findings
+// directory. The status defaults to open.
+func (p *Project) AddFinding(f *Finding) error {
+	if f.Status == "" {
+		f.Status = FindingStatuses[0]
+	}
+	if err := f.Validate(); err != nil {
+		return fmt.Errorf("project.Project.AddFinding: %s", err.Error())
+	}
+	findings, err := p.readFindings()
+	if err != nil {
+		return fmt.Errorf("project.Project.AddFinding: %s", err.Error())
+	}
+	next := 1
+	for _, existing := range findings {
+		if n := findingNumber(existing.ID); n >= next {
+			next = n + 1
+		}
+	}
+	if err := os.MkdirAll(p.findingsDir(), os.ModePerm); err != nil {
+		return fmt.Errorf("project.Project.AddFinding: %s", err.Error())
+	}
+	f.ID = fmt.Sprintf(findingIDFormat, next)
+	f.Created = time.Now().Format(dateFormat)
+	f.Updated = f.Created
+	f.path = filepath.Join(p.findingsDir(), findingFilename(f.ID, f.Title))
+	// Never overwrite a finding file.
+	out, err := os.OpenFile(f.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("project.Project.AddFinding: %s", err.Error())
+	}
+	defer out.Close()
+	if _, err := out.WriteString(f.Markdown()); err != nil {
+		return fmt.Errorf("project.Project.AddFinding: %s", err.Error())
+	}
+	return nil
+}
+
+// SaveFinding validates the finding and writes it to its file. The updated
+// date is set to today.
+func (p *Project) SaveFinding(f *Finding) error {
+	if f.path == "" {
+		return fmt.Errorf("project.Project.SaveFinding: %s was not read from a project", f.ID)
+	}
+	if err := f.Validate(); err != nil {
+		return fmt.Errorf("project.Project.SaveFinding: %s", err.Error())
+	}
+	f.Updated = time.Now().Format(dateFormat)
+	if err := shared.WriteFileString(f.path, f.Markdown(), true); err != nil {
+		return fmt.Errorf("project.Project.SaveFinding: %s", err.Error())
+	}
+	return nil
+}
+
+// SetFindingStatus changes the status of a finding.
+func (p *Project) SetFindingStatus(id, status string) (*Finding, error) {
+	f, err := p.Finding(id)
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.SetFindingStatus: %s", err.Error())
+	}
+	f.Status = strings.ToLower(strings.TrimSpace(status))
+	if err := p.SaveFinding(f); err != nil {
+		return nil, fmt.Errorf("project.Project.SetFindingStatus: %s", err.Error())
+	}
+	return f, nil
+}
+
+// Path returns the path to the finding file.
+func (f Finding) Path() string {
+	return f.path
+}
+
+// findingFilename returns "ID-slug.md" where slug is the title in lowercase
+// with other characters replaced with "-".
+func findingFilename(id, title string) string {
+	slug := strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	// Long titles are cut at a word.
+	if len(slug) > 40 {
+		slug = slug[:40]
+		if i := strings.LastIndex(slug, "-"); i > 0 {
+			slug = slug[:i]
+		}
+	}
+	if slug == "" {
+		return id + ".md"
+	}
+	return id + "-" + slug + ".md"
+}
+
+// Markdown returns the content of the finding file.
+func (f Finding) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString(frontMatterDelim + "\n")
+	for _, kv := range [][2]string{
+		{"id", f.ID},
+		{"title", f.Title},
+		{"severity", f.Severity},
+		{"status", f.Status},
+	} {
+		// Library entries have no ID or status.
+		if kv[1] != "" {
+			writeFrontMatterValue(&sb, kv[0], kv[1])
+		}
+	}
+	writeFrontMatterList(&sb, "assets", f.Assets)
+	writeFrontMatterList(&sb, "evidence", f.Evidence)
+	if len(f.References) > 0 {
+		writeFrontMatterList(&sb, "references", f.References)
+	}
+	for _, kv := range [][2]string{
+		{"cvss", f.CVSS},
+		{"created", f.Created},
+		{"updated", f.Updated},
+		{"original", f.Original},
+	} {
+		if kv[1] != "" {
+			writeFrontMatterValue(&sb, kv[0], kv[1])
+		}
+	}
+	for _, k := range shared.SortedKeys(f.Extra) {
+		writeFrontMatterValue(&sb, k, f.Extra[k])
+	}
+	sb.WriteString(frontMatterDelim + "\n\n")
+	fmt.Fprintf(&sb, "%s\n\n", descriptionHeading)
+	if d := strings.TrimSpace(f.Description); d != "" {
+		sb.WriteString(d + "\n\n")
+	}
+	fmt.Fprintf(&sb, "%s\n\n", remediationHeading)
+	if r := strings.TrimSpace(f.Remediation); r != "" {
+		sb.WriteString(r + "\n\n")
+	}
+	if n := strings.TrimSpace(f.Notes); n != "" {
+		sb.WriteString(n + "\n")
+	}
+	return strings.TrimRight(sb.String(), "\n") + "\n"
+}
+
+// writeFrontMatterValue writes "key: value".
+func writeFrontMatterValue(sb *strings.Builder, key, value string) {
+	fmt.Fprintf(sb, "%s: %s\n", key, quoteFrontMatter(value))
+}
+
+// writeFrontMatterList writes the key and one "  - item" line per value.
+func writeFrontMatterList(sb *strings.Builder, key string, values []string) {
+	if len(values) == 0 {
+		fmt.Fprintf(sb, "%s: []\n", key)
+		return
+	}
+	fmt.Fprintf(sb, "%s:\n", key)
+	for _, v := range values {
+		fmt.Fprintf(sb, "  - %s\n", quoteFrontMatter(v))
+	}
+}
+
+// quoteFrontMatter quotes values that YAML would not read as plain strings.
+func quoteFrontMatter(s string) string {
+	if s == "" {
+		return ""
+	}
+	if strings.ContainsAny(s[:1], "@`'\"[]{}#&*!|>%,-?: ") || strings.HasSuffix(s, " ") ||
+		strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.ContainsAny(s, "\n\r\t") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// unquoteFrontMatter reverses quoteFrontMatter. Single quoted YAML strings are
+// also supported.
+func unquoteFrontMatter(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case len(s) >= 2 && s[0] == '"':
+		return strconv.Unquote(s)
+	case len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'':
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1), nil
+	}
+	return s, nil
+}
+
+// ParseFinding parses the content of a finding file. The front matter is a
+// subset of YAML with "key: value" lines and lists as "  - item" lines or
+// "[a, b]".
+func ParseFinding(content string) (*Finding, error) {
+	f, err := parseFinding(content)
+	if err != nil {
+		return nil, fmt.Errorf("project.ParseFinding: %s", err.Error())
+	}
+	if f.ID == "" {
+		return nil, fmt.Errorf("project.ParseFinding: missing id")
+	}
+	return f, nil
+}
+
+// parseFinding parses a finding file without checking the ID. Library entries
+// have no ID.
+func parseFinding(content string) (*Finding, error) {
+	content = strings.Replace(content, "\r\n", "\n", -1)
+	lines := strings.Split(content, "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != frontMatterDelim {
+		return nil, fmt.Errorf("missing front matter")
+	}
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == frontMatterDelim {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return nil, fmt.Errorf("front matter is not closed")
+	}
+	values, lists, err := parseFrontMatter(lines[1:end])
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Finding{Extra: make(map[string]string)}
+	for k, v := range values {
+		switch k {
+		case "id":
+			f.ID = v
+		case "title":
+			f.Title = v
+		case "severity":
+			f.Severity = strings.ToLower(v)
+		case "status":
+			f.Status = strings.ToLower(v)
+		case "created":
+			f.Created = v
+		case "updated":
+			f.Updated = v
+		case "original":
+			f.Original = v
+		case "cvss":
+			f.CVSS = v
+		default:
+			f.Extra[k] = v
+		}
+	}
+	f.Assets, f.Evidence = lists["assets"], lists["evidence"]
+	f.References = lists["references"]
+	f.Description, f.Remediation, f.Notes = parseFindingBody(lines[end+1:])
+	return f, nil
+}
+
+// parseFrontMatter returns the values and lists in the front matter lines.
+func parseFrontMatter(lines []string) (map[string]string, map[string][]string, error) {
+	values := make(map[string]string)
+	lists := make(map[string][]string)
+	key := ""
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+			if key == "" {
+				return nil, nil, fmt.Errorf("line %d: list item without a key", i+2)
+			}
+			item, err := unquoteFrontMatter(strings.TrimPrefix(trimmed, "-"))
+			if err != nil {
+				return nil, nil, fmt.Errorf("line %d: %s", i+2, err.Error())
+			}
+			lists[key] = append(lists[key], item)
+			continue
+		}
+		kv := strings.SplitN(trimmed, ":", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, nil, fmt.Errorf("line %d: invalid line %q, use key: value", i+2, trimmed)
+		}
+		key = strings.ToLower(strings.TrimSpace(kv[0]))
+		value := strings.TrimSpace(kv[1])
+		switch {
+		case value == "":
+			// A list follows.
+			lists[key] = nil
+		case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+			lists[key] = nil
+			for _, item := range strings.Split(value[1:len(value)-1], ",") {
+				if item, err := unquoteFrontMatter(item); err != nil {
+					return nil, nil, fmt.Errorf("line %d: %s", i+2, err.Error())
+				} else if item != "" {
+					lists[key] = append(lists[key], item)
+				}
+			}
+		default:
+			v, err := unquoteFrontMatter(value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("line %d: %s", i+2, err.Error())
+			}
+			values[key] = v
+		}
+	}
+	return values, lists, nil
+}
+
+// parseFindingBody returns the description and remediation sections and the
+// rest of the body.
+func parseFindingBody(lines []string) (description, remediation, notes string) {
+	var desc, rem, rest []string
+	current := &rest
+	for _, line := range lines {
+		switch strings.TrimSpace(line) {
+		case descriptionHeading:
+			current = &desc
+			continue
+		case remediationHeading:
+			current = &rem
+			continue
+		}
+		if strings.HasPrefix(line, "## ") {
+			current = &rest
+		}
+		*current = append(*current, line)
+	}
+	join := func(l []string) string { return strings.TrimSpace(strings.Join(l, "\n")) }
+	return join(desc), join(rem), join(rest)
+}
+
+// CVSSScore returns the score of the CVSS vector. ok is false if the finding
+// has no valid vector.
+func (f Finding) CVSSScore() (score float64, ok bool) {
+	v, err := cvss.Parse(f.CVSS)
+	if err != nil {
+		return 0, false
+	}
+	return v.Score(), true
+}
+
+// ScoreFinding sets the CVSS vector of a finding and the severity of its
+// score. A score of 0 is info.
+func (p *Project) ScoreFinding(id, vector string) (*Finding, error) {
+	v, err := cvss.Parse(vector)
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.ScoreFinding: %s", err.Error())
+	}
+	f, err := p.Finding(id)
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.ScoreFinding: %s", err.Error())
+	}
+	f.CVSS = v.String()
+	f.Severity = severityOf(v.Score())
+	if err := p.SaveFinding(f); err != nil {
+		return nil, fmt.Errorf("project.Project.ScoreFinding: %s", err.Error())
+	}
+	return f, nil
+}
+
+// severityOf returns the finding severity of a CVSS score.
+func severityOf(score float64) string {
+	if s := cvss.Severity(score); s != "none" {
+		return s
+	}
+	return "info"
+}
+
+// markFindingsRetest sets the status of every finding in the project to
+// retest and records the original finding in src. Evidence that was not
+// copied is removed from the finding and listed in the notes with a link to
+// the source project. Returns the number of findings.
+func (p *Project) markFindingsRetest(src *Project) (int, error) {
+	findings, err := p.readFindings()
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range findings {
+		f.Status = "retest"
+		f.Original = src.ProjectName + "/" + f.ID
+		var kept, missing []string
+		for _, e := range f.Evidence {
+			exists := isRemote(e)
+			if !exists {
+				if exists, err = shared.PathExists(filepath.Join(p.Root(), filepath.FromSlash(e))); err != nil {
+					return 0, err
+				}
+			}
+			if exists {
+				kept = append(kept, e)
+			} else {
+				missing = append(missing, e)
+			}
+		}
+		if len(missing) > 0 {
+			f.Evidence = kept
+			var sb strings.Builder
+			// Notes start with a heading.
+			fmt.Fprintf(&sb, "## Original Evidence\n\nNot copied from %s.\n\n", src.ProjectName)
+			for _, e := range missing {
+				// Links are relative to the finding file.
+				link, err := filepath.Rel(filepath.Dir(f.path), filepath.Join(src.Root(), filepath.FromSlash(e)))
+				if err != nil {
+					return 0, err
+				}
+				fmt.Fprintf(&sb, "* [%s](%s)\n", e, filepath.ToSlash(link))
+			}
+			f.Notes = strings.TrimSpace(f.Notes + "\n\n" + sb.String())
+		}
+		if err := p.SaveFinding(f); err != nil {
+			return 0, err
+		}
+	}
+	return len(findings), nil
+}
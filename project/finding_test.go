@@ -0,0 +1,165 @@
+package project
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindingRoundTrip(t *testing.T) {
+	f := &Finding{
+		ID:          "F-007",
+		Title:       "Reflected XSS: search",
+		Severity:    "high",
+		Status:      "open",
+		Assets:      []string{"https://example.com/search", "api"},
+		Evidence:    []string{"@pix/xss.png"},
+		CVSS:        "CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N",
+		References:  []string{"https://owasp.org/www-community/attacks/xss/"},
+		Created:     "2026-10-01",
+		Updated:     "2026-10-02",
+		Description: "The q parameter is reflected.\n\n### Steps\n\n1. Search.",
+		Remediation: "Encode the output.",
+		Notes:       "## References\n\n* OWASP",
+		Extra:       map[string]string{"cwe": "CWE-79"},
+	}
+	got, err := ParseFinding(f.Markdown())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, f) {
+		t.Errorf("ParseFinding(Markdown()) = %+v, want %+v", got, f)
+	}
+}
+
+func TestParseFinding(t *testing.T) {
+	content := "---\r\nid: F-001\r\ntitle: 'It''s broken'\r\nseverity: High\r\nassets: [a, \"b\", c]\r\n" +
+		"evidence: []\r\n---\r\nIntro.\r\n## Description\r\nDetails.\r\n"
+	f, err := ParseFinding(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Title != "It's broken" || f.Severity != "high" || f.Description != "Details." || f.Notes != "Intro." {
+		t.Errorf("ParseFinding() = %+v", f)
+	}
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(f.Assets, want) {
+		t.Errorf("ParseFinding() assets = %q, want %q", f.Assets, want)
+	}
+
+	for name, content := range map[string]string{
+		"no-front-matter": "# Finding\n",
+		"not-closed":      "---\nid: F-001\n",
+		"no-id":           "---\ntitle: XSS\n---\n",
+		"invalid-line":    "---\nid: F-001\nnot a key\n---\n",
+		"orphan-item":     "---\n- item\nid: F-001\n---\n",
+	} {
+		if _, err := ParseFinding(content); err == nil {
+			t.Errorf("%s: ParseFinding() did not return an error", name)
+		}
+	}
+}
+
+func TestFindingFilename(t *testing.T) {
+	tests := map[string]string{
+		"SQL Injection in /login": "F-001-sql-injection-in-login.md",
+		"!!!":                     "F-001.md",
+		"A very long title that is longer than forty characters": "F-001-a-very-long-title-that-is-longer-than.md",
+	}
+	for title, want := range tests {
+		if got := findingFilename("F-001", title); got != want {
+			t.Errorf("findingFilename(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
+
+func TestFindings(t *testing.T) {
+	p := &Project{ProjectName: "acme", ProjectRoot: t.TempDir()}
+	for _, f := range []*Finding{
+		{Title: "Verbose errors", Severity: "low"},
+		{Title: "SQL injection", Severity: "critical", Assets: []string{"db"}},
+		{Title: "XSS", Severity: "high", Status: "fixed"},
+		{Title: "CSRF", Severity: "high"},
+	} {
+		if err := p.AddFinding(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.AddFinding(&Finding{Title: "Bad", Severity: "severe"}); err == nil {
+		t.Error("AddFinding() did not return an error for an invalid severity")
+	}
+
+	ids := func(filter FindingFilter) string {
+		findings, err := p.Findings(filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var s []string
+		for _, f := range findings {
+			s = append(s, f.ID)
+		}
+		return strings.Join(s, ",")
+	}
+	tests := []struct {
+		name   string
+		filter FindingFilter
+		want   string
+	}{
+		{"all", FindingFilter{}, "F-002,F-003,F-004,F-001"},
+		{"severity", FindingFilter{Severities: []string{"high"}}, "F-003,F-004"},
+		{"status", FindingFilter{Statuses: []string{"open"}}, "F-002,F-004,F-001"},
+		{"both", FindingFilter{Severities: []string{"HIGH", "low"}, Statuses: []string{"open"}}, "F-004,F-001"},
+	}
+	for _, tt := range tests {
+		if got := ids(tt.filter); got != tt.want {
+			t.Errorf("%s: Findings() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	f, err := p.SetFindingStatus("2", "accepted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(f.Path()) != "F-002-sql-injection.md" {
+		t.Errorf("SetFindingStatus() path = %s", f.Path())
+	}
+	if f, err = p.Finding("f-2"); err != nil || f.Status != "accepted" || f.Assets[0] != "db" {
+		t.Errorf("Finding() = %+v, %v", f, err)
+	}
+	if _, err := p.SetFindingStatus("F-002", "closed"); err == nil {
+		t.Error("SetFindingStatus() did not return an error for an invalid status")
+	}
+	if _, err := p.Finding("F-009"); err == nil {
+		t.Error("Finding() did not return an error for a missing finding")
+	}
+
+	scoreTests := []struct {
+		vector       string
+		wantSeverity string
+		wantErr      bool
+	}{
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", "medium", false},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", "critical", false},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N", "info", false},
+		{"CVSS:3.1/AV:N", "", true},
+	}
+	for _, tt := range scoreTests {
+		f, err := p.ScoreFinding("F-004", tt.vector)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("ScoreFinding(%s) error = %v, wantErr %v", tt.vector, err, tt.wantErr)
+		}
+		if err == nil && (f.Severity != tt.wantSeverity || f.CVSS != tt.vector) {
+			t.Errorf("ScoreFinding(%s) = %s, %s, want %s", tt.vector, f.Severity, f.CVSS, tt.wantSeverity)
+		}
+	}
+	if f, _ = p.Finding("F-004"); f.Severity != "info" {
+		t.Errorf("ScoreFinding() did not save the severity, got %s", f.Severity)
+	}
+
+	if n, err := p.markFindingsRetest(&Project{ProjectName: "old", ProjectRoot: t.TempDir()}); err != nil || n != 4 {
+		t.Fatalf("markFindingsRetest() = %d, %v", n, err)
+	}
+	if f, _ = p.Finding("F-003"); f.Status != "retest" || f.Original != "old/F-003" {
+		t.Errorf("markFindingsRetest() finding = %+v", f)
+	}
+}
@@ -0,0 +1,144 @@
+package project
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// instance is one copy of a node and the project data used to create it.
+type instance struct {
+	node *Node
+	p    Project
+}
+
+// instances returns the copies of the child node that should be created under
+// n. If the child has foreach, it returns one copy per item with .Item and
+// .Index set, otherwise one copy of the child. FullPath of each copy is
+// rendered and joined with the path of n. The template tree is not modified so
+// it can be walked more than once.
+func (n *Node) instances(child *Node, p Project) ([]instance, error) {
+	var insts []instance
+	if child.ForEach == "" {
+		insts = append(insts, instance{node: child.clone(), p: p})
+	} else {
+		items, err := evalList(p, child.ForEach)
+		if err != nil {
+			return nil, err
+		}
+		for i, item := range items {
+			ip := p
+			ip.Item, ip.Index = item, i
+			insts = append(insts, instance{node: child.clone(), p: ip})
+		}
+	}
+
+	for _, inst := range insts {
+		// Paths inside a foreach can reference the item. The rest of the
+		// template has already been rendered.
+		pth, err := renderString(inst.p, inst.node.FullPath)
+		if err != nil {
+			return nil, err
+		}
+		inst.node.FullPath = filepath.Join(n.FullPath, pth)
+	}
+	return insts, nil
+}
+
+// clone returns a deep copy of the node. Each foreach item needs its own copy
+// because FullPath is different.
+func (n *Node) clone() *Node {
+	cp := *n
+	if n.Info != nil {
+		info := *n.Info
+		cp.Info = &info
+	}
+	cp.Children = make([]*Node, 0, len(n.Children))
+	for _, child := range n.Children {
+		cp.Children = append(cp.Children, child.clone())
+	}
+	return &cp
+}
+
+// evalList evaluates a foreach expression against the project and returns the
+// list of items. The expression is a template pipeline such as .Vars.hosts,
+// .Data.hosts or index .Config "roles". Strings are split on commas and new
+// lines.
+func evalList(p Project, expr string) ([]interface{}, error) {
+	expr = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(expr), "{{"), "}}"))
+
+	// Capture the value of the pipeline instead of printing it.
+	var value interface{}
+	funcs := template.FuncMap{
+		"capture": func(v interface{}) string {
+			value = v
+			return ""
+		},
+	}
+	tmpl, err := template.New("foreach").Funcs(funcs).Parse("{{ capture (" + expr + ") }}")
+	if err != nil {
+		return nil, fmt.Errorf("project.evalList: parse %q - %s", expr, err.Error())
+	}
+	if err := tmpl.Execute(&strings.Builder{}, p); err != nil {
+		return nil, fmt.Errorf("project.evalList: execute %q - %s", expr, err.Error())
+	}
+
+	var items []interface{}
+	switch v := value.(type) {
+	case nil:
+	case string:
+		for _, item := range strings.FieldsFunc(v, func(r rune) bool {
+			return r == ',' || r == '\n' || r == '\r'
+		}) {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	default:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return nil, fmt.Errorf("project.evalList: %q is %T, not a list", expr, v)
+		}
+		for i := 0; i < rv.Len(); i++ {
+			items = append(items, rv.Index(i).Interface())
+		}
+	}
+	return items, nil
+}
+
+// renderString executes s as a template with the project and the delimiters of
+// the project template. Strings without actions are returned as-is.
+func renderString(p Project, s string) (string, error) {
+	if !p.delims.hasAction(s) {
+		return s, nil
+	}
+	tmpl, err := template.New("path").Delims(p.delims.Left, p.delims.Right).Parse(s)
+	if err != nil {
+		return "", fmt.Errorf("project.renderString: parse %q - %s", s, err.Error())
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, p); err != nil {
+		return "", fmt.Errorf("project.renderString: execute %q - %s", s, err.Error())
+	}
+	return sb.String(), nil
+}
+
+// itemRef matches references to the current foreach item inside an action.
+var itemRef = regexp.MustCompile(`\.(Item|Index)\b`)
+
+// deferItemActions escapes the actions that reference .Item or .Index in a
+// project template with delimiters d. The project template is rendered before
+// foreach is expanded, these actions are rendered later for each item.
+func deferItemActions(tmpl string, d delims) string {
+	action := regexp.MustCompile(regexp.QuoteMeta(d.left()) + `[^\n]*?` + regexp.QuoteMeta(d.right()))
+	return action.ReplaceAllStringFunc(tmpl, func(a string) string {
+		// Actions with backticks cannot be escaped in a raw string.
+		if !itemRef.MatchString(a) || strings.Contains(a, "`") {
+			return a
+		}
+		return d.left() + "`" + a + "`" + d.right()
+	})
+}
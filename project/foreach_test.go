@@ -0,0 +1,86 @@
+package project
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEvalList(t *testing.T) {
+	p := Project{
+		Config: map[string]string{"roles": "admin,user"},
+		Vars:   map[string]string{"hosts": "10.0.0.1, 10.0.0.2,,"},
+		Data: map[string]interface{}{
+			"accounts": []interface{}{"alice", "bob"},
+			"lines":    []string{"a", "b", "c"},
+			"object":   map[string]interface{}{"a": 1},
+		},
+	}
+	tests := []struct {
+		name    string
+		expr    string
+		want    []interface{}
+		wantErr bool
+	}{
+		{"vars", ".Vars.hosts", []interface{}{"10.0.0.1", "10.0.0.2"}, false},
+		{"config", `index .Config "roles"`, []interface{}{"admin", "user"}, false},
+		{"data-json", ".Data.accounts", []interface{}{"alice", "bob"}, false},
+		{"data-lines", ".Data.lines", []interface{}{"a", "b", "c"}, false},
+		{"wrapped", "{{ .Data.accounts }}", []interface{}{"alice", "bob"}, false},
+		{"missing", ".Vars.missing", nil, false},
+		{"not-a-list", ".Data.object", nil, true},
+		{"parse-error", ".Vars.hosts)", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := evalList(p, tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("evalList() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("evalList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeferItemActions(t *testing.T) {
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{"item", `"path": "{{ .Item }}"`, "\"path\": \"{{`{{ .Item }}`}}\""},
+		{"item-field", `{{ .Item.name }}-{{ .Index }}`, "{{`{{ .Item.name }}`}}-{{`{{ .Index }}`}}"},
+		{"index-func", `{{ index .Item \"host\" }}`, "{{`{{ index .Item \\\"host\\\" }}`}}"},
+		{"other-actions", `{{ .Workspace }}/{{ .ProjectName }}`, `{{ .Workspace }}/{{ .ProjectName }}`},
+		{"items", `{{ .Items }}`, `{{ .Items }}`},
+		{"backtick", "{{ printf `%s` .Item }}", "{{ printf `%s` .Item }}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deferItemActions(tt.tmpl, delims{}); got != tt.want {
+				t.Errorf("deferItemActions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeferItemActionsDelims(t *testing.T) {
+	d := delims{Left: "[[", Right: "]]"}
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{"item", `"path": "[[ .Item ]]"`, "\"path\": \"[[`[[ .Item ]]`]]\""},
+		{"literal-braces", `"{{ .Item }}" [[ .ProjectName ]]`, `"{{ .Item }}" [[ .ProjectName ]]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deferItemActions(tt.tmpl, d); got != tt.want {
+				t.Errorf("deferItemActions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/parsiya/borrowedtime/config"
+	"github.com/parsiya/borrowedtime/shared"
+)
+
+// Template test files in the templates directory.
+const (
+	// fixturesFilename contains the fixture projects as a map of fixture name
+	// to project.
+	fixturesFilename = "fixtures.json"
+	// goldenDirname contains the golden files in "fixture/kind/name.golden".
+	goldenDirname = "golden"
+	goldenExt     = "golden"
+	// workspacePlaceholder replaces the temporary workspace in golden files.
+	workspacePlaceholder = "$WORKSPACE"
+)
+
+// Template test statuses.
+const (
+	TestPass    = "pass"
+	TestFail    = "fail"
+	TestMissing = "missing"
+	TestUpdated = "updated"
+	TestError   = "error"
+)
+
+// TemplateTestResult is the result of rendering one template with one
+// fixture.
+type TemplateTestResult struct {
+	Fixture string
+	Kind    string
+	Name    string
+	Status  string
+	// Details contains the diff from the golden file for failed tests and the
+	// error for errors.
+	Details string
+}
+
+// goldenReportDate is the date of reports without an end date.
+const goldenReportDate = "2006-01-02"
+
+// defaultFixtures are used if the fixtures file does not exist.
+var defaultFixtures = map[string]Project{
+	"default": {ProjectName: "acme"},
+}
+
+// RunTemplateTests renders every template with every fixture and compares the
+// output with the golden files. If name is not empty, only templates with that
+// name are tested. If update is set, golden files are written instead.
+// Project templates are created in a temporary workspace.
+func RunTemplateTests(name string, update bool) ([]TemplateTestResult, error) {
+	tmplDir, err := config.TemplateDir()
+	if err != nil {
+		return nil, fmt.Errorf("project.RunTemplateTests: %s", err.Error())
+	}
+	fixtures, err := readFixtures(filepath.Join(tmplDir, fixturesFilename))
+	if err != nil {
+		return nil, fmt.Errorf("project.RunTemplateTests: %s", err.Error())
+	}
+	tmpls, err := config.Templates()
+	if err != nil {
+		return nil, fmt.Errorf("project.RunTemplateTests: %s", err.Error())
+	}
+
+	var results []TemplateTestResult
+	for _, fixture := range sortedFixtures(fixtures) {
+		for _, t := range tmpls {
+			if name != "" && t.Name != shared.RemoveExtension(name) {
+				continue
+			}
+			res := TemplateTestResult{Fixture: fixture, Kind: t.Kind, Name: t.Name}
+			out, err := renderFixture(fixtures[fixture], t)
+			if err != nil {
+				res.Status, res.Details = TestError, err.Error()
+				results = append(results, res)
+				continue
+			}
+			golden := filepath.Join(tmplDir, goldenDirname, fixture, t.Kind,
+				shared.AddExtension(t.Name, goldenExt))
+			res.Status, res.Details, err = compareGolden(golden, out, update)
+			if err != nil {
+				return results, fmt.Errorf("project.RunTemplateTests: %s", err.Error())
+			}
+			results = append(results, res)
+		}
+	}
+	if len(results) == 0 {
+		if name != "" {
+			return nil, fmt.Errorf("project.RunTemplateTests: template %s not found", name)
+		}
+		return nil, fmt.Errorf("project.RunTemplateTests: no templates in %s", tmplDir)
+	}
+	return results, nil
+}
+
+// readFixtures reads the fixtures file. The default fixture is returned if the
+// file does not exist.
+func readFixtures(pth string) (map[string]Project, error) {
+	exists, err := shared.PathExists(pth)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return defaultFixtures, nil
+	}
+	content, err := shared.ReadFileByte(pth)
+	if err != nil {
+		return nil, err
+	}
+	fixtures := make(map[string]Project)
+	if err := json.Unmarshal(content, &fixtures); err != nil {
+		return nil, fmt.Errorf("unmarshal %s - %s", fixturesFilename, err.Error())
+	}
+	if len(fixtures) == 0 {
+		return nil, fmt.Errorf("%s has no fixtures", pth)
+	}
+	return fixtures, nil
+}
+
+// sortedFixtures returns the fixture names in alphabetical order.
+func sortedFixtures(fixtures map[string]Project) []string {
+	names := make(map[string]string, len(fixtures))
+	for name := range fixtures {
+		names[name] = name
+	}
+	return shared.SortedKeys(names)
+}
+
+// renderFixture renders the template with the fixture project in a temporary
+// workspace. File templates return their content and project templates return
+// the created tree. The workspace is replaced with a placeholder so the output
+// does not depend on the machine.
+func renderFixture(fixture Project, t config.Template) (string, error) {
+	ws, err := ioutil.TempDir("", "borrowedtime-test")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(ws)
+
+	p := fixture
+	if p.ProjectName == "" {
+		p.ProjectName = "acme"
+	}
+	p.Workspace = filepath.ToSlash(ws)
+	p.ProjectRoot = path.Join(p.Workspace, p.ProjectName)
+	if p.Config == nil {
+		p.Config = make(map[string]string)
+	}
+	if p.ProjectConfig == nil {
+		p.ProjectConfig = make(map[string]string)
+	}
+	if p.Vars == nil {
+		p.Vars = make(map[string]string)
+	}
+	if err := p.loadData(); err != nil {
+		return "", err
+	}
+
+	var out string
+	switch t.Kind {
+	case config.FileKind:
+		out, _, err = genTemplate(p, t.Name, false)
+		if err != nil {
+			return "", err
+		}
+	case config.ReportKind:
+		// Reports are rendered without findings. The date is fixed so golden
+		// files do not change every day.
+		r := newReport(&p, map[string]interface{}{}, nil)
+		if r.Date == "" {
+			r.Date = goldenReportDate
+		}
+		// The document of DOCX reports is compared.
+		if isDOCX(t.FullPath) {
+			d, err := r.renderDOCX(t)
+			if err != nil {
+				return "", err
+			}
+			out = string(d.files[docxDocument])
+		} else if out, err = r.render(t, p.reportDir()); err != nil {
+			return "", err
+		}
+	default:
+		tmpl, err := p.generateTemplate(t.Name, true)
+		if err != nil {
+			return "", err
+		}
+		root, err := parseProjectTemplate(tmpl)
+		if err != nil {
+			return "", err
+		}
+		// Never create anything outside of the temporary workspace.
+		if rel, err := filepath.Rel(ws, root.FullPath); err != nil || strings.HasPrefix(rel, "..") {
+			return "", fmt.Errorf("project root %s is not inside {{ .Workspace }}", root.FullPath)
+		}
+		if err := execProjectTemplate(p, tmpl, false); err != nil {
+			return "", err
+		}
+		if out, err = dumpTree(ws); err != nil {
+			return "", err
+		}
+	}
+	out = strings.Replace(out, p.Workspace, workspacePlaceholder, -1)
+	return strings.Replace(out, filepath.FromSlash(p.Workspace), workspacePlaceholder, -1), nil
+}
+
+// dumpTree returns the paths under root and the content of files as text.
+// Each path starts with "=== ", directories end with "/" and symbolic links
+// are followed by their target.
+func dumpTree(root string) (string, error) {
+	var sb strings.Builder
+	err := filepath.Walk(root, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, pth)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(pth)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&sb, "=== %s -> %s\n", rel, filepath.ToSlash(target))
+		case info.IsDir():
+			fmt.Fprintf(&sb, "=== %s/\n", rel)
+		default:
+			content, err := shared.ReadFileString(pth)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&sb, "=== %s\n%s", rel, content)
+			if content != "" && !strings.HasSuffix(content, "\n") {
+				sb.WriteString("\n")
+			}
+		}
+		return nil
+	})
+	return sb.String(), err
+}
+
+// compareGolden compares out with the golden file and returns the status and
+// the diff. If update is set, the golden file is written.
+func compareGolden(golden, out string, update bool) (string, string, error) {
+	exists, err := shared.PathExists(golden)
+	if err != nil {
+		return "", "", err
+	}
+	if update {
+		if err := os.MkdirAll(filepath.Dir(golden), os.ModePerm); err != nil {
+			return "", "", err
+		}
+		if err := shared.WriteFileString(golden, out, true); err != nil {
+			return "", "", err
+		}
+		return TestUpdated, "", nil
+	}
+	if !exists {
+		return TestMissing, "", nil
+	}
+	want, err := shared.ReadFileString(golden)
+	if err != nil {
+		return "", "", err
+	}
+	if diff := shared.Diff(want, out, 2); diff != "" {
+		return TestFail, diff, nil
+	}
+	return TestPass, "", nil
+}
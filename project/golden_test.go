@@ -0,0 +1,86 @@
+package project
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/parsiya/borrowedtime/config"
+)
+
+func TestCompareGolden(t *testing.T) {
+	dir, err := ioutil.TempDir("", "golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	golden := filepath.Join(dir, "default", "file", "notes.golden")
+
+	tests := []struct {
+		name   string
+		out    string
+		update bool
+		want   string
+	}{
+		{"missing", "# acme Notes\n", false, TestMissing},
+		{"update", "# acme Notes\n", true, TestUpdated},
+		{"pass", "# acme Notes\n", false, TestPass},
+		{"fail", "# acme notes\n", false, TestFail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, diff, err := compareGolden(golden, tt.out, tt.update)
+			if err != nil {
+				t.Fatalf("compareGolden() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("compareGolden() = %v, want %v", got, tt.want)
+			}
+			if (got == TestFail) != (diff != "") {
+				t.Errorf("compareGolden() diff = %q with status %v", diff, got)
+			}
+		})
+	}
+}
+
+func TestRunTemplateTests(t *testing.T) {
+	testHome(t)
+	for _, tt := range []struct {
+		update bool
+		want   string
+	}{
+		{true, TestUpdated},
+		{false, TestPass},
+	} {
+		results, err := RunTemplateTests("", tt.update)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) == 0 {
+			t.Fatal("RunTemplateTests() returned no results")
+		}
+		for _, r := range results {
+			if r.Status != tt.want {
+				t.Errorf("RunTemplateTests(%v) %s %s = %s, want %s - %s", tt.update, r.Kind, r.Name, r.Status, tt.want, r.Details)
+			}
+		}
+	}
+	if _, err := RunTemplateTests("not-a-template", false); err == nil {
+		t.Error("RunTemplateTests() did not return an error for a missing template")
+	}
+
+	// Nothing to test is not a success.
+	tmpls, err := config.Templates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tm := range tmpls {
+		if err := os.Remove(tm.FullPath); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if results, err := RunTemplateTests("", false); err == nil {
+		t.Errorf("RunTemplateTests() without templates = %v, want an error", results)
+	}
+}
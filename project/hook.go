@@ -0,0 +1,200 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/parsiya/borrowedtime/shared"
+)
+
+// Hook names. Hooks are declared in the "hooks" object of the project template
+// root and with the same keys in the workspace config.
+const (
+	// HookPostCreate runs after the project is created.
+	HookPostCreate = "post-create"
+	// HookPostOpen runs when the project is opened.
+	HookPostOpen = "post-open"
+)
+
+// Values of "hookfailure" in the workspace config.
+const (
+	// keyHookFailure decides what happens to a new project when a post-create
+	// hook fails. The project is kept unless it's set to rollback.
+	keyHookFailure = "hookfailure"
+	// hookRollback deletes the project.
+	hookRollback = "rollback"
+)
+
+// hookNames contains the supported hooks.
+var hookNames = []string{HookPostCreate, HookPostOpen}
+
+// hookCommands returns the commands for a hook. Commands from the project
+// template run first, then the command in the workspace config. root can be
+// nil.
+func (p Project) hookCommands(hook string, root *Node) []string {
+	var cmds []string
+	if root != nil {
+		cmds = append(cmds, root.Hooks[hook]...)
+	}
+	if cmd := p.Config[hook]; strings.TrimSpace(cmd) != "" {
+		cmds = append(cmds, cmd)
+	}
+	return cmds
+}
+
+// hooksObject matches the start of the hooks object in a project template.
+var hooksObject = regexp.MustCompile(`"hooks"\s*:\s*\{`)
+
+// deferHookActions escapes the left delimiters inside the hooks objects of a
+// project template with delimiters d. Hook commands are not rendered because
+// values such as variables would be run by the shell. Hooks read them from the
+// environment instead (see hookEnv).
+func deferHookActions(tmpl string, d delims) string {
+	escaped := d.left() + "`" + d.left() + "`" + d.right()
+	var sb strings.Builder
+	for {
+		loc := hooksObject.FindStringIndex(tmpl)
+		if loc == nil {
+			sb.WriteString(tmpl)
+			return sb.String()
+		}
+		end := jsonObjectEnd(tmpl, loc[1]-1)
+		sb.WriteString(tmpl[:loc[0]])
+		sb.WriteString(strings.Replace(tmpl[loc[0]:end], d.left(), escaped, -1))
+		tmpl = tmpl[end:]
+	}
+}
+
+// jsonObjectEnd returns the index after the end of the JSON object that
+// starts at s[start]. Braces in strings are ignored. It returns len(s) if the
+// object is not closed.
+func jsonObjectEnd(s string, start int) int {
+	depth, inString := 0, false
+	for i := start; i < len(s); i++ {
+		switch c := s[i]; {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(s)
+}
+
+// runHooks runs the commands in the project root. It returns the output of
+// every command. The first failing command stops the rest. Commands are not
+// rendered, project values are in the environment.
+func (p Project) runHooks(hook string, cmds []string) (string, error) {
+	var sb strings.Builder
+	for _, command := range cmds {
+		fmt.Fprintf(&sb, "[%s] %s\n", hook, command)
+		out, err := p.runHook(command)
+		sb.WriteString(out)
+		if len(out) > 0 && !strings.HasSuffix(out, "\n") {
+			sb.WriteString("\n")
+		}
+		if err != nil {
+			return sb.String(), fmt.Errorf("project.Project.runHooks: %s %q failed - %s", hook, command, err.Error())
+		}
+	}
+	return sb.String(), nil
+}
+
+// runHook runs one command with the shell and returns the combined output.
+func (p Project) runHook(command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Dir = p.ProjectRoot
+	cmd.Env = append(os.Environ(), p.hookEnv()...)
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+// hookEnv returns the project information and template variables as
+// environment variables. Variables are exported as BT_VAR_NAME.
+func (p Project) hookEnv() []string {
+	env := []string{
+		"BT_PROJECTNAME=" + p.ProjectName,
+		"BT_WORKSPACE=" + p.Workspace,
+		"BT_PROJECTROOT=" + p.ProjectRoot,
+	}
+	for _, k := range shared.SortedKeys(p.Vars) {
+		name := strings.ToUpper(strings.Map(func(r rune) rune {
+			if r == '-' || r == '.' || r == ' ' {
+				return '_'
+			}
+			return r
+		}, k))
+		env = append(env, "BT_VAR_"+name+"="+p.Vars[k])
+	}
+	return env
+}
+
+// postCreate runs the post-create hooks of a new project. If a hook fails and
+// hookfailure is rollback, a project that did not exist before Create is
+// deleted and an error is returned. Otherwise the failure is printed and the
+// project is kept.
+func (p Project) postCreate(root *Node, existed bool) error {
+	out, err := p.runHooks(HookPostCreate, p.hookCommands(HookPostCreate, root))
+	fmt.Print(out)
+	if err == nil {
+		return nil
+	}
+	if p.Config[keyHookFailure] != hookRollback || existed {
+		fmt.Printf("%s\nProject kept at %s.\n", err.Error(), p.ProjectRoot)
+		return nil
+	}
+	if delErr := shared.DeletePath(p.ProjectRoot); delErr != nil {
+		return fmt.Errorf("project.Project.postCreate: %s, rollback failed - %s", err.Error(), delErr.Error())
+	}
+	if regErr := p.unregister(); regErr != nil {
+		return fmt.Errorf("project.Project.postCreate: %s, project was removed - %s", err.Error(), regErr.Error())
+	}
+	return fmt.Errorf("project.Project.postCreate: %s, project was removed", err.Error())
+}
+
+// PostOpen runs the post-open hooks of the project and returns their output.
+// Hooks are read from the project template recorded in the project config and
+// the workspace config.
+func (p *Project) PostOpen() (string, error) {
+	var root *Node
+	if name := p.ProjectConfig[keyTemplate]; name != "" {
+		if err := p.loadData(); err != nil {
+			return "", fmt.Errorf("project.Project.PostOpen: %s", err.Error())
+		}
+		tmpl, err := p.generateTemplate(name, true)
+		if err != nil {
+			return "", fmt.Errorf("project.Project.PostOpen: %s", err.Error())
+		}
+		if root, err = parseProjectTemplate(tmpl); err != nil {
+			return "", fmt.Errorf("project.Project.PostOpen: %s", err.Error())
+		}
+	}
+	return p.runHooks(HookPostOpen, p.hookCommands(HookPostOpen, root))
+}
+
+// validHook returns true if name is a supported hook.
+func validHook(name string) bool {
+	for _, h := range hookNames {
+		if h == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/parsiya/borrowedtime/config"
+)
+
+func TestHookEnv(t *testing.T) {
+	p := Project{
+		ProjectName: "acme",
+		Workspace:   "/ws",
+		ProjectRoot: "/ws/acme",
+		Vars:        map[string]string{"client-name": "Acme Corp", "platform": "web"},
+	}
+	want := []string{
+		"BT_PROJECTNAME=acme",
+		"BT_WORKSPACE=/ws",
+		"BT_PROJECTROOT=/ws/acme",
+		"BT_VAR_CLIENT_NAME=Acme Corp",
+		"BT_VAR_PLATFORM=web",
+	}
+	if got := p.hookEnv(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Project.hookEnv() = %v, want %v", got, want)
+	}
+}
+
+func TestHookCommands(t *testing.T) {
+	root := &Node{Hooks: map[string][]string{
+		HookPostCreate: {"git init", "python -m venv .venv"},
+	}}
+	p := Project{Config: map[string]string{HookPostCreate: "tmux new -d -s acme", HookPostOpen: " "}}
+	tests := []struct {
+		name string
+		hook string
+		root *Node
+		want []string
+	}{
+		{"template-and-config", HookPostCreate, root, []string{"git init", "python -m venv .venv", "tmux new -d -s acme"}},
+		{"config-only", HookPostCreate, nil, []string{"tmux new -d -s acme"}},
+		{"empty-config", HookPostOpen, root, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.hookCommands(tt.hook, tt.root); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Project.hookCommands() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeferHookActions(t *testing.T) {
+	tests := []struct {
+		name string
+		tmpl string
+		d    delims
+		want string
+	}{
+		{"hooks",
+			`{"path": "{{ .ProjectName }}", "hooks": {"post-create": ["echo {{ .ProjectName }}"]}, "children": []}`, delims{},
+			"{\"path\": \"{{ .ProjectName }}\", \"hooks\": {\"post-create\": [\"echo {{`{{`}} .ProjectName }}\"]}, \"children\": []}"},
+		{"braces-in-strings",
+			`{"hooks": {"post-open": ["echo \"}\" {{x}}"]}, "a": "{{x}}"}`, delims{},
+			"{\"hooks\": {\"post-open\": [\"echo \\\"}\\\" {{`{{`}}x}}\"]}, \"a\": \"{{x}}\"}"},
+		{"delims",
+			`{"hooks": {"post-open": ["docker ps --format '{{.Names}}' [[ .ProjectName ]]"]}}`, delims{"[[", "]]"},
+			"{\"hooks\": {\"post-open\": [\"docker ps --format '{{.Names}}' [[`[[`]] .ProjectName ]]\"]}}"},
+		{"no-hooks", `{"path": "{{ .ProjectName }}"}`, delims{}, `{"path": "{{ .ProjectName }}"}`},
+	}
+	for _, tt := range tests {
+		if got := deferHookActions(tt.tmpl, tt.d); got != tt.want {
+			t.Errorf("%s: deferHookActions() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRunHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks use sh in this test")
+	}
+	root := t.TempDir()
+	p := Project{
+		ProjectName: "acme",
+		ProjectRoot: root,
+		Vars:        map[string]string{"client": "$(touch pwned); Acme"},
+	}
+	out, err := p.runHooks(HookPostCreate, []string{
+		`echo "$BT_VAR_CLIENT" > client.txt`,
+		"echo {{ .ProjectName }}",
+		"exit 3",
+		"touch never",
+	})
+	if err == nil {
+		t.Fatal("runHooks() did not return an error for a failing command")
+	}
+	if !strings.Contains(out, "[post-create] echo {{ .ProjectName }}\n{{ .ProjectName }}\n") {
+		t.Errorf("runHooks() rendered the command:\n%s", out)
+	}
+	for name, want := range map[string]bool{"client.txt": true, "pwned": false, "never": false} {
+		if _, err := os.Stat(filepath.Join(root, name)); (err == nil) != want {
+			t.Errorf("%s exists = %v, want %v", name, err == nil, want)
+		}
+	}
+	content, err := os.ReadFile(filepath.Join(root, "client.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "$(touch pwned); Acme\n" {
+		t.Errorf("client.txt = %q", content)
+	}
+}
+
+func TestPostCreateFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks use sh in this test")
+	}
+	home := testHome(t)
+	tmpl := `{"path": "{{ .Workspace }}/{{ .ProjectName }}", "info": {"isdir": true},
+		"hooks": {"post-create": ["echo {{ .ProjectName }} > name.txt", "exit 1"]},
+		"children": [{"path": ".config.json", "info": {"template": "project-config"}}]}`
+	if _, err := config.AddTemplate(config.ProjectKind, "hooks", tmpl, false); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		failure  string
+		wantKept bool
+	}{
+		{"keep", "keep", true},
+		{"rollback", hookRollback, false},
+	}
+	for _, tt := range tests {
+		p := New(tt.name)
+		p.Config[keyHookFailure] = tt.failure
+		err := p.Create("hooks", false)
+		if (err == nil) != tt.wantKept {
+			t.Errorf("%s: Create() error = %v", tt.name, err)
+		}
+		root := filepath.Join(home, "ws", tt.name)
+		if _, err := os.Stat(root); (err == nil) != tt.wantKept {
+			t.Errorf("%s: project exists = %v, want %v", tt.name, err == nil, tt.wantKept)
+		}
+		r, err := RefreshRegistry()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := r.Projects[tt.name]; ok != tt.wantKept {
+			t.Errorf("%s: registered = %v, want %v", tt.name, ok, tt.wantKept)
+		}
+		if !tt.wantKept {
+			continue
+		}
+		// The hook is not rendered.
+		content, err := os.ReadFile(filepath.Join(root, "name.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != "{{ .ProjectName }}\n" {
+			t.Errorf("%s: name.txt = %q", tt.name, content)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/parsiya/borrowedtime/config"
+	"github.com/parsiya/borrowedtime/shared"
+)
+
+// Info contains the project information shown by project info.
+type Info struct {
+	Name     string
+	Root     string
+	Template string
+	Meta     Metadata
+	// Config is the project config.
+	Config map[string]string
+	// Files and Dirs are counted recursively. Size is the total size of the
+	// files.
+	Files, Dirs int
+	Size        int64
+	// LastActivity is the latest modification time of a file in the project
+	// and LastFile is that file.
+	LastActivity time.Time
+	LastFile     string
+}
+
+// Info returns the project information. Hidden directories are counted in
+// size but not in the last activity.
+func (p *Project) Info() (*Info, error) {
+	info := &Info{
+		Name:     p.ProjectName,
+		Root:     p.Root(),
+		Template: p.ProjectConfig[keyTemplate],
+		Meta:     p.Meta,
+		Config:   p.ProjectConfig,
+	}
+	root := p.Root()
+	err := filepath.Walk(root, func(pth string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if pth == root {
+			return nil
+		}
+		if fi.IsDir() {
+			info.Dirs++
+			return nil
+		}
+		info.Files++
+		info.Size += fi.Size()
+		rel, err := filepath.Rel(root, pth)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if strings.HasPrefix(rel, ".") || strings.Contains(rel, "/.") {
+			return nil
+		}
+		if fi.ModTime().After(info.LastActivity) {
+			info.LastActivity, info.LastFile = fi.ModTime(), rel
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.Info: %s", err.Error())
+	}
+	return info, nil
+}
+
+// applyConfig overrides the workspace config with the values in the project
+// config. Template variables are not copied.
+func (p *Project) applyConfig(workspace config.ConfigMap) {
+	cfg := make(config.ConfigMap, len(workspace)+len(p.ProjectConfig))
+	for k, v := range workspace {
+		cfg[k] = v
+	}
+	for k, v := range p.ProjectConfig {
+		if !strings.HasPrefix(k, varPrefix) {
+			cfg[k] = v
+		}
+	}
+	p.Config = cfg
+}
+
+// readOnlyKeys are managed by borrowed time and cannot be changed with
+// SetConfig.
+var readOnlyKeys = map[string]bool{
+	keyTemplateHash: true,
+	keyClonedFrom:   true,
+}
+
+// ConfigKeys returns the keys of the project config and the workspace config
+// that can be set, sorted.
+func (p *Project) ConfigKeys() []string {
+	seen := make(map[string]bool)
+	for _, m := range []map[string]string{p.ProjectConfig, p.Config} {
+		for k := range m {
+			seen[k] = true
+		}
+	}
+	for _, k := range []string{keyClient, keyType, keyStart, keyEnd, keyStatus, keyTags, keyTemplate} {
+		seen[k] = true
+	}
+	var keys []string
+	for k := range seen {
+		if !readOnlyKeys[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GetConfig returns the value of a key in the project config. If the key is
+// not in the project config, the workspace value is returned and project is
+// false.
+func (p *Project) GetConfig(key string) (value string, project bool, err error) {
+	key = strings.ToLower(strings.TrimSpace(key))
+	if v, ok := p.ProjectConfig[key]; ok {
+		return v, true, nil
+	}
+	if v, ok := p.Config[key]; ok {
+		return v, false, nil
+	}
+	return "", false, fmt.Errorf("project.Project.GetConfig: %s is not set", key)
+}
+
+// SetConfig validates the value and writes it to the project config.
+// Metadata is also updated in the registry.
+func (p *Project) SetConfig(key, value string) error {
+	key = strings.ToLower(strings.TrimSpace(key))
+	if err := p.validConfig(key, value); err != nil {
+		return fmt.Errorf("project.Project.SetConfig: %s", err.Error())
+	}
+	switch key {
+	case keyTags:
+		value = strings.Join(ParseTags(value), ",")
+	case keyTemplate:
+		// The template hash is not updated so sync reports the new template
+		// as changed.
+		value = shared.RemoveExtension(value)
+	}
+	p.ProjectConfig[key] = value
+	if err := p.saveConfig(key); err != nil {
+		return fmt.Errorf("project.Project.SetConfig: %s", err.Error())
+	}
+	return nil
+}
+
+// UnsetConfig removes a key from the project config. The workspace value is
+// used again.
+func (p *Project) UnsetConfig(key string) error {
+	key = strings.ToLower(strings.TrimSpace(key))
+	if readOnlyKeys[key] || key == keyTemplate {
+		return fmt.Errorf("project.Project.UnsetConfig: %s cannot be removed", key)
+	}
+	if _, ok := p.ProjectConfig[key]; !ok {
+		return fmt.Errorf("project.Project.UnsetConfig: %s is not in the project config", key)
+	}
+	delete(p.ProjectConfig, key)
+	if err := p.saveConfig(key); err != nil {
+		return fmt.Errorf("project.Project.UnsetConfig: %s", err.Error())
+	}
+	return nil
+}
+
+// validConfig returns an error if the key is read-only or the value is not
+// valid for the key.
+func (p *Project) validConfig(key, value string) error {
+	switch {
+	case key == "" || strings.ContainsAny(key, " \t\r\n"):
+		return fmt.Errorf("invalid key %q", key)
+	case readOnlyKeys[key]:
+		return fmt.Errorf("%s is read-only", key)
+	case key == keyTemplate:
+		if _, err := config.FindTemplate(value, config.ProjectKind); err != nil {
+			return err
+		}
+	case key == keyHookFailure:
+		if value != hookRollback && value != "keep" {
+			return fmt.Errorf("invalid %s %q, use keep or rollback", key, value)
+		}
+	}
+	// Metadata keys are validated together.
+	m := p.Meta
+	switch key {
+	case keyStart:
+		m.Start = value
+	case keyEnd:
+		m.End = value
+	case keyStatus:
+		m.Status = value
+	}
+	return m.Validate()
+}
+
+// saveConfig writes the project config, reloads the variables, metadata and
+// config overrides and updates the registry if the key is in it.
+func (p *Project) saveConfig(key string) error {
+	if err := p.writeConfig(); err != nil {
+		return err
+	}
+	p.Vars = make(map[string]string)
+	p.loadVars()
+	p.loadMeta()
+	workspace, err := config.Read()
+	if err != nil {
+		return err
+	}
+	p.applyConfig(workspace)
+	switch key {
+	case keyClient, keyType, keyStart, keyEnd, keyStatus, keyTags, keyTemplate:
+		return p.register()
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package project
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/parsiya/borrowedtime/config"
+)
+
+func TestApplyConfig(t *testing.T) {
+	p := Project{ProjectConfig: map[string]string{
+		"yourname": "project tester",
+		"notes":    "/ws/acme/@notes.md",
+		"var.host": "example.com",
+	}}
+	p.applyConfig(config.ConfigMap{"yourname": "workspace tester", "editor": "code"})
+	want := map[string]string{
+		"yourname": "project tester",
+		"editor":   "code",
+		"notes":    "/ws/acme/@notes.md",
+	}
+	if !reflect.DeepEqual(p.Config, want) {
+		t.Errorf("applyConfig() = %v, want %v", p.Config, want)
+	}
+}
+
+func TestValidConfig(t *testing.T) {
+	p := Project{Meta: Metadata{Start: "2026-03-02", End: "2026-03-13"}}
+	tests := []struct {
+		key, value string
+		wantErr    bool
+	}{
+		{"yourname", "tester", false},
+		{"", "x", true},
+		{"two words", "x", true},
+		{keyTemplateHash, "x", true},
+		{keyClonedFrom, "x", true},
+		{keyHookFailure, "rollback", false},
+		{keyHookFailure, "ignore", true},
+		{keyStatus, StatusReporting, false},
+		{keyStatus, "done", true},
+		{keyEnd, "2026-03-01", true},
+		{keyStart, "2026-03-10", false},
+		{keyStart, "March", true},
+	}
+	for _, tt := range tests {
+		if err := p.validConfig(tt.key, tt.value); (err != nil) != tt.wantErr {
+			t.Errorf("validConfig(%q, %q) error = %v, wantErr %v", tt.key, tt.value, err, tt.wantErr)
+		}
+	}
+}
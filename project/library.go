@@ -0,0 +1,256 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/parsiya/borrowedtime/config"
+	"github.com/parsiya/borrowedtime/shared"
+)
+
+const (
+	// libraryDirName is the directory in the data directory that contains the
+	// finding library.
+	libraryDirName = "library"
+	// keyLibrary is the front matter key of a finding added from the library.
+	keyLibrary = "library"
+	// minReplaceLength is the shortest project or client name that is replaced
+	// with an action when a finding is promoted.
+	minReplaceLength = 3
+)
+
+// libraryAction matches the actions added by libraryReplacer.
+var libraryAction = regexp.MustCompile(`\{\{.*?\}\}`)
+
+// LibraryEntry is a reusable finding in "data/library/ID.md". It has the same
+// format as a finding file without the ID and status. The content is a
+// template that is executed with the project when the entry is added to a
+// project.
+type LibraryEntry struct {
+	// ID is the path of the entry relative to the library without the
+	// extension in slash form, e.g., "web/xss-reflected".
+	ID string
+	// Finding is the parsed template. Fields might contain actions.
+	Finding *Finding
+	// Content is the template.
+	Content string
+	// path is the entry file.
+	path string
+}
+
+// Path returns the path to the entry file.
+func (e LibraryEntry) Path() string {
+	return e.path
+}
+
+// libraryDir returns the path to the library directory.
+func libraryDir() (string, error) {
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, libraryDirName), nil
+}
+
+// Library returns the library entries sorted by ID.
+func Library() ([]*LibraryEntry, error) {
+	dir, err := libraryDir()
+	if err != nil {
+		return nil, fmt.Errorf("project.Library: %s", err.Error())
+	}
+	var entries []*LibraryEntry
+	err = filepath.Walk(dir, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			// An empty library.
+			if os.IsNotExist(err) && pth == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if info.IsDir() || strings.ToLower(filepath.Ext(pth)) != ".md" {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, pth)
+		if err != nil {
+			return err
+		}
+		e, err := readLibraryEntry(pth)
+		if err != nil {
+			return fmt.Errorf("%s - %s", filepath.ToSlash(rel), err.Error())
+		}
+		e.ID = shared.RemoveExtension(filepath.ToSlash(rel))
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("project.Library: %s", err.Error())
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries, nil
+}
+
+// readLibraryEntry reads and parses an entry file.
+func readLibraryEntry(pth string) (*LibraryEntry, error) {
+	content, err := shared.ReadFileString(pth)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parseFinding(content)
+	if err != nil {
+		return nil, err
+	}
+	return &LibraryEntry{Finding: f, Content: content, path: pth}, nil
+}
+
+// FindLibraryEntry returns a library entry by ID. IDs are not case sensitive
+// and can have the ".md" extension.
+func FindLibraryEntry(id string) (*LibraryEntry, error) {
+	entries, err := Library()
+	if err != nil {
+		return nil, fmt.Errorf("project.FindLibraryEntry: %s", err.Error())
+	}
+	id = strings.TrimSuffix(filepath.ToSlash(strings.TrimSpace(id)), ".md")
+	for _, e := range entries {
+		if strings.EqualFold(e.ID, id) {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("project.FindLibraryEntry: library entry %s not found", id)
+}
+
+// SearchLibrary returns the entries that contain every term in their ID or
+// content. Terms are not case sensitive.
+func SearchLibrary(terms ...string) ([]*LibraryEntry, error) {
+	entries, err := Library()
+	if err != nil {
+		return nil, fmt.Errorf("project.SearchLibrary: %s", err.Error())
+	}
+	var found []*LibraryEntry
+	for _, e := range entries {
+		text := strings.ToLower(e.ID + "\n" + e.Content)
+		match := true
+		for _, t := range terms {
+			if !strings.Contains(text, strings.ToLower(t)) {
+				match = false
+				break
+			}
+		}
+		if match {
+			found = append(found, e)
+		}
+	}
+	return found, nil
+}
+
+// RenderLibraryEntry executes the entry with the project and returns a new
+// finding. The ID of the entry is stored in the "library" front matter key.
+func (p *Project) RenderLibraryEntry(e *LibraryEntry) (*Finding, error) {
+	if err := p.loadData(); err != nil {
+		return nil, fmt.Errorf("project.Project.RenderLibraryEntry: %s", err.Error())
+	}
+	tmplStr, d := parseDelims(e.Content)
+	tmpl, err := template.New(e.ID).Delims(d.Left, d.Right).Parse(tmplStr)
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.RenderLibraryEntry: parse %s - %s", e.ID, err.Error())
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, p); err != nil {
+		return nil, fmt.Errorf("project.Project.RenderLibraryEntry: execute %s - %s", e.ID, err.Error())
+	}
+	f, err := parseFinding(sb.String())
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.RenderLibraryEntry: %s - %s", e.ID, err.Error())
+	}
+	// Only the write-up is reused.
+	f.ID, f.Status, f.Created, f.Updated, f.Original = "", "", "", "", ""
+	f.Extra[keyLibrary] = e.ID
+	return f, nil
+}
+
+// PromoteFinding writes a finding of the project to the library. The ID
+// defaults to the library entry the finding was added from or the title. The
+// project name and client are replaced with actions. Assets, evidence, status
+// and dates are not copied. Existing entries are only replaced if overwrite is
+// set.
+func (p *Project) PromoteFinding(findingID, libID string, overwrite bool) (*LibraryEntry, error) {
+	f, err := p.Finding(findingID)
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.PromoteFinding: %s", err.Error())
+	}
+	r := p.libraryReplacer()
+	if libID == "" {
+		libID = f.Extra[keyLibrary]
+	}
+	if libID == "" {
+		// The ID does not contain the project name or client.
+		title := libraryAction.ReplaceAllString(r.Replace(f.Title), "")
+		libID = strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	}
+	libID = strings.TrimSuffix(filepath.ToSlash(strings.TrimSpace(libID)), ".md")
+	if libID == "" {
+		return nil, fmt.Errorf("project.Project.PromoteFinding: empty library ID")
+	}
+
+	dir, err := libraryDir()
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.PromoteFinding: %s", err.Error())
+	}
+	pth := filepath.Join(dir, filepath.FromSlash(libID)+".md")
+	// Entries cannot be outside the library.
+	if rel, err := filepath.Rel(dir, pth); err != nil || strings.HasPrefix(rel, "..") {
+		return nil, fmt.Errorf("project.Project.PromoteFinding: %s is not inside the library", libID)
+	}
+	exists, err := shared.PathExists(pth)
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.PromoteFinding: %s", err.Error())
+	}
+	if exists && !overwrite {
+		return nil, fmt.Errorf("project.Project.PromoteFinding: library entry %s already exists at %s", libID, pth)
+	}
+
+	entry := &Finding{
+		Title:       r.Replace(f.Title),
+		Severity:    f.Severity,
+		CVSS:        f.CVSS,
+		References:  f.References,
+		Description: r.Replace(f.Description),
+		Remediation: r.Replace(f.Remediation),
+		Notes:       r.Replace(f.Notes),
+		Extra:       make(map[string]string),
+	}
+	for k, v := range f.Extra {
+		if k != keyLibrary {
+			entry.Extra[k] = r.Replace(v)
+		}
+	}
+	content := entry.Markdown()
+	if err := os.MkdirAll(filepath.Dir(pth), os.ModePerm); err != nil {
+		return nil, fmt.Errorf("project.Project.PromoteFinding: %s", err.Error())
+	}
+	if err := shared.WriteFileString(pth, content, true); err != nil {
+		return nil, fmt.Errorf("project.Project.PromoteFinding: %s", err.Error())
+	}
+	return &LibraryEntry{ID: libID, Finding: entry, Content: content, path: pth}, nil
+}
+
+// libraryReplacer returns a replacer that escapes template actions and
+// replaces the project name and client with actions. Names are only replaced
+// as whole words and short names are not replaced because they are likely to
+// be common words.
+func (p Project) libraryReplacer() *wordReplacer {
+	var pairs [][2]string
+	for _, kv := range [][2]string{
+		{p.Meta.Client, "{{ .Meta.Client }}"},
+		{p.ProjectName, "{{ .ProjectName }}"},
+	} {
+		if len(kv[0]) >= minReplaceLength {
+			pairs = append(pairs, kv)
+		}
+	}
+	return newWordReplacer(pairs)
+}
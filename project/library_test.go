@@ -0,0 +1,94 @@
+package project
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRenderLibraryEntry(t *testing.T) {
+	p := &Project{ProjectName: "acme-web", Meta: Metadata{Client: "Acme"}}
+	e := &LibraryEntry{
+		ID: "web/xss",
+		Content: `---
+title: Reflected XSS in {{ .Meta.Client }} search
+severity: high
+cvss: CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N
+references:
+  - https://owasp.org/www-community/attacks/xss/
+---
+
+## Description
+
+{{ .ProjectName }} reflects the q parameter. Use {{"{{"}}.{{"}}"}} in payloads.
+
+## Remediation
+
+Encode the output.
+`,
+	}
+	f, err := p.RenderLibraryEntry(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Finding{
+		Title:       "Reflected XSS in Acme search",
+		Severity:    "high",
+		CVSS:        "CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N",
+		References:  []string{"https://owasp.org/www-community/attacks/xss/"},
+		Description: "acme-web reflects the q parameter. Use {{.}} in payloads.",
+		Remediation: "Encode the output.",
+		Extra:       map[string]string{"library": "web/xss"},
+	}
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("RenderLibraryEntry() = %+v, want %+v", f, want)
+	}
+
+	e.Content = "---\ntitle: {{ .Missing }\n---\n"
+	if _, err := p.RenderLibraryEntry(e); err == nil {
+		t.Error("RenderLibraryEntry() did not return an error for an invalid template")
+	}
+}
+
+func TestLibraryReplacer(t *testing.T) {
+	tests := []struct {
+		name    string
+		project Project
+		in      string
+		want    string
+	}{
+		{"names", Project{ProjectName: "acme-web", Meta: Metadata{Client: "Acme"}},
+			"Acme runs acme-web.", "{{ .Meta.Client }} runs {{ .ProjectName }}."},
+		{"actions", Project{ProjectName: "acme-web"},
+			"Payload {{7*7}}", "Payload {{`{{`}}7*7{{`}}`}}"},
+		{"short names", Project{ProjectName: "ab", Meta: Metadata{Client: "x"}},
+			"ab x", "ab x"},
+		{"inside words", Project{ProjectName: "acme", Meta: Metadata{Client: "Bank"}},
+			"Bankside acme, acmetools and the Bank's banking app",
+			"Bankside {{ .ProjectName }}, acmetools and the {{ .Meta.Client }}'s banking app"},
+		{"longer name first", Project{ProjectName: "acme", Meta: Metadata{Client: "acme corp"}},
+			"acme corp and acme", "{{ .Meta.Client }} and {{ .ProjectName }}"},
+	}
+	for _, tt := range tests {
+		if got := tt.project.libraryReplacer().Replace(tt.in); got != tt.want {
+			t.Errorf("%s: Replace(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPromotedEntryRenders(t *testing.T) {
+	// A promoted finding renders back to the original in the same project.
+	p := &Project{ProjectName: "acme-web", Meta: Metadata{Client: "Acme"}}
+	r := p.libraryReplacer()
+	entry := Finding{
+		Title:       r.Replace("Acme admin panel exposed"),
+		Severity:    "medium",
+		Description: r.Replace("acme-web serves {{ admin }}."),
+	}
+	f, err := p.RenderLibraryEntry(&LibraryEntry{ID: "admin", Content: entry.Markdown()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Title != "Acme admin panel exposed" || f.Description != "acme-web serves {{ admin }}." {
+		t.Errorf("RenderLibraryEntry() = %+v", f)
+	}
+}
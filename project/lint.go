@@ -0,0 +1,150 @@
+package project
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/parsiya/borrowedtime/config"
+)
+
+// Lint results.
+const (
+	lintError   = "error"
+	lintWarning = "warning"
+	lintWhen    = "when"
+	lintForEach = "foreach"
+)
+
+// Lint checks a project template and returns a report and the number of
+// errors. The template is generated for p and conditions are evaluated
+// against p.
+func (p *Project) Lint(templateName string) (string, int, error) {
+	if err := p.loadData(); err != nil {
+		return "", 0, fmt.Errorf("project.Project.Lint: %s", err.Error())
+	}
+	// Generate template.
+	tmpl, err := p.generateTemplate(templateName, true)
+	if err != nil {
+		return "", 0, fmt.Errorf("project.Project.Lint: %s", err.Error())
+	}
+	root, err := parseProjectTemplate(tmpl)
+	if err != nil {
+		return "", 0, fmt.Errorf("project.Project.Lint: %s", err.Error())
+	}
+	fileTmpls, err := config.FileTemplates()
+	if err != nil {
+		return "", 0, fmt.Errorf("project.Project.Lint: %s", err.Error())
+	}
+
+	var sb strings.Builder
+	errs := root.lint(*p, fileTmpls, "", &sb)
+	if errs == 0 {
+		fmt.Fprintf(&sb, "%s: no errors\n", templateName)
+	}
+	return sb.String(), errs, nil
+}
+
+// lint writes the problems and conditions of the node and its children to w.
+// name is the path of the node relative to the root. Returns the number of
+// errors.
+func (n *Node) lint(p Project, fileTmpls map[string]string, name string, w io.Writer) int {
+	errs := 0
+	report := func(kind, format string, a ...interface{}) {
+		errs += n.lintReport(kind, name, w, format, a...)
+	}
+
+	if n.FullPath == "" {
+		report(lintError, "empty path")
+	}
+	if n.Info == nil {
+		report(lintError, "missing info")
+		return errs
+	}
+	if err := n.Info.validate(); err != nil {
+		report(lintError, "%s", err.Error())
+	}
+	if n.Info.Symlink != "" && len(n.Children) > 0 {
+		report(lintError, "symlinks cannot have children")
+	}
+	for hook, cmds := range n.Hooks {
+		switch {
+		case name != "":
+			report(lintWarning, "hooks are only run on the root node")
+		case !validHook(hook):
+			report(lintError, "unknown hook %s, use %s", hook, strings.Join(hookNames, " or "))
+		default:
+			for _, c := range cmds {
+				if p.delims.hasAction(c) {
+					report(lintWarning, "%s hook %q is not rendered, use the BT_* environment variables", hook, c)
+				}
+			}
+		}
+	}
+	if n.When != "" {
+		if ok, err := evalCondition(p, n.When); err != nil {
+			report(lintError, "%s", err.Error())
+		} else {
+			report(lintWhen, "%s = %t", n.When, ok)
+		}
+	}
+	if n.Info.Source != "" {
+		if src, err := n.sourcePath(p); err != nil {
+			report(lintError, "%s", err.Error())
+		} else if info, err := os.Stat(src); err != nil {
+			report(lintError, "source %s - %s", n.Info.Source, err.Error())
+		} else if info.IsDir() != n.Info.IsDir {
+			report(lintError, "isdir does not match source %s", n.Info.Source)
+		}
+	}
+	if n.Info.IsDir && n.Info.Template != "" {
+		report(lintWarning, "template %s is ignored for directories", n.Info.Template)
+	}
+	if !n.Info.IsDir {
+		if len(n.Children) > 0 {
+			report(lintError, "files cannot have children")
+		}
+		if n.Info.Source != "" && n.Info.Template != "" {
+			report(lintWarning, "template %s is ignored, source is set", n.Info.Template)
+		} else if n.Info.Template != "" && fileTmpls[n.Info.Template] == "" {
+			report(lintWarning, "file template %s not found, file will be empty", n.Info.Template)
+		}
+	}
+
+	for _, child := range n.Children {
+		childName := child.FullPath
+		if name != "" {
+			childName = path.Join(name, child.FullPath)
+		}
+		childProject := p
+		if child.ForEach != "" {
+			items, err := evalList(p, child.ForEach)
+			if err != nil {
+				errs += child.lintReport(lintError, childName, w, "%s", err.Error())
+				continue
+			}
+			child.lintReport(lintForEach, childName, w, "%s = %d item(s)", child.ForEach, len(items))
+			// Lint the subtree once with the first item.
+			if len(items) > 0 {
+				childProject.Item = items[0]
+			}
+		}
+		errs += child.lint(childProject, fileTmpls, childName, w)
+	}
+	return errs
+}
+
+// lintReport writes one line of the lint report for the node to w. Returns 1
+// for errors so it can be added to the error count.
+func (n *Node) lintReport(kind, name string, w io.Writer, format string, a ...interface{}) int {
+	if name == "" {
+		name = "/"
+	}
+	fmt.Fprintf(w, "%-10s %s: %s\n", "["+kind+"]", name, fmt.Sprintf(format, a...))
+	if kind == lintError {
+		return 1
+	}
+	return 0
+}
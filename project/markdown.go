@@ -0,0 +1,270 @@
+package project
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Reports convert the markdown in findings to other formats. Only the basic
+// syntax used in findings is supported: headings, paragraphs, lists, fenced
+// code blocks, code, bold, italic, links and images.
+
+// Markdown block kinds.
+const (
+	mdParagraph = iota
+	mdHeading
+	mdList
+	mdOrderedList
+	mdCode
+)
+
+// mdBlock is one block of markdown. Items contains the items of lists, the
+// lines of code blocks and the text of other blocks.
+type mdBlock struct {
+	Kind  int
+	Level int
+	Items []string
+}
+
+// mdSpan is a piece of inline text with the same formatting. Link is the
+// target of links and Image is the path of images where Text is the alt text.
+type mdSpan struct {
+	Text   string
+	Bold   bool
+	Italic bool
+	Code   bool
+	Link   string
+	Image  string
+}
+
+var (
+	mdHeadingRE = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdBulletRE  = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	mdNumberRE  = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	mdLinkRE    = regexp.MustCompile(`^(!?)\[([^\]]*)\]\(([^)\s]*)(?:\s+"[^"]*")?\)`)
+	// mdImageRE matches images in markdown to rewrite their paths.
+	mdImageRE = regexp.MustCompile(`(!\[[^\]]*\]\()([^)\s]+)((?:\s+"[^"]*")?\))`)
+)
+
+// parseMarkdown splits markdown into blocks.
+func parseMarkdown(s string) []mdBlock {
+	var blocks []mdBlock
+	lines := strings.Split(strings.Replace(s, "\r\n", "\n", -1), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "```"):
+			b := mdBlock{Kind: mdCode}
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				b.Items = append(b.Items, lines[i])
+			}
+			blocks = append(blocks, b)
+		case mdHeadingRE.MatchString(trimmed):
+			m := mdHeadingRE.FindStringSubmatch(trimmed)
+			blocks = append(blocks, mdBlock{Kind: mdHeading, Level: len(m[1]), Items: []string{m[2]}})
+		case mdBulletRE.MatchString(line), mdNumberRE.MatchString(line):
+			kind, re := mdList, mdBulletRE
+			if !mdBulletRE.MatchString(line) {
+				kind, re = mdOrderedList, mdNumberRE
+			}
+			b := mdBlock{Kind: kind}
+			for ; i < len(lines); i++ {
+				if m := re.FindStringSubmatch(lines[i]); m != nil {
+					b.Items = append(b.Items, m[1])
+					continue
+				}
+				// Indented lines continue the previous item.
+				next := strings.TrimSpace(lines[i])
+				if next == "" || !strings.HasPrefix(lines[i], " ") {
+					break
+				}
+				b.Items[len(b.Items)-1] += " " + next
+			}
+			i--
+			blocks = append(blocks, b)
+		default:
+			var text []string
+			for ; i < len(lines); i++ {
+				next := strings.TrimSpace(lines[i])
+				if next == "" || strings.HasPrefix(next, "```") || mdHeadingRE.MatchString(next) ||
+					mdBulletRE.MatchString(lines[i]) || mdNumberRE.MatchString(lines[i]) {
+					break
+				}
+				text = append(text, next)
+			}
+			i--
+			blocks = append(blocks, mdBlock{Kind: mdParagraph, Items: []string{strings.Join(text, " ")}})
+		}
+	}
+	return blocks
+}
+
+// parseInline splits a line of markdown into spans. Formatting is not nested.
+func parseInline(s string) []mdSpan {
+	var spans []mdSpan
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			spans = append(spans, mdSpan{Text: text.String()})
+			text.Reset()
+		}
+	}
+	for i := 0; i < len(s); {
+		rest := s[i:]
+		if m := mdLinkRE.FindStringSubmatch(rest); m != nil {
+			flush()
+			if m[1] == "!" {
+				spans = append(spans, mdSpan{Text: m[2], Image: m[3]})
+			} else {
+				spans = append(spans, mdSpan{Text: m[2], Link: m[3]})
+			}
+			i += len(m[0])
+			continue
+		}
+		matched := false
+		for _, d := range []struct {
+			delim string
+			span  mdSpan
+		}{
+			{"`", mdSpan{Code: true}},
+			{"**", mdSpan{Bold: true}},
+			{"__", mdSpan{Bold: true}},
+			{"*", mdSpan{Italic: true}},
+		} {
+			if !strings.HasPrefix(rest, d.delim) {
+				continue
+			}
+			end := strings.Index(rest[len(d.delim):], d.delim)
+			if end <= 0 {
+				continue
+			}
+			flush()
+			d.span.Text = rest[len(d.delim) : len(d.delim)+end]
+			spans = append(spans, d.span)
+			i += 2*len(d.delim) + end
+			matched = true
+			break
+		}
+		if !matched {
+			text.WriteByte(s[i])
+			i++
+		}
+	}
+	flush()
+	return spans
+}
+
+// markdownToHTML converts markdown to HTML. Headings are moved down by shift
+// levels so they are under the heading of the finding. image returns the src
+// of an image path.
+func markdownToHTML(s string, shift int, image func(string) (string, error)) (string, error) {
+	var sb strings.Builder
+	for _, b := range parseMarkdown(s) {
+		switch b.Kind {
+		case mdHeading:
+			level := b.Level + shift
+			if level > 6 {
+				level = 6
+			}
+			text, err := inlineHTML(b.Items[0], image)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&sb, "<h%d>%s</h%d>\n", level, text, level)
+		case mdCode:
+			fmt.Fprintf(&sb, "<pre><code>%s</code></pre>\n", html.EscapeString(strings.Join(b.Items, "\n")))
+		case mdList, mdOrderedList:
+			tag := "ul"
+			if b.Kind == mdOrderedList {
+				tag = "ol"
+			}
+			sb.WriteString("<" + tag + ">\n")
+			for _, item := range b.Items {
+				text, err := inlineHTML(item, image)
+				if err != nil {
+					return "", err
+				}
+				sb.WriteString("<li>" + text + "</li>\n")
+			}
+			sb.WriteString("</" + tag + ">\n")
+		default:
+			text, err := inlineHTML(b.Items[0], image)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString("<p>" + text + "</p>\n")
+		}
+	}
+	return sb.String(), nil
+}
+
+// inlineHTML converts a line of markdown to HTML.
+func inlineHTML(s string, image func(string) (string, error)) (string, error) {
+	var sb strings.Builder
+	for _, sp := range parseInline(s) {
+		text := html.EscapeString(sp.Text)
+		switch {
+		case sp.Image != "":
+			src, err := image(sp.Image)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&sb, `<img src="%s" alt="%s">`, html.EscapeString(src), text)
+		case sp.Link != "":
+			fmt.Fprintf(&sb, `<a href="%s">%s</a>`, html.EscapeString(safeURL(sp.Link)), text)
+		case sp.Code:
+			sb.WriteString("<code>" + text + "</code>")
+		case sp.Bold:
+			sb.WriteString("<strong>" + text + "</strong>")
+		case sp.Italic:
+			sb.WriteString("<em>" + text + "</em>")
+		default:
+			sb.WriteString(text)
+		}
+	}
+	return sb.String(), nil
+}
+
+// unsafeURL replaces URLs with schemes that are not allowed in HTML reports.
+// It's the same value html/template uses.
+const unsafeURL = "#ZgotmplZ"
+
+// safeSchemes are the URL schemes allowed in HTML reports.
+var safeSchemes = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+}
+
+// safeURL returns u if it's relative, a fragment or uses one of safeSchemes.
+// Other URLs (e.g., "javascript:") are replaced with unsafeURL.
+func safeURL(u string) string {
+	parsed, err := url.Parse(strings.TrimSpace(u))
+	if err != nil {
+		return unsafeURL
+	}
+	if parsed.Scheme != "" && !safeSchemes[parsed.Scheme] {
+		return unsafeURL
+	}
+	return u
+}
+
+// rewriteImages replaces the paths of images in markdown.
+func rewriteImages(s string, image func(string) (string, error)) (string, error) {
+	var err error
+	out := mdImageRE.ReplaceAllStringFunc(s, func(m string) string {
+		parts := mdImageRE.FindStringSubmatch(m)
+		src, e := image(parts[2])
+		if e != nil {
+			err = e
+			return m
+		}
+		return parts[1] + src + parts[3]
+	})
+	return out, err
+}
@@ -0,0 +1,93 @@
+package project
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseInline(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []mdSpan
+	}{
+		{"plain", []mdSpan{{Text: "plain"}}},
+		{"a **b** *c* `d`", []mdSpan{{Text: "a "}, {Text: "b", Bold: true}, {Text: " "},
+			{Text: "c", Italic: true}, {Text: " "}, {Text: "d", Code: true}}},
+		{"see [docs](https://x.y) ![shot](@pix/a.png)", []mdSpan{{Text: "see "},
+			{Text: "docs", Link: "https://x.y"}, {Text: " "}, {Text: "shot", Image: "@pix/a.png"}}},
+		{"2 * 3 = 6", []mdSpan{{Text: "2 * 3 = 6"}}},
+		{"`a*b*c`", []mdSpan{{Text: "a*b*c", Code: true}}},
+	}
+	for _, tt := range tests {
+		if got := parseInline(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseInline(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMarkdownToHTML(t *testing.T) {
+	image := func(pth string) (string, error) { return "img:" + pth, nil }
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"paragraphs", "one\ntwo\n\nthree", "<p>one two</p>\n<p>three</p>\n"},
+		{"heading", "## Steps", "<h5>Steps</h5>\n"},
+		{"heading-max", "#### Deep", "<h6>Deep</h6>\n"},
+		{"list", "- a\n- b\n  c\n\n1. x\n2. y",
+			"<ul>\n<li>a</li>\n<li>b c</li>\n</ul>\n<ol>\n<li>x</li>\n<li>y</li>\n</ol>\n"},
+		{"code", "```http\nGET /?q=<script> HTTP/1.1\n```", "<pre><code>GET /?q=&lt;script&gt; HTTP/1.1</code></pre>\n"},
+		{"escape", "<b>&</b>", "<p>&lt;b&gt;&amp;&lt;/b&gt;</p>\n"},
+		{"image", "![a \"b\"](@pix/x.png)", "<p><img src=\"img:@pix/x.png\" alt=\"a &#34;b&#34;\"></p>\n"},
+		{"link", "[docs](https://example.com/?a=1&b=2)", "<p><a href=\"https://example.com/?a=1&amp;b=2\">docs</a></p>\n"},
+		{"link-unsafe", "[x](javascript:alert`1`)", "<p><a href=\"#ZgotmplZ\">x</a></p>\n"},
+	}
+	for _, tt := range tests {
+		got, err := markdownToHTML(tt.in, 3, image)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: markdownToHTML() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSafeURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		safe bool
+	}{
+		{"https://example.com/a?b=c", true},
+		{"HTTP://example.com", true},
+		{"mailto:security@example.com", true},
+		{"#finding-1", true},
+		{"@pix/x.png", true},
+		{"../notes.md", true},
+		{"javascript:alert(1)", false},
+		{"JavaScript:alert(1)", false},
+		{" javascript:alert(1)", false},
+		{"java\tscript:alert(1)", false},
+		{"javascript://%0aalert(1)", false},
+		{"data:text/html;base64,PHNjcmlwdD4=", false},
+		{"vbscript:msgbox", false},
+	}
+	for _, tt := range tests {
+		got := safeURL(tt.in)
+		if safe := got == tt.in; safe != tt.safe || (!safe && got != unsafeURL) {
+			t.Errorf("safeURL(%q) = %q, want safe = %v", tt.in, got, tt.safe)
+		}
+	}
+}
+
+func TestRewriteImages(t *testing.T) {
+	image := func(pth string) (string, error) { return "../" + pth, nil }
+	got, err := rewriteImages("a ![x](@pix/a.png) [link](b) ![y](@pix/b.png \"title\")", image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a ![x](../@pix/a.png) [link](b) ![y](../@pix/b.png \"title\")"; got != want {
+		t.Errorf("rewriteImages() = %q, want %q", got, want)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 
 	"github.com/parsiya/borrowedtime/shared"
 )
@@ -13,19 +14,93 @@ type FileInfo struct {
 	Name     string `json:"name"`
 	IsDir    bool   `json:"isdir"`
 	Template string `json:"template"` // File template.
+	// Source is a file or directory in the data directory that is copied
+	// verbatim. Template is ignored if Source is set.
+	Source string `json:"source,omitempty"`
+	// Render runs text files from Source through the template engine.
+	Render bool `json:"render,omitempty"`
+	// Mode is an optional octal permission such as "0600" for files or "0700"
+	// for directories.
+	Mode string `json:"mode,omitempty"`
+	// Symlink creates a symbolic link to this target instead of a file or
+	// directory. IsDir should match the target.
+	Symlink string `json:"symlink,omitempty"`
+}
+
+// validate checks the node's info for invalid combinations.
+func (fi *FileInfo) validate() error {
+	if _, err := fi.perm(); err != nil {
+		return err
+	}
+	if fi.Symlink != "" && (fi.Template != "" || fi.Source != "") {
+		return fmt.Errorf("symlink cannot have a template or source")
+	}
+	if fi.Symlink != "" && fi.Mode != "" {
+		return fmt.Errorf("symlink cannot have a mode")
+	}
+	return nil
+}
+
+// perm returns the permission in Mode. It returns 0 if Mode is not set.
+func (fi *FileInfo) perm() (os.FileMode, error) {
+	if fi.Mode == "" {
+		return 0, nil
+	}
+	mode, err := strconv.ParseUint(fi.Mode, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("invalid mode %q, use an octal permission such as 0600", fi.Mode)
+	}
+	return os.FileMode(mode), nil
 }
 
 // Node represents a node in a directory tree.
 type Node struct {
 	FullPath string    `json:"path"`
 	Info     *FileInfo `json:"info"`
-	Children []*Node   `json:"children"`
+	// When is an optional template expression (e.g., eq .Vars.platform "mobile").
+	// The node and its children are only created if it's true.
+	When string `json:"when,omitempty"`
+	// ForEach is an optional template expression that returns a list (e.g.,
+	// .Vars.hosts). The node and its children are created once per item. The
+	// item is available in templates as .Item and its index as .Index.
+	ForEach string `json:"foreach,omitempty"`
+	// Hooks contains shell commands per hook (e.g., post-create). Only hooks of
+	// the root node are run.
+	Hooks    map[string][]string `json:"hooks,omitempty"`
+	Children []*Node             `json:"children"`
+}
+
+// exists returns true if the node's path exists. Symbolic links are not
+// followed so broken links also exist.
+func (n *Node) exists() (bool, error) {
+	if n.Info.Symlink == "" {
+		return shared.PathExists(n.FullPath)
+	}
+	if _, err := os.Lstat(n.FullPath); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return true, err
+	}
+	return true, nil
 }
 
 // Create creates the file or directory represented by the node and its children.
 func (n *Node) Create(p Project, overwrite bool) error {
 
-	exists, err := shared.PathExists(n.FullPath)
+	// Skip the node and its children if the condition is false.
+	ok, err := evalCondition(p, n.When)
+	if err != nil {
+		return fmt.Errorf("project.Node.Create: %s", err.Error())
+	}
+	if !ok {
+		return nil
+	}
+
+	if err := n.Info.validate(); err != nil {
+		return fmt.Errorf("project.Node.Create: %s - %s", n.FullPath, err.Error())
+	}
+	exists, err := n.exists()
 	// Check if we have access to the path.
 	if err != nil {
 		return fmt.Errorf("project.Node.Create: %s", err.Error())
@@ -36,14 +111,37 @@ func (n *Node) Create(p Project, overwrite bool) error {
 		// of the project.
 		return fmt.Errorf("project.Node.Create: %s already exists, use overwrite", n.FullPath)
 	}
+	// Create the symbolic link instead of the file or directory. Links do not
+	// have children.
+	if n.Info.Symlink != "" {
+		if exists {
+			if err := os.Remove(n.FullPath); err != nil {
+				return fmt.Errorf("project.Node.Create: %s", err.Error())
+			}
+		}
+		target, err := renderString(p, n.Info.Symlink)
+		if err != nil {
+			return fmt.Errorf("project.Node.Create: %s", err.Error())
+		}
+		if err := os.Symlink(filepath.FromSlash(target), n.FullPath); err != nil {
+			return fmt.Errorf("project.Node.Create: %s", err.Error())
+		}
+		return nil
+	}
 	// If node is a directory, create the directory. No need to create if it already exists.
 	if n.Info.IsDir && !exists {
 		if err := os.MkdirAll(n.FullPath, os.ModePerm); err != nil {
 			return err
 		}
 	}
+	// Copy the source from the data directory.
+	if n.Info.Source != "" {
+		if err := n.copySource(p); err != nil {
+			return fmt.Errorf("project.Node.Create: %s", err.Error())
+		}
+	}
 	// If file, create the file.
-	if !n.Info.IsDir {
+	if !n.Info.IsDir && n.Info.Source == "" {
 		// Node is a file, create the file.
 		f, err := os.Create(n.FullPath)
 		if err != nil {
@@ -67,15 +165,25 @@ func (n *Node) Create(p Project, overwrite bool) error {
 	for _, child := range n.Children {
 		// Calculate and populate FullPath based on the parent. Everything but
 		// root should have a parent and if we are here, then we are not
-		// populating root.
-		// fmt.Printf("processing child.FullPath: %s\n", child.FullPath)
-		// fmt.Printf("Parent's FullPath is %v\n", n.FullPath)
-		// fmt.Printf("joined: %s\n", filepath.Join(n.FullPath, child.FullPath))
+		// populating root. Children with foreach are expanded here.
 		// This is a good place to place logging statements.
 		// E.g., creating blahblah/whatever.txt using X template.
-		child.FullPath = filepath.Join(n.FullPath, child.FullPath)
-		if err := child.Create(p, overwrite); err != nil {
-			return err
+		insts, err := n.instances(child, p)
+		if err != nil {
+			return fmt.Errorf("project.Node.Create: %s", err.Error())
+		}
+		for _, inst := range insts {
+			if err := inst.node.Create(inst.p, overwrite); err != nil {
+				return err
+			}
+		}
+	}
+	// Set the permission after the content and children are created, source
+	// files keep their own mode and read-only directories cannot be filled
+	// otherwise.
+	if perm, _ := n.Info.perm(); perm != 0 {
+		if err := os.Chmod(n.FullPath, perm); err != nil {
+			return fmt.Errorf("project.Node.Create: %s", err.Error())
 		}
 	}
 	return nil
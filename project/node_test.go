@@ -0,0 +1,99 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestFileInfoPerm(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		want    os.FileMode
+		wantErr bool
+	}{
+		{"empty", "", 0, false},
+		{"file", "0600", 0600, false},
+		{"dir", "0700", 0700, false},
+		{"no-leading-zero", "755", 0755, false},
+		{"too-large", "1777", 0, true},
+		{"not-octal", "999", 0, true},
+		{"not-a-number", "rwx", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fi := &FileInfo{Mode: tt.mode}
+			got, err := fi.perm()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FileInfo.perm() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("FileInfo.perm() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileInfoValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		info    FileInfo
+		wantErr bool
+	}{
+		{"file", FileInfo{Template: "notes", Mode: "0600"}, false},
+		{"symlink", FileInfo{IsDir: true, Symlink: "../wordlists"}, false},
+		{"symlink-template", FileInfo{Symlink: "../notes.md", Template: "notes"}, true},
+		{"symlink-source", FileInfo{Symlink: "../logo.png", Source: "logo.png"}, true},
+		{"symlink-mode", FileInfo{Symlink: "../notes.md", Mode: "0600"}, true},
+		{"invalid-mode", FileInfo{Mode: "0800"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.info.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("FileInfo.validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNodeCreateMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not supported on Windows")
+	}
+	root := filepath.Join(t.TempDir(), "acme")
+	tmpl := `{
+		"path": "` + filepath.ToSlash(root) + `",
+		"info": {"isdir": true},
+		"children": [
+			{"path": "readonly", "info": {"isdir": true, "mode": "0555"}, "children": [
+				{"path": "notes.md", "info": {"isdir": false}},
+				{"path": "sub", "info": {"isdir": true}}
+			]},
+			{"path": "@creds.md", "info": {"isdir": false, "mode": "0600"}}
+		]
+	}`
+	readonly := filepath.Join(root, "readonly")
+	// TempDir cannot remove the children of a read-only directory.
+	t.Cleanup(func() { os.Chmod(readonly, 0755) })
+	if err := execProjectTemplate(Project{}, tmpl, false); err != nil {
+		t.Fatal(err)
+	}
+	for pth, want := range map[string]os.FileMode{
+		readonly:                            0555,
+		filepath.Join(root, "@creds.md"):    0600,
+		filepath.Join(readonly, "notes.md"): 0,
+		filepath.Join(readonly, "sub"):      0,
+	} {
+		info, err := os.Stat(pth)
+		if err != nil {
+			t.Errorf("Create() did not create %s - %v", pth, err)
+			continue
+		}
+		if want != 0 && info.Mode().Perm() != want {
+			t.Errorf("%s mode = %o, want %o", pth, info.Mode().Perm(), want)
+		}
+	}
+}
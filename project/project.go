@@ -23,6 +23,20 @@ type Project struct {
 	Config map[string]string `json:"config"`
 	// ProjectConfig contains project specific configuration.
 	ProjectConfig map[string]string `json:"projectconfig"`
+	// Vars contains template variables passed when the project is created.
+	Vars map[string]string `json:"vars"`
+	// Data contains the files in the data directory. JSON files are decoded and
+	// text files are split into lines.
+	Data map[string]interface{} `json:"data"`
+	// Item is the current item when a node with foreach is created.
+	Item interface{} `json:"item"`
+	// Index is the index of Item in the foreach list.
+	Index int `json:"index"`
+	// Meta contains the client, dates, status and tags of the engagement.
+	Meta Metadata `json:"meta"`
+	// delims are the delimiters of the project template. They are used to
+	// render paths and hooks after the template is generated.
+	delims delims
 }
 
 // New creates a new project.
@@ -33,6 +47,7 @@ func New(name string) *Project {
 		Workspace:   shared.EscapeString(cfg.Key("workspace")),
 		ProjectRoot: shared.EscapeString(filepath.Join(cfg.Key("workspace"), name)),
 		Config:      cfg,
+		Vars:        make(map[string]string),
 	}
 }
 
@@ -41,49 +56,107 @@ func (p *Project) Create(templateName string, overwrite bool) error {
 	if p.ProjectName == "" || p.Workspace == "" {
 		return fmt.Errorf("project.Project.Create: empty project")
 	}
+	if p.Meta.Status == "" {
+		p.Meta.Status = StatusActive
+	}
+	if err := p.Meta.Validate(); err != nil {
+		return fmt.Errorf("project.Project.Create: %s", err.Error())
+	}
+	if err := p.loadData(); err != nil {
+		return fmt.Errorf("project.Project.Create: %s", err.Error())
+	}
 	// Generate template.
 	tmpl, err := p.generateTemplate(templateName, true)
 	if err != nil {
 		return fmt.Errorf("project.Project.Create: %s", err.Error())
 	}
+	root, err := parseProjectTemplate(tmpl)
+	if err != nil {
+		return fmt.Errorf("project.Project.Create: %s", err.Error())
+	}
+	// Only new projects are removed when a post-create hook fails.
+	existed, err := shared.PathExists(p.ProjectRoot)
+	if err != nil {
+		return fmt.Errorf("project.Project.Create: %s", err.Error())
+	}
 	// Execute template.
 	err = p.executeTemplate(tmpl, overwrite)
 	if err != nil {
 		return fmt.Errorf("project.Project.Create: %s", err.Error())
 	}
+	// Hash the created files so sync can detect files edited by the user.
+	if err := p.recordHashes(tmpl); err != nil {
+		return fmt.Errorf("project.Project.Create: %s", err.Error())
+	}
 	// Update project with newly created project config.
 	// Project config is at "projectRoot/.config.json".
-	configPath := filepath.Join(p.ProjectRoot, ".config.json")
-	cfgBytes, err := shared.ReadFileByte(configPath)
+	if err := p.readConfig(); err != nil {
+		// If there is no project config in the template or the project config
+		// file is empty (no template or wrong template), this will return an
+		// error. Print an error and run the hooks instead.
+		fmt.Printf("project.Project.Create: %s\n", err.Error())
+		return p.postCreate(root, existed)
+	}
+	// Record the template and variables so the project can be synced later.
+	// The project is already on disk, a missing hash only makes sync report
+	// the template as changed.
+	hash, err := templateHash(templateName)
 	if err != nil {
-		// If there is no project config in the template, this will return an
-		// error. Print an error and return instead.
-		fmt.Printf("project.Project.Create: read project config - %s", err.Error())
-		return nil
-		// return fmt.Errorf("project.Project.Create: read project config - %s", err.Error())
+		fmt.Printf("project.Project.Create: %s\n", err.Error())
+	}
+	p.ProjectConfig[keyTemplate] = shared.RemoveExtension(templateName)
+	p.ProjectConfig[keyTemplateHash] = hash
+	p.setVars()
+	p.setMeta()
+	if err := p.writeConfig(); err != nil {
+		return fmt.Errorf("project.Project.Create: %s", err.Error())
+	}
+	if err := p.register(); err != nil {
+		return fmt.Errorf("project.Project.Create: %s", err.Error())
+	}
+	return p.postCreate(root, existed)
+}
+
+// Load reads an existing project in the workspace and its project config.
+// Template variables are read from the project config.
+func Load(name string) (*Project, error) {
+	p := New(name)
+	if p.ProjectName == "" || p.Workspace == "" {
+		return nil, fmt.Errorf("project.Load: empty project")
 	}
-	err = json.Unmarshal(cfgBytes, &p.ProjectConfig)
+	exists, err := shared.PathExists(p.ProjectRoot)
 	if err != nil {
-		// If the project config file is empty (no template or wrong template)
-		// then we will get an error here that we can print and return.
-		fmt.Printf("project.Project.Create: unmarshal project config - %s", err.Error())
-		return nil
-		// return fmt.Errorf("project.Project.Create: unmarshal project config - %s", err.Error())
+		return nil, fmt.Errorf("project.Load: %s", err.Error())
 	}
-	return nil
+	if !exists {
+		return nil, fmt.Errorf("project.Load: project %s not found in the workspace", name)
+	}
+	if err := p.readConfig(); err != nil {
+		return nil, fmt.Errorf("project.Load: %s", err.Error())
+	}
+	p.loadVars()
+	p.loadMeta()
+	// Project values override the workspace config.
+	p.applyConfig(p.Config)
+	return p, nil
 }
 
 // generateTemplate creates a template using the provided template string and
 // project info. If isProject is set to true then we are generating a project,
-// otherwise we are generating a file.
-func (p Project) generateTemplate(templateName string, isProject bool) (string, error) {
-	return genTemplate(p, templateName, isProject)
+// otherwise we are generating a file. The delimiters of a project template are
+// stored in the project.
+func (p *Project) generateTemplate(templateName string, isProject bool) (string, error) {
+	tmpl, d, err := genTemplate(*p, templateName, isProject)
+	if err == nil && isProject {
+		p.delims = d
+	}
+	return tmpl, err
 }
 
 // genTemplate creates a template using the provided template string and project
 // info. If isProject is set to true then we are generating a project, otherwise
-// we are generating a file
-func genTemplate(p Project, tmplName string, isProject bool) (string, error) {
+// we are generating a file. Returns the delimiters of the template.
+func genTemplate(p Project, tmplName string, isProject bool) (string, delims, error) {
 
 	pth := ""
 	// Remove extension from tmplName if any.
@@ -93,7 +166,7 @@ func genTemplate(p Project, tmplName string, isProject bool) (string, error) {
 		// Get project templates.
 		prjTmpls, err := config.ProjectTemplates()
 		if err != nil {
-			return "", err
+			return "", delims{}, err
 		}
 		// Get project template path if it exists.
 		pth = prjTmpls[tmplName]
@@ -101,33 +174,40 @@ func genTemplate(p Project, tmplName string, isProject bool) (string, error) {
 		// Get file templates.
 		fileTmpls, err := config.FileTemplates()
 		if err != nil {
-			return "", err
+			return "", delims{}, err
 		}
 		pth = fileTmpls[tmplName]
 	}
 
 	// If template is not found.
 	if pth == "" {
-		return "", fmt.Errorf("project.genTemplate: template %s not found", tmplName)
+		return "", delims{}, fmt.Errorf("project.genTemplate: template %s not found", tmplName)
 	}
 
 	// Read file, apparently ParseFiles does not work.
 	tmplStr, err := shared.ReadFileString(pth)
 	if err != nil {
-		return "", err
+		return "", delims{}, err
+	}
+	// Templates with literal {{ }} can change the delimiters in a header.
+	tmplStr, d := parseDelims(tmplStr)
+	// Actions that use the foreach item are rendered when the node is created.
+	// Hooks are never rendered.
+	if isProject {
+		tmplStr = deferItemActions(deferHookActions(tmplStr, d), d)
 	}
 	// Read the template and execute it.
-	tmpl, err := template.New(tmplName).Parse(tmplStr)
+	tmpl, err := template.New(tmplName).Delims(d.Left, d.Right).Parse(tmplStr)
 	if err != nil {
-		return "", fmt.Errorf("project.genTemplate: create new template - %s", err.Error())
+		return "", delims{}, fmt.Errorf("project.genTemplate: create new template - %s", err.Error())
 	}
 
 	var tplResult strings.Builder
 	// fmt.Println("-----------------")
 	if err := tmpl.Execute(&tplResult, p); err != nil {
-		return "", fmt.Errorf("project.genTemplate: execute template - %s", err.Error())
+		return "", delims{}, fmt.Errorf("project.genTemplate: execute template - %s", err.Error())
 	}
-	return tplResult.String(), nil
+	return tplResult.String(), d, nil
 }
 
 // executeTemplate creates the directory structure according to a generated template.
@@ -137,10 +217,9 @@ func (p Project) executeTemplate(tmpl string, overwrite bool) error {
 
 // execProjectTemplate creates the directory structure according to a generated template.
 func execProjectTemplate(p Project, tmpl string, overwrite bool) error {
-	root := &Node{}
-	// Unmarshal template.
-	if err := json.Unmarshal([]byte(tmpl), root); err != nil {
-		return fmt.Errorf("project.executeTemplate: unmarshal template - %s", err.Error())
+	root, err := parseProjectTemplate(tmpl)
+	if err != nil {
+		return fmt.Errorf("project.executeTemplate: %s", err.Error())
 	}
 	// Create directory structure.
 	if err := root.Create(p, overwrite); err != nil {
@@ -148,3 +227,13 @@ func execProjectTemplate(p Project, tmpl string, overwrite bool) error {
 	}
 	return nil
 }
+
+// parseProjectTemplate unmarshals a generated project template.
+func parseProjectTemplate(tmpl string) (*Node, error) {
+	root := &Node{}
+	// Unmarshal template.
+	if err := json.Unmarshal([]byte(tmpl), root); err != nil {
+		return nil, fmt.Errorf("unmarshal template - %s", err.Error())
+	}
+	return root, nil
+}
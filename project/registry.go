@@ -0,0 +1,379 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/parsiya/borrowedtime/config"
+	"github.com/parsiya/borrowedtime/shared"
+)
+
+// registryFilename is the central project index in the config directory.
+const registryFilename = "projects.json"
+
+// Metadata keys in the project config.
+const (
+	keyClient = "client"
+	keyType   = "type"
+	keyStart  = "start"
+	keyEnd    = "end"
+	keyStatus = "status"
+	// keyTags is a comma separated list of tags.
+	keyTags = "tags"
+)
+
+// Project statuses.
+const (
+	StatusPlanned   = "planned"
+	StatusActive    = "active"
+	StatusReporting = "reporting"
+	StatusClosed    = "closed"
+)
+
+// Statuses contains the valid project statuses in order.
+var Statuses = []string{StatusPlanned, StatusActive, StatusReporting, StatusClosed}
+
+// dateFormat is the format of start and end dates.
+const dateFormat = "2006-01-02"
+
+// Metadata describes an engagement. It's stored in the project config and the
+// registry and is available in templates as .Meta.
+type Metadata struct {
+	Client string   `json:"client,omitempty"`
+	Type   string   `json:"type,omitempty"`
+	Start  string   `json:"start,omitempty"`
+	End    string   `json:"end,omitempty"`
+	Status string   `json:"status,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+// Validate checks the status and dates.
+func (m Metadata) Validate() error {
+	if m.Status != "" && !validStatus(m.Status) {
+		return fmt.Errorf("invalid status %q, use one of %s", m.Status, strings.Join(Statuses, ", "))
+	}
+	for _, d := range []string{m.Start, m.End} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse(dateFormat, d); err != nil {
+			return fmt.Errorf("invalid date %q, use YYYY-MM-DD", d)
+		}
+	}
+	// Dates in this format can be compared as strings.
+	if m.Start != "" && m.End != "" && m.End < m.Start {
+		return fmt.Errorf("end date %s is before start date %s", m.End, m.Start)
+	}
+	return nil
+}
+
+// HasTag returns true if the project has the tag. Tags are not case-sensitive.
+func (m Metadata) HasTag(tag string) bool {
+	for _, t := range m.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// validStatus returns true if s is a valid project status.
+func validStatus(s string) bool {
+	for _, st := range Statuses {
+		if st == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTags splits a comma separated list of tags and removes empty and
+// duplicate tags.
+func ParseTags(values ...string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, v := range values {
+		for _, t := range strings.Split(v, ",") {
+			t = strings.TrimSpace(t)
+			if t == "" || seen[strings.ToLower(t)] {
+				continue
+			}
+			seen[strings.ToLower(t)] = true
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// setMeta stores the metadata in ProjectConfig.
+func (p *Project) setMeta() {
+	for k, v := range map[string]string{
+		keyClient: p.Meta.Client,
+		keyType:   p.Meta.Type,
+		keyStart:  p.Meta.Start,
+		keyEnd:    p.Meta.End,
+		keyStatus: p.Meta.Status,
+		keyTags:   strings.Join(p.Meta.Tags, ","),
+	} {
+		if v == "" {
+			delete(p.ProjectConfig, k)
+		} else {
+			p.ProjectConfig[k] = v
+		}
+	}
+}
+
+// loadMeta reads the metadata from ProjectConfig.
+func (p *Project) loadMeta() {
+	p.Meta = Metadata{
+		Client: p.ProjectConfig[keyClient],
+		Type:   p.ProjectConfig[keyType],
+		Start:  p.ProjectConfig[keyStart],
+		End:    p.ProjectConfig[keyEnd],
+		Status: p.ProjectConfig[keyStatus],
+		Tags:   ParseTags(p.ProjectConfig[keyTags]),
+	}
+}
+
+// SaveMeta validates the metadata, writes it to the project config and
+// updates the registry.
+func (p *Project) SaveMeta() error {
+	if err := p.Meta.Validate(); err != nil {
+		return fmt.Errorf("project.Project.SaveMeta: %s", err.Error())
+	}
+	p.setMeta()
+	if err := p.writeConfig(); err != nil {
+		return fmt.Errorf("project.Project.SaveMeta: %s", err.Error())
+	}
+	if err := p.register(); err != nil {
+		return fmt.Errorf("project.Project.SaveMeta: %s", err.Error())
+	}
+	return nil
+}
+
+// RegistryEntry is one project in the registry.
+type RegistryEntry struct {
+	Name     string `json:"name"`
+	Root     string `json:"root"`
+	Template string `json:"template,omitempty"`
+	Metadata
+	// Archived is the archive date and Size is the size of the archive in
+	// bytes. They are only set for archived projects.
+	Archived string `json:"archived,omitempty"`
+	Size     int64  `json:"size,omitempty"`
+}
+
+// Registry is the central index of projects in the workspace. It's rebuilt
+// from the project configs with RefreshRegistry.
+type Registry struct {
+	Projects map[string]RegistryEntry `json:"projects"`
+	// Archived contains the projects in the archive directory.
+	Archived map[string]RegistryEntry `json:"archived"`
+}
+
+// registryPath returns the path to the registry file.
+func registryPath() (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, registryFilename), nil
+}
+
+// ReadRegistry reads the registry. An empty registry is returned if it does
+// not exist.
+func ReadRegistry() (*Registry, error) {
+	r := &Registry{
+		Projects: make(map[string]RegistryEntry),
+		Archived: make(map[string]RegistryEntry),
+	}
+	pth, err := registryPath()
+	if err != nil {
+		return nil, fmt.Errorf("project.ReadRegistry: %s", err.Error())
+	}
+	exists, err := shared.PathExists(pth)
+	if err != nil {
+		return nil, fmt.Errorf("project.ReadRegistry: %s", err.Error())
+	}
+	if !exists {
+		return r, nil
+	}
+	content, err := shared.ReadFileByte(pth)
+	if err != nil {
+		return nil, fmt.Errorf("project.ReadRegistry: %s", err.Error())
+	}
+	if err := json.Unmarshal(content, r); err != nil {
+		return nil, fmt.Errorf("project.ReadRegistry: unmarshal registry - %s", err.Error())
+	}
+	if r.Projects == nil {
+		r.Projects = make(map[string]RegistryEntry)
+	}
+	if r.Archived == nil {
+		r.Archived = make(map[string]RegistryEntry)
+	}
+	return r, nil
+}
+
+// Write writes the registry to the config directory.
+func (r *Registry) Write() error {
+	pth, err := registryPath()
+	if err != nil {
+		return fmt.Errorf("project.Registry.Write: %s", err.Error())
+	}
+	content, err := shared.StructToJSONString(r, true)
+	if err != nil {
+		return fmt.Errorf("project.Registry.Write: %s", err.Error())
+	}
+	if err := shared.WriteFileString(pth, content, true); err != nil {
+		return fmt.Errorf("project.Registry.Write: %s", err.Error())
+	}
+	return nil
+}
+
+// entry returns the registry entry of the project.
+func (p Project) entry() RegistryEntry {
+	return RegistryEntry{
+		Name:     p.ProjectName,
+		Root:     filepath.ToSlash(p.Root()),
+		Template: p.ProjectConfig[keyTemplate],
+		Metadata: p.Meta,
+	}
+}
+
+// register adds or updates the project in the registry.
+func (p Project) register() error {
+	r, err := ReadRegistry()
+	if err != nil {
+		return err
+	}
+	r.Projects[p.ProjectName] = p.entry()
+	return r.Write()
+}
+
+// unregister removes the project from the registry.
+func (p Project) unregister() error {
+	r, err := ReadRegistry()
+	if err != nil {
+		return err
+	}
+	delete(r.Projects, p.ProjectName)
+	return r.Write()
+}
+
+// RefreshRegistry rebuilds the registry from the project configs in the
+// workspace. Directories without a project config are not projects. Projects
+// that are no longer in the workspace are removed. Archived projects are kept.
+func RefreshRegistry() (*Registry, error) {
+	cfg, err := config.Read()
+	if err != nil {
+		return nil, fmt.Errorf("project.RefreshRegistry: %s", err.Error())
+	}
+	r, err := ReadRegistry()
+	if err != nil {
+		return nil, fmt.Errorf("project.RefreshRegistry: %s", err.Error())
+	}
+	dirs, err := ioutil.ReadDir(cfg.Key("workspace"))
+	if err != nil {
+		return nil, fmt.Errorf("project.RefreshRegistry: %s", err.Error())
+	}
+	projects := make(map[string]RegistryEntry)
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		p, err := Load(dir.Name())
+		if err != nil {
+			continue
+		}
+		projects[p.ProjectName] = p.entry()
+	}
+	r.Projects = projects
+	if err := r.Write(); err != nil {
+		return nil, fmt.Errorf("project.RefreshRegistry: %s", err.Error())
+	}
+	return r, nil
+}
+
+// ListOptions filters and sorts the registry.
+type ListOptions struct {
+	// Status, Tag and Client filters are not case-sensitive. Client matches
+	// part of the name.
+	Status string
+	Tag    string
+	Client string
+	// SortBy is one of SortFields, name is the default.
+	SortBy string
+	// Desc sorts in descending order.
+	Desc bool
+	// Archived lists the archived projects instead.
+	Archived bool
+}
+
+// SortFields contains the fields that projects can be sorted by.
+var SortFields = []string{"name", "client", "type", "status", "start", "end", "archived"}
+
+// List returns the projects in the registry that match the filters sorted by
+// the sort field.
+func (r *Registry) List(opts ListOptions) ([]RegistryEntry, error) {
+	projects := r.Projects
+	if opts.Archived {
+		projects = r.Archived
+	}
+	var entries []RegistryEntry
+	for _, e := range projects {
+		if opts.Status != "" && !strings.EqualFold(e.Status, opts.Status) {
+			continue
+		}
+		if opts.Tag != "" && !e.HasTag(opts.Tag) {
+			continue
+		}
+		if opts.Client != "" && !strings.Contains(strings.ToLower(e.Client), strings.ToLower(opts.Client)) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	key := func(e RegistryEntry) string { return strings.ToLower(e.Name) }
+	switch opts.SortBy {
+	case "", "name":
+	case "client":
+		key = func(e RegistryEntry) string { return strings.ToLower(e.Client) }
+	case "type":
+		key = func(e RegistryEntry) string { return strings.ToLower(e.Type) }
+	case "status":
+		// Statuses are sorted in the order of the engagement.
+		key = func(e RegistryEntry) string {
+			for i, st := range Statuses {
+				if st == e.Status {
+					return fmt.Sprint(i)
+				}
+			}
+			return fmt.Sprint(len(Statuses))
+		}
+	case "start":
+		key = func(e RegistryEntry) string { return e.Start }
+	case "end":
+		key = func(e RegistryEntry) string { return e.End }
+	case "archived":
+		key = func(e RegistryEntry) string { return e.Archived }
+	default:
+		return nil, fmt.Errorf("project.Registry.List: invalid sort field %q, use one of %s",
+			opts.SortBy, strings.Join(SortFields, ", "))
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		ki, kj := key(entries[i]), key(entries[j])
+		if ki == kj {
+			return entries[i].Name < entries[j].Name
+		}
+		if opts.Desc {
+			return ki > kj
+		}
+		return ki < kj
+	})
+	return entries, nil
+}
@@ -0,0 +1,72 @@
+package project
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMetadataValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		meta    Metadata
+		wantErr bool
+	}{
+		{"empty", Metadata{}, false},
+		{"valid", Metadata{Status: StatusReporting, Start: "2026-03-02", End: "2026-03-13"}, false},
+		{"invalid-status", Metadata{Status: "done"}, true},
+		{"invalid-date", Metadata{Start: "03/02/2026"}, true},
+		{"end-before-start", Metadata{Start: "2026-03-13", End: "2026-03-02"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.meta.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Metadata.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	got := ParseTags("web, SAML", "", "saml,api")
+	want := []string{"web", "SAML", "api"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseTags() = %v, want %v", got, want)
+	}
+}
+
+func TestRegistryList(t *testing.T) {
+	r := &Registry{Projects: map[string]RegistryEntry{
+		"acme":    {Name: "acme", Metadata: Metadata{Client: "Acme Corp", Status: StatusClosed, Start: "2026-01-05", Tags: []string{"web"}}},
+		"globex":  {Name: "globex", Metadata: Metadata{Client: "Globex", Status: StatusActive, Start: "2026-03-02", Tags: []string{"mobile"}}},
+		"initech": {Name: "initech", Metadata: Metadata{Client: "Initech", Status: StatusPlanned, Start: "2026-02-10", Tags: []string{"Web", "api"}}},
+	}}
+	tests := []struct {
+		name    string
+		opts    ListOptions
+		want    []string
+		wantErr bool
+	}{
+		{"all", ListOptions{}, []string{"acme", "globex", "initech"}, false},
+		{"status", ListOptions{Status: "Active"}, []string{"globex"}, false},
+		{"tag", ListOptions{Tag: "web"}, []string{"acme", "initech"}, false},
+		{"client", ListOptions{Client: "corp"}, []string{"acme"}, false},
+		{"sort-start", ListOptions{SortBy: "start"}, []string{"acme", "initech", "globex"}, false},
+		{"sort-status-desc", ListOptions{SortBy: "status", Desc: true}, []string{"acme", "globex", "initech"}, false},
+		{"invalid-sort", ListOptions{SortBy: "size"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := r.List(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Registry.List() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Registry.List() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/parsiya/borrowedtime/shared"
+)
+
+// Rename moves the project to newName in the workspace and rewrites the values
+// in the project config and the registry entries that contain the old project
+// root. It fails if newName exists. If a step fails, the previous steps are
+// rolled back.
+func (p *Project) Rename(newName string) (*Project, error) {
+	if err := validProjectName(newName); err != nil {
+		return nil, fmt.Errorf("project.Project.Rename: %s", err.Error())
+	}
+	np := New(newName)
+	oldRoot, newRoot := p.Root(), np.Root()
+	exists, err := shared.PathExists(newRoot)
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.Rename: %s", err.Error())
+	}
+	if exists {
+		return nil, fmt.Errorf("project.Project.Rename: %s already exists", newRoot)
+	}
+	reg, err := ReadRegistry()
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.Rename: %s", err.Error())
+	}
+	if _, ok := reg.Archived[newName]; ok {
+		return nil, fmt.Errorf("project.Project.Rename: an archived project named %s exists", newName)
+	}
+	oldConfig := make(map[string]string, len(p.ProjectConfig))
+	for k, v := range p.ProjectConfig {
+		oldConfig[k] = v
+	}
+
+	// undo contains the rollback of each completed step in order.
+	var undo []func() error
+	fail := func(err error) (*Project, error) {
+		for i := len(undo) - 1; i >= 0; i-- {
+			if uErr := undo[i](); uErr != nil {
+				return nil, fmt.Errorf("project.Project.Rename: %s, rollback failed - %s", err.Error(), uErr.Error())
+			}
+		}
+		return nil, fmt.Errorf("project.Project.Rename: %s, changes were rolled back", err.Error())
+	}
+
+	if err := os.Rename(oldRoot, newRoot); err != nil {
+		return nil, fmt.Errorf("project.Project.Rename: %s", err.Error())
+	}
+	undo = append(undo, func() error { return os.Rename(newRoot, oldRoot) })
+
+	np.ProjectConfig = make(map[string]string, len(oldConfig))
+	for k, v := range oldConfig {
+		np.ProjectConfig[k] = replaceRoot(v, p, np)
+	}
+	if err := np.writeConfig(); err != nil {
+		return fail(err)
+	}
+	undo = append(undo, func() error {
+		np.ProjectConfig = oldConfig
+		return np.writeConfig()
+	})
+
+	delete(reg.Projects, p.ProjectName)
+	np.loadVars()
+	np.loadMeta()
+	reg.Projects[newName] = np.entry()
+	for _, entries := range []map[string]RegistryEntry{reg.Projects, reg.Archived} {
+		for name, e := range entries {
+			e.Root = replaceRoot(e.Root, p, np)
+			entries[name] = e
+		}
+	}
+	if err := reg.Write(); err != nil {
+		return fail(err)
+	}
+	return np, nil
+}
+
+// validProjectName returns an error if name cannot be a directory in the
+// workspace.
+func validProjectName(name string) error {
+	switch {
+	case strings.TrimSpace(name) == "":
+		return fmt.Errorf("empty project name")
+	case name == "." || name == "..", strings.ContainsAny(name, `/\`):
+		return fmt.Errorf("invalid project name %q", name)
+	}
+	return nil
+}
+
+// replaceRoot replaces the root of the old project in s with the root of the
+// new project. Roots in native, slash and "workspace/name" (used by templates)
+// forms are replaced. Paths that only start with the old root (e.g., "acme2"
+// for "acme") are not changed.
+func replaceRoot(s string, oldP, newP *Project) string {
+	ws := strings.Replace(oldP.Workspace, `\\`, `\`, -1)
+	forms := [][2]string{
+		{oldP.Root(), newP.Root()},
+		{filepath.ToSlash(oldP.Root()), filepath.ToSlash(newP.Root())},
+		{ws + "/" + oldP.ProjectName, ws + "/" + newP.ProjectName},
+	}
+	for _, f := range forms {
+		s = replacePathPrefix(s, f[0], f[1])
+	}
+	return s
+}
+
+// replacePathPrefix replaces old with new where old is followed by a path
+// separator, a quote, whitespace or the end of s.
+func replacePathPrefix(s, old, new string) string {
+	if old == "" || old == new {
+		return s
+	}
+	var sb strings.Builder
+	for {
+		i := strings.Index(s, old)
+		if i < 0 {
+			sb.WriteString(s)
+			return sb.String()
+		}
+		end := i + len(old)
+		if end == len(s) || strings.ContainsRune("/\\\"' \t\r\n,;", rune(s[end])) {
+			sb.WriteString(s[:i] + new)
+		} else {
+			sb.WriteString(s[:end])
+		}
+		s = s[end:]
+	}
+}
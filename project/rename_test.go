@@ -0,0 +1,48 @@
+package project
+
+import "testing"
+
+func TestReplaceRoot(t *testing.T) {
+	oldP := &Project{ProjectName: "acme", Workspace: "/ws", ProjectRoot: "/ws/acme"}
+	newP := &Project{ProjectName: "globex", Workspace: "/ws", ProjectRoot: "/ws/globex"}
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"root", "/ws/acme", "/ws/globex"},
+		{"file", "/ws/acme/@notes.md", "/ws/globex/@notes.md"},
+		{"backslash", `/ws/acme\@pix`, `/ws/globex\@pix`},
+		{"prefix-of-another-project", "/ws/acme2/@notes.md", "/ws/acme2/@notes.md"},
+		{"multiple", "/ws/acme/a,/ws/acme/b", "/ws/globex/a,/ws/globex/b"},
+		{"not-a-path", "acme", "acme"},
+		{"other", "/other/acme", "/other/acme"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := replaceRoot(tt.in, oldP, newP); got != tt.want {
+				t.Errorf("replaceRoot(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidProjectName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"acme", false},
+		{"acme corp", false},
+		{"", true},
+		{" ", true},
+		{"..", true},
+		{"a/b", true},
+		{`a\b`, true},
+	}
+	for _, tt := range tests {
+		if err := validProjectName(tt.name); (err != nil) != tt.wantErr {
+			t.Errorf("validProjectName(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
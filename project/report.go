@@ -0,0 +1,326 @@
+package project
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/parsiya/borrowedtime/config"
+	"github.com/parsiya/borrowedtime/shared"
+)
+
+const (
+	// keyReport is the report directory in the project config.
+	keyReport = "report"
+	// keyReportConfig is the path to report.json in the project config.
+	keyReportConfig = "reportconfig"
+	// defaultReportDir is used if the project config has no report directory.
+	defaultReportDir = "@report"
+	// reportConfigFilename is the report config inside the report directory.
+	reportConfigFilename = "report.json"
+)
+
+// imageExts are the evidence extensions that are shown as images.
+var imageExts = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".bmp": true,
+	".svg": true, ".webp": true,
+}
+
+// Report is passed to report templates.
+type Report struct {
+	// Title, Client and Author are from report.json. Title defaults to the
+	// project name and Client to the client in the project metadata.
+	Title  string
+	Client string
+	Author string
+	// Date is "date" in report.json, the end date of the project or today.
+	Date    string
+	Project *Project
+	// Config contains every value in report.json.
+	Config   map[string]interface{}
+	Findings []*Finding
+	Summary  []SeveritySummary
+}
+
+// SeveritySummary is the number of findings with one severity.
+type SeveritySummary struct {
+	Severity string
+	Total    int
+	Open     int
+}
+
+// NewReport reads report.json and the findings of the project.
+func (p *Project) NewReport() (*Report, error) {
+	cfg, err := p.readReportConfig()
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.NewReport: %s", err.Error())
+	}
+	findings, err := p.Findings(FindingFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.NewReport: %s", err.Error())
+	}
+	r := newReport(p, cfg, findings)
+	if r.Date == "" {
+		r.Date = time.Now().Format(dateFormat)
+	}
+	return r, nil
+}
+
+// newReport creates a report and the summary of findings.
+func newReport(p *Project, cfg map[string]interface{}, findings []*Finding) *Report {
+	str := func(key string) string {
+		s, _ := cfg[key].(string)
+		return strings.TrimSpace(s)
+	}
+	r := &Report{
+		Title:    str("title"),
+		Client:   str("client"),
+		Author:   str("author"),
+		Date:     str("date"),
+		Project:  p,
+		Config:   cfg,
+		Findings: findings,
+	}
+	if r.Title == "" {
+		r.Title = p.ProjectName
+	}
+	if r.Client == "" {
+		r.Client = p.Meta.Client
+	}
+	if r.Date == "" {
+		r.Date = p.Meta.End
+	}
+	for _, sev := range Severities {
+		s := SeveritySummary{Severity: sev}
+		for _, f := range findings {
+			if f.Severity == sev {
+				s.Total++
+				if f.Status == "open" {
+					s.Open++
+				}
+			}
+		}
+		r.Summary = append(r.Summary, s)
+	}
+	return r
+}
+
+// reportDir returns the report directory.
+func (p Project) reportDir() string {
+	if dir := p.ProjectConfig[keyReport]; dir != "" {
+		return filepath.FromSlash(dir)
+	}
+	return filepath.Join(p.Root(), defaultReportDir)
+}
+
+// readReportConfig reads report.json. An empty config is returned if it does
+// not exist.
+func (p Project) readReportConfig() (map[string]interface{}, error) {
+	pth := p.ProjectConfig[keyReportConfig]
+	if pth == "" {
+		pth = filepath.Join(p.reportDir(), reportConfigFilename)
+	}
+	cfg := make(map[string]interface{})
+	exists, err := shared.PathExists(pth)
+	if err != nil || !exists {
+		return cfg, err
+	}
+	content, err := shared.ReadFileByte(pth)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &cfg); err != nil {
+		return nil, fmt.Errorf("unmarshal %s - %s", pth, err.Error())
+	}
+	return cfg, nil
+}
+
+// OutputDir returns "output" in report.json or the report directory.
+func (r Report) OutputDir() string {
+	if out, _ := r.Config["output"].(string); strings.TrimSpace(out) != "" {
+		return filepath.FromSlash(strings.TrimSpace(out))
+	}
+	return r.Project.reportDir()
+}
+
+// Write renders the report templates with names into the output directory.
+// If names is empty, every report template is rendered. The output of a
+// template is named "project-template.ext". Returns the paths to the files.
+func (r *Report) Write(names ...string) ([]string, error) {
+	tmpls, err := config.ReportTemplates()
+	if err != nil {
+		return nil, fmt.Errorf("project.Report.Write: %s", err.Error())
+	}
+	if len(names) == 0 {
+		names = shared.SortedKeys(tmpls)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("project.Report.Write: no report templates in templates/report, use \"template restore-defaults\"")
+	}
+	outDir := r.OutputDir()
+	if err := os.MkdirAll(outDir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("project.Report.Write: %s", err.Error())
+	}
+	var files []string
+	for _, name := range names {
+		name = shared.RemoveExtension(name)
+		pth, ok := tmpls[name]
+		if !ok {
+			return files, fmt.Errorf("project.Report.Write: report template %s not found", name)
+		}
+		t := config.Template{Name: name, FullPath: pth, Kind: config.ReportKind}
+		var out []byte
+		if isDOCX(pth) {
+			d, err := r.renderDOCX(t)
+			if err == nil {
+				out, err = d.bytes()
+			}
+			if err != nil {
+				return files, fmt.Errorf("project.Report.Write: %s", err.Error())
+			}
+		} else {
+			s, err := r.render(t, outDir)
+			if err != nil {
+				return files, fmt.Errorf("project.Report.Write: %s", err.Error())
+			}
+			out = []byte(s)
+		}
+		outPath := filepath.Join(outDir, r.Project.ProjectName+"-"+name+filepath.Ext(pth))
+		if err := shared.WriteFile(outPath, out, true); err != nil {
+			return files, fmt.Errorf("project.Report.Write: %s", err.Error())
+		}
+		files = append(files, outPath)
+	}
+	return files, nil
+}
+
+// isHTML returns true if the template creates an HTML report.
+func isHTML(pth string) bool {
+	ext := strings.ToLower(filepath.Ext(pth))
+	return ext == ".html" || ext == ".htm"
+}
+
+// render executes a report template. HTML templates escape values and embed
+// images. Other templates link to evidence relative to outDir.
+func (r *Report) render(t config.Template, outDir string) (string, error) {
+	tmplStr, err := shared.ReadFileString(t.FullPath)
+	if err != nil {
+		return "", err
+	}
+	tmplStr, d := parseDelims(tmplStr)
+
+	html := isHTML(t.FullPath)
+	evidence := func(pth string) (string, error) { return r.evidenceLink(pth, outDir) }
+	if html {
+		evidence = r.evidenceDataURI
+	}
+	funcs := map[string]interface{}{
+		"title":   strings.Title,
+		"join":    strings.Join,
+		"upper":   strings.ToUpper,
+		"score":   findingScore,
+		"isImage": isImage,
+		"evidence": func(pth string) (string, error) {
+			return evidence(pth)
+		},
+		"markdown": func(s string) (string, error) {
+			return rewriteImages(s, evidence)
+		},
+	}
+
+	var sb strings.Builder
+	var exec interface {
+		Execute(io.Writer, interface{}) error
+	}
+	if html {
+		// Evidence is embedded as data URIs that html/template would reject.
+		funcs["evidence"] = func(pth string) (htmltemplate.URL, error) {
+			uri, err := evidence(pth)
+			return htmltemplate.URL(uri), err
+		}
+		funcs["markdown"] = func(s string) (htmltemplate.HTML, error) {
+			out, err := markdownToHTML(s, 3, evidence)
+			return htmltemplate.HTML(out), err
+		}
+		exec, err = htmltemplate.New(t.Name).Delims(d.Left, d.Right).Funcs(funcs).Parse(tmplStr)
+	} else {
+		exec, err = template.New(t.Name).Delims(d.Left, d.Right).Funcs(funcs).Parse(tmplStr)
+	}
+	if err != nil {
+		return "", fmt.Errorf("parse report template %s - %s", t.Name, err.Error())
+	}
+	if err := exec.Execute(&sb, r); err != nil {
+		return "", fmt.Errorf("execute report template %s - %s", t.Name, err.Error())
+	}
+	return sb.String(), nil
+}
+
+// isImage returns true if the evidence is an image.
+func isImage(pth string) bool {
+	return imageExts[strings.ToLower(path.Ext(pth))]
+}
+
+// isRemote returns true if the path is a URL.
+func isRemote(pth string) bool {
+	return strings.Contains(pth, "://") || strings.HasPrefix(pth, "data:")
+}
+
+// evidencePath returns the path of evidence relative to the project root.
+// Evidence outside the project is rejected.
+func (r Report) evidencePath(pth string) (string, error) {
+	root := r.Project.Root()
+	full := filepath.Join(root, filepath.FromSlash(pth))
+	if rel, err := filepath.Rel(root, full); err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("evidence %s is not inside the project", pth)
+	}
+	return full, nil
+}
+
+// evidenceLink returns the path to evidence relative to the output directory.
+func (r Report) evidenceLink(pth, outDir string) (string, error) {
+	if isRemote(pth) {
+		return pth, nil
+	}
+	full, err := r.evidencePath(pth)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(outDir, full)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// evidenceDataURI returns the evidence as a data URI. Only images keep their
+// content type. URLs are checked with safeURL because the result is not
+// escaped by html/template.
+func (r Report) evidenceDataURI(pth string) (string, error) {
+	if isRemote(pth) {
+		return safeURL(pth), nil
+	}
+	full, err := r.evidencePath(pth)
+	if err != nil {
+		return "", err
+	}
+	content, err := shared.ReadFileByte(full)
+	if err != nil {
+		return "", fmt.Errorf("read evidence %s - %s", pth, err.Error())
+	}
+	typ := "application/octet-stream"
+	if isImage(full) {
+		if t := mime.TypeByExtension(strings.ToLower(filepath.Ext(full))); t != "" {
+			typ = t
+		}
+	}
+	return "data:" + typ + ";base64," + base64.StdEncoding.EncodeToString(content), nil
+}
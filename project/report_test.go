@@ -0,0 +1,138 @@
+package project
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/parsiya/borrowedtime/config"
+)
+
+func TestReportRender(t *testing.T) {
+	root := t.TempDir()
+	p := &Project{ProjectName: "acme", ProjectRoot: root, Meta: Metadata{Client: "Acme Corp"}}
+	if err := os.MkdirAll(filepath.Join(root, "@pix"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "@pix", "sqli.png"), []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []*Finding{
+		{Title: "Verbose <errors>", Severity: "low", Evidence: []string{"@clientFiles/log.txt"}},
+		{Title: "SQL injection", Severity: "critical", Evidence: []string{"@pix/sqli.png"},
+			Description: "The **id** parameter.\n\n![query](@pix/sqli.png)",
+			CVSS:        "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"},
+	} {
+		if err := p.AddFinding(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	findings, err := p.Findings(FindingFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newReport(p, map[string]interface{}{"author": "Jane"}, findings)
+	if r.Title != "acme" || r.Client != "Acme Corp" || r.Author != "Jane" {
+		t.Errorf("newReport() = %+v", r)
+	}
+	if s := r.Summary[0]; s.Severity != "critical" || s.Total != 1 || s.Open != 1 {
+		t.Errorf("newReport() summary = %+v", r.Summary)
+	}
+
+	tmpls, err := config.DefaultTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	outDir := filepath.Join(root, "@report")
+	want := map[string][]string{
+		".md": {
+			"| Critical | 1 | 1 |",
+			"| F-002 | Critical | 9.8 | open | SQL injection |",
+			"![@pix/sqli.png](../@pix/sqli.png)",
+			"![query](../@pix/sqli.png)",
+			"* [@clientFiles/log.txt](../@clientFiles/log.txt)",
+			"Verbose <errors>",
+		},
+		".html": {
+			"<td>Acme Corp</td>",
+			`<img src="data:image/png;base64,cG5n" alt="@pix/sqli.png">`,
+			`<img src="data:image/png;base64,cG5n" alt="query">`,
+			"<p>The <strong>id</strong> parameter.</p>",
+			"Verbose &lt;errors&gt;",
+			"<code>@clientFiles/log.txt</code>",
+		},
+	}
+	found := 0
+	for _, tm := range tmpls {
+		if tm.Kind != config.ReportKind {
+			continue
+		}
+		found++
+		content, err := config.DefaultContent(tm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tm.FullPath = filepath.Join(t.TempDir(), path.Base(tm.FullPath))
+		if err := os.WriteFile(tm.FullPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		out, err := r.render(tm, outDir)
+		if err != nil {
+			t.Fatalf("%s: %v", tm.Name, err)
+		}
+		for _, s := range want[filepath.Ext(tm.FullPath)] {
+			if !strings.Contains(out, s) {
+				t.Errorf("%s: output does not contain %q:\n%s", tm.Name, s, out)
+			}
+		}
+	}
+	if found != 2 {
+		t.Errorf("found %d default report templates, want 2", found)
+	}
+
+	// Remote evidence is not escaped in HTML reports.
+	if got, err := r.evidenceDataURI("javascript://%0aalert(1)"); err != nil || got != unsafeURL {
+		t.Errorf("evidenceDataURI() = %q, %v, want %q", got, err, unsafeURL)
+	}
+
+	// Evidence outside the project is rejected.
+	if _, err := r.evidenceDataURI("../secret.png"); err == nil {
+		t.Error("evidenceDataURI() did not return an error for evidence outside the project")
+	}
+}
+
+func TestReportWrite(t *testing.T) {
+	home := testHome(t)
+	p := New("acme")
+	if err := p.Create("project-structure", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.AddFinding(&Finding{Title: "Open redirect", Severity: "low",
+		Description: "[login](https://acme.example/login) and [x](javascript:alert`1`)"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := p.NewReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := r.Write()
+	if err != nil {
+		t.Fatal(err)
+	}
+	outDir := filepath.Join(home, "ws", "acme", "@report")
+	want := []string{filepath.Join(outDir, "acme-html.html"), filepath.Join(outDir, "acme-markdown.md")}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("Write() = %v, want %v", files, want)
+	}
+	content, err := os.ReadFile(want[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), `<a href="https://acme.example/login">login</a>`) ||
+		strings.Contains(string(content), "javascript:") {
+		t.Errorf("Write() HTML links:\n%s", content)
+	}
+}
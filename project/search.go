@@ -0,0 +1,354 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/parsiya/borrowedtime/config"
+	"github.com/parsiya/borrowedtime/shared"
+)
+
+const (
+	// searchIndexFilename is the search index in the config directory.
+	searchIndexFilename = "search-index.json"
+	// credsFilename is the credentials file created by the default project
+	// structure. It's never indexed.
+	credsFilename = "@creds.md"
+	// maxIndexSize is the size of the largest file that is indexed.
+	maxIndexSize = 1 << 20
+	// snippetLength is the maximum length of a result snippet.
+	snippetLength = 120
+)
+
+// searchIndex contains the lines of every text file in the workspace. Files
+// are only read again when their modification time or size changes.
+type searchIndex struct {
+	// Workspace is the indexed workspace. The index is rebuilt if it changes.
+	Workspace string `json:"workspace"`
+	// Files are indexed by "project/path".
+	Files map[string]*indexedFile `json:"files"`
+}
+
+// indexedFile is one file in the search index. Binary and large files have
+// no lines.
+type indexedFile struct {
+	Project string   `json:"project"`
+	Path    string   `json:"path"`
+	ModTime int64    `json:"modtime"`
+	Size    int64    `json:"size"`
+	Lines   []string `json:"lines,omitempty"`
+}
+
+// SearchOptions contains the search query and filters.
+type SearchOptions struct {
+	// Terms must all appear in a line. Terms with spaces are phrases. Terms
+	// are not case-sensitive.
+	Terms []string
+	// Regex must match the line if it's not empty.
+	Regex string
+	// Projects only searches projects with names that match one of these glob
+	// patterns.
+	Projects []string
+	// Rebuild discards the index and reads every file.
+	Rebuild bool
+}
+
+// SearchResult is one matching line.
+type SearchResult struct {
+	Project string
+	// File is the path relative to the project root.
+	File string
+	// Line starts from 1.
+	Line    int
+	Snippet string
+}
+
+// IndexStats shows how much of the index was updated.
+type IndexStats struct {
+	Files, Updated, Removed int
+}
+
+// Search updates the search index and returns the lines in the workspace
+// projects that match the query. Results are sorted by project, file and line.
+func Search(opts SearchOptions) ([]SearchResult, IndexStats, error) {
+	var stats IndexStats
+	terms := make([]string, 0, len(opts.Terms))
+	for _, t := range opts.Terms {
+		if t = strings.TrimSpace(t); t != "" {
+			terms = append(terms, strings.ToLower(t))
+		}
+	}
+	var re *regexp.Regexp
+	if opts.Regex != "" {
+		var err error
+		if re, err = regexp.Compile(opts.Regex); err != nil {
+			return nil, stats, fmt.Errorf("project.Search: invalid regex - %s", err.Error())
+		}
+	}
+	if len(terms) == 0 && re == nil {
+		return nil, stats, fmt.Errorf("project.Search: empty query")
+	}
+	for _, pattern := range opts.Projects {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, stats, fmt.Errorf("project.Search: invalid project filter %q", pattern)
+		}
+	}
+
+	idx, err := readSearchIndex()
+	if err != nil {
+		return nil, stats, fmt.Errorf("project.Search: %s", err.Error())
+	}
+	cfg, err := config.Read()
+	if err != nil {
+		return nil, stats, fmt.Errorf("project.Search: %s", err.Error())
+	}
+	if opts.Rebuild || idx.Workspace != cfg.Key("workspace") {
+		idx = &searchIndex{Workspace: cfg.Key("workspace"), Files: make(map[string]*indexedFile)}
+	}
+	if stats, err = idx.update(searchIgnore(cfg.Key(keyEvidenceDirs))); err != nil {
+		return nil, stats, fmt.Errorf("project.Search: %s", err.Error())
+	}
+	if stats.Updated > 0 || stats.Removed > 0 || opts.Rebuild {
+		if err := idx.write(); err != nil {
+			return nil, stats, fmt.Errorf("project.Search: %s", err.Error())
+		}
+	}
+	return idx.search(terms, re, opts.Projects), stats, nil
+}
+
+// searchIndexPath returns the path to the search index.
+func searchIndexPath() (string, error) {
+	dir, err := config.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, searchIndexFilename), nil
+}
+
+// readSearchIndex reads the search index. An empty index is returned if it
+// does not exist or cannot be read.
+func readSearchIndex() (*searchIndex, error) {
+	idx := &searchIndex{Files: make(map[string]*indexedFile)}
+	pth, err := searchIndexPath()
+	if err != nil {
+		return nil, err
+	}
+	content, err := ioutil.ReadFile(pth)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return idx, nil
+		}
+		return nil, err
+	}
+	// A corrupted index is rebuilt.
+	if err := json.Unmarshal(content, idx); err != nil || idx.Files == nil {
+		return &searchIndex{Files: make(map[string]*indexedFile)}, nil
+	}
+	return idx, nil
+}
+
+// write writes the search index to the config directory.
+func (idx *searchIndex) write() error {
+	pth, err := searchIndexPath()
+	if err != nil {
+		return err
+	}
+	content, err := shared.StructToJSONString(idx, false)
+	if err != nil {
+		return err
+	}
+	// The index contains the content of the projects.
+	if err := ioutil.WriteFile(pth, []byte(content), 0600); err != nil {
+		return err
+	}
+	// WriteFile does not change the mode of existing files.
+	return os.Chmod(pth, 0600)
+}
+
+// searchIgnore returns the patterns of the files and directories that are
+// never indexed: the credentials and the evidence directories.
+func searchIgnore(evidence string) []string {
+	return append([]string{credsFilename}, evidenceDirs(evidence)...)
+}
+
+// update reads new and modified files in the workspace projects and removes
+// files that no longer exist. Hidden directories and paths that match the
+// ignore patterns are skipped.
+func (idx *searchIndex) update(ignore []string) (IndexStats, error) {
+	var stats IndexStats
+	dirs, err := ioutil.ReadDir(idx.Workspace)
+	if err != nil {
+		return stats, err
+	}
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		if !dir.IsDir() || strings.HasPrefix(dir.Name(), ".") {
+			continue
+		}
+		root := filepath.Join(idx.Workspace, dir.Name())
+		err := filepath.Walk(root, func(pth string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if pth == root {
+				return nil
+			}
+			rel, err := filepath.Rel(root, pth)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			if info.IsDir() {
+				if strings.HasPrefix(info.Name(), ".") || ignored(rel, ignore) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.Mode().IsRegular() || info.Name() == hashesFilename || ignored(rel, ignore) {
+				return nil
+			}
+			key := dir.Name() + "/" + rel
+			seen[key] = true
+			stats.Files++
+			f := idx.Files[key]
+			if f != nil && f.ModTime == info.ModTime().UnixNano() && f.Size == info.Size() {
+				return nil
+			}
+			f = &indexedFile{
+				Project: dir.Name(),
+				Path:    rel,
+				ModTime: info.ModTime().UnixNano(),
+				Size:    info.Size(),
+			}
+			if info.Size() <= maxIndexSize {
+				content, err := ioutil.ReadFile(pth)
+				if err != nil {
+					return err
+				}
+				if isText(content) {
+					f.Lines = strings.Split(strings.Replace(string(content), "\r\n", "\n", -1), "\n")
+				}
+			}
+			idx.Files[key] = f
+			stats.Updated++
+			return nil
+		})
+		if err != nil {
+			return stats, err
+		}
+	}
+	for key := range idx.Files {
+		if !seen[key] {
+			delete(idx.Files, key)
+			stats.Removed++
+		}
+	}
+	return stats, nil
+}
+
+// search returns the lines that contain all terms and match re. terms must be
+// lowercase and re can be nil.
+func (idx *searchIndex) search(terms []string, re *regexp.Regexp, projects []string) []SearchResult {
+	var results []SearchResult
+	for _, f := range idx.Files {
+		if !matchProject(f.Project, projects) {
+			continue
+		}
+		for i, line := range f.Lines {
+			start, ok := matchLine(line, terms, re)
+			if !ok {
+				continue
+			}
+			results = append(results, SearchResult{
+				Project: f.Project,
+				File:    f.Path,
+				Line:    i + 1,
+				Snippet: snippet(line, start),
+			})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Project != b.Project {
+			return a.Project < b.Project
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return results
+}
+
+// matchProject returns true if there are no patterns or name matches one.
+func matchProject(name string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(strings.ToLower(p), strings.ToLower(name)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchLine returns true and the index of the first match if the line
+// contains every term and matches re.
+func matchLine(line string, terms []string, re *regexp.Regexp) (int, bool) {
+	start := -1
+	if re != nil {
+		loc := re.FindStringIndex(line)
+		if loc == nil {
+			return 0, false
+		}
+		start = loc[0]
+	}
+	lower := strings.ToLower(line)
+	for _, t := range terms {
+		i := strings.Index(lower, t)
+		if i < 0 {
+			return 0, false
+		}
+		if start < 0 || i < start {
+			start = i
+		}
+	}
+	return start, true
+}
+
+// snippet returns the trimmed line. Long lines are cut around the match at
+// start.
+func snippet(line string, start int) string {
+	r := []rune(line)
+	if len(r) <= snippetLength {
+		return strings.TrimSpace(line)
+	}
+	// start is a byte index.
+	if start > len(line) {
+		start = len(line)
+	}
+	pos := len([]rune(line[:start]))
+	from := pos - snippetLength/4
+	if from < 0 {
+		from = 0
+	}
+	to := from + snippetLength
+	if to > len(r) {
+		to, from = len(r), len(r)-snippetLength
+	}
+	s := strings.TrimSpace(string(r[from:to]))
+	if from > 0 {
+		s = "..." + s
+	}
+	if to < len(r) {
+		s += "..."
+	}
+	return s
+}
@@ -0,0 +1,158 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatchLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      string
+		terms     []string
+		re        string
+		wantStart int
+		wantOK    bool
+	}{
+		{"term", "Found a SAML bypass", []string{"saml"}, "", 8, true},
+		{"all-terms", "Found a SAML bypass", []string{"bypass", "found"}, "", 0, true},
+		{"missing-term", "Found a SAML bypass", []string{"saml", "xss"}, "", 0, false},
+		{"phrase", "Found a SAML bypass", []string{"saml bypass"}, "", 8, true},
+		{"phrase-order", "Found a SAML bypass", []string{"bypass saml"}, "", 0, false},
+		{"regex", "token: abc123", nil, `[a-z]+\d+`, 7, true},
+		{"regex-no-match", "token: abc", nil, `\d+`, 0, false},
+		{"regex-and-term", "token: abc123", []string{"token"}, `\d+`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var re *regexp.Regexp
+			if tt.re != "" {
+				re = regexp.MustCompile(tt.re)
+			}
+			start, ok := matchLine(tt.line, tt.terms, re)
+			if ok != tt.wantOK || (ok && start != tt.wantStart) {
+				t.Errorf("matchLine() = %d, %t, want %d, %t", start, ok, tt.wantStart, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	if got := snippet("  short line ", 2); got != "short line" {
+		t.Errorf("snippet() = %q, want %q", got, "short line")
+	}
+	long := strings.Repeat("a", 200) + "match" + strings.Repeat("b", 200)
+	got := snippet(long, 200)
+	if !strings.Contains(got, "match") || !strings.HasPrefix(got, "...") || !strings.HasSuffix(got, "...") {
+		t.Errorf("snippet() = %q, want the match surrounded by ...", got)
+	}
+	if got := snippet(long, len(long)-1); !strings.HasPrefix(got, "...") || strings.HasSuffix(got, "...") {
+		t.Errorf("snippet() at the end = %q", got)
+	}
+}
+
+func TestSearchIndexUpdate(t *testing.T) {
+	ws := t.TempDir()
+	write := func(name, content string) {
+		pth := filepath.Join(ws, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(pth), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(pth, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("acme/@notes.md", "SAML bypass\r\nsecond line")
+	write("acme/@clientFiles/scope.txt", "saml")
+	write("acme/@creds.md", "admin:password")
+	write("acme/notes/@creds.md", "admin:password")
+	write("acme/@pix/ocr.txt", "saml")
+	write("globex/@dumps/saml.txt", "saml")
+	write("acme/bin/tool.exe", "MZ\x00\x00")
+	write("acme/.git/config", "saml")
+	write("globex/@findings.md", "no saml here\nsaml bypass in sso")
+
+	idx := &searchIndex{Workspace: ws, Files: make(map[string]*indexedFile)}
+	ignore := searchIgnore("@clientFiles, @pix, @dumps")
+	stats, err := idx.update(ignore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats != (IndexStats{Files: 3, Updated: 3}) {
+		t.Errorf("update() = %+v, want 3 files updated", stats)
+	}
+	for _, key := range []string{"acme/@clientFiles/scope.txt", "acme/@pix/ocr.txt", "globex/@dumps/saml.txt"} {
+		if idx.Files[key] != nil {
+			t.Errorf("evidence %s should not be indexed", key)
+		}
+	}
+	for _, key := range []string{"acme/@creds.md", "acme/notes/@creds.md"} {
+		if idx.Files[key] != nil {
+			t.Errorf("credentials %s should not be indexed", key)
+		}
+	}
+	if idx.Files["acme/bin/tool.exe"].Lines != nil {
+		t.Error("binary file should not have lines")
+	}
+
+	got := idx.search([]string{"saml bypass"}, nil, nil)
+	want := []SearchResult{
+		{"acme", "@notes.md", 1, "SAML bypass"},
+		{"globex", "@findings.md", 2, "saml bypass in sso"},
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("search() = %v, want %v", got, want)
+	}
+	if got := idx.search([]string{"saml"}, nil, []string{"ACME*"}); len(got) != 1 || got[0].Project != "acme" {
+		t.Errorf("search() with project filter = %v", got)
+	}
+
+	// Only modified files are read again.
+	write("acme/@notes.md", "xss")
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(filepath.Join(ws, "acme", "@notes.md"), later, later)
+	if err := os.Remove(filepath.Join(ws, "globex", "@findings.md")); err != nil {
+		t.Fatal(err)
+	}
+	stats, err = idx.update(ignore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats != (IndexStats{Files: 2, Updated: 1, Removed: 1}) {
+		t.Errorf("update() = %+v, want 1 updated and 1 removed", stats)
+	}
+	if got := idx.search([]string{"saml"}, nil, nil); len(got) != 0 {
+		t.Errorf("search() after update = %v, want no results", got)
+	}
+}
+
+func TestSearchIndexWrite(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not supported on Windows")
+	}
+	testHome(t)
+	pth, err := searchIndexPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An index created by an older version.
+	if err := os.WriteFile(pth, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	idx := &searchIndex{Files: make(map[string]*indexedFile)}
+	if err := idx.write(); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(pth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("search index mode = %o, want 600", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,118 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/parsiya/borrowedtime/config"
+	"github.com/parsiya/borrowedtime/shared"
+)
+
+// sourcePath returns the full path of the node's source in the data directory.
+// Actions that use the foreach item are rendered here.
+func (n *Node) sourcePath(p Project) (string, error) {
+	source, err := renderString(p, n.Info.Source)
+	if err != nil {
+		return "", err
+	}
+	dataDir, err := config.DataDir()
+	if err != nil {
+		return "", err
+	}
+	pth := filepath.Join(dataDir, source)
+	// Source cannot be outside the data directory.
+	rel, err := filepath.Rel(dataDir, pth)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("source %s is not inside the data directory", n.Info.Source)
+	}
+	return pth, nil
+}
+
+// copySource copies the node's source from the data directory to FullPath.
+// File modes are preserved. If Render is set, text files are executed as
+// templates.
+func (n *Node) copySource(p Project) error {
+	src, err := n.sourcePath(p)
+	if err != nil {
+		return fmt.Errorf("project.Node.copySource: %s", err.Error())
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("project.Node.copySource: %s", err.Error())
+	}
+	if info.IsDir() != n.Info.IsDir {
+		return fmt.Errorf("project.Node.copySource: isdir of %s does not match source %s", n.FullPath, n.Info.Source)
+	}
+	if !info.IsDir() {
+		return copyFile(p, src, n.FullPath, n.Info.Render)
+	}
+
+	// Copy the contents of the directory.
+	err = filepath.Walk(src, func(pth string, fi os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		rel, err := filepath.Rel(src, pth)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(n.FullPath, rel)
+		if fi.IsDir() {
+			if err := os.MkdirAll(dst, os.ModePerm); err != nil {
+				return err
+			}
+			return os.Chmod(dst, fi.Mode().Perm())
+		}
+		return copyFile(p, pth, dst, n.Info.Render)
+	})
+	if err != nil {
+		return fmt.Errorf("project.Node.copySource: %s", err.Error())
+	}
+	return nil
+}
+
+// copyFile copies src to dst. If render is set and src is a text file, it's
+// executed as a template with the project.
+func copyFile(p Project, src, dst string, render bool) error {
+	if !render {
+		return shared.CopyFile(src, dst)
+	}
+	content, err := shared.ReadFileByte(src)
+	if err != nil {
+		return err
+	}
+	// Binary files are always copied verbatim.
+	if !isText(content) {
+		return shared.CopyFile(src, dst)
+	}
+	rendered, err := renderSource(p, filepath.Base(src), string(content))
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if err := shared.WriteFileString(dst, rendered, true); err != nil {
+		return err
+	}
+	return os.Chmod(dst, info.Mode().Perm())
+}
+
+// renderSource executes the content of a source file as a template. Source
+// files can change the delimiters with the same header as templates.
+func renderSource(p Project, name, content string) (string, error) {
+	content, d := parseDelims(content)
+	tmpl, err := template.New(name).Delims(d.Left, d.Right).Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("project.renderSource: parse %s - %s", name, err.Error())
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, p); err != nil {
+		return "", fmt.Errorf("project.renderSource: execute %s - %s", name, err.Error())
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,301 @@
+package project
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/parsiya/borrowedtime/config"
+	"github.com/parsiya/borrowedtime/shared"
+)
+
+// hashesFilename stores the hash of every file when it was created from the
+// template. It's used to detect files edited by the user.
+const hashesFilename = ".hashes.json"
+
+// Sync statuses.
+const (
+	syncCreated = "created"
+	syncChanged = "changed"
+	syncUpdated = "updated"
+	syncEdited  = "edited"
+)
+
+// errSkipChildren is returned by walk functions to skip the children of a node.
+var errSkipChildren = errors.New("skip children")
+
+// walk calls fn for the node and every child that would be created. Paths are
+// calculated, conditions are evaluated and foreach is expanded the same way as
+// Create. If fn returns errSkipChildren, children of that node are skipped.
+func (n *Node) walk(p Project, fn func(n *Node, p Project) error) error {
+	ok, err := evalCondition(p, n.When)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+	if err := fn(n, p); err != nil {
+		if err == errSkipChildren {
+			return nil
+		}
+		return err
+	}
+	for _, child := range n.Children {
+		insts, err := n.instances(child, p)
+		if err != nil {
+			return err
+		}
+		for _, inst := range insts {
+			if err := inst.node.walk(inst.p, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// expectedContent returns the content that Create writes to a file node.
+func (n *Node) expectedContent(p Project) ([]byte, error) {
+	if n.Info.Source == "" {
+		// Same as Create, a missing template results in an empty file.
+		tmplString, _ := p.generateTemplate(n.Info.Template, false)
+		return []byte(tmplString), nil
+	}
+	src, err := n.sourcePath(p)
+	if err != nil {
+		return nil, err
+	}
+	content, err := shared.ReadFileByte(src)
+	if err != nil {
+		return nil, err
+	}
+	if !n.Info.Render || !isText(content) {
+		return content, nil
+	}
+	rendered, err := renderSource(p, filepath.Base(src), string(content))
+	return []byte(rendered), err
+}
+
+// hashBytes returns the hex encoded SHA-256 hash of b.
+func hashBytes(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// hashFile returns the hex encoded SHA-256 hash of a file.
+func hashFile(pth string) (string, error) {
+	f, err := os.Open(pth)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// templateHash returns the hash of a project template file.
+func templateHash(templateName string) (string, error) {
+	prjTmpls, err := config.ProjectTemplates()
+	if err != nil {
+		return "", err
+	}
+	pth := prjTmpls[shared.RemoveExtension(templateName)]
+	if pth == "" {
+		return "", fmt.Errorf("template %s not found", templateName)
+	}
+	return hashFile(pth)
+}
+
+// readHashes reads the file hashes of the project. A missing file returns an
+// empty map.
+func (p Project) readHashes() (map[string]string, error) {
+	hashes := make(map[string]string)
+	pth := filepath.Join(p.ProjectRoot, hashesFilename)
+	exists, err := shared.PathExists(pth)
+	if err != nil || !exists {
+		return hashes, err
+	}
+	content, err := shared.ReadFileByte(pth)
+	if err != nil {
+		return hashes, err
+	}
+	err = json.Unmarshal(content, &hashes)
+	return hashes, err
+}
+
+// writeHashes writes the file hashes of the project.
+func (p Project) writeHashes(hashes map[string]string) error {
+	content, err := shared.StructToJSONString(hashes, true)
+	if err != nil {
+		return err
+	}
+	return shared.WriteFileString(filepath.Join(p.ProjectRoot, hashesFilename), content, true)
+}
+
+// relPath returns the path of a node relative to the project root in slash
+// form. This is used as the key in the hashes file.
+func relPath(root, pth string) string {
+	rel, err := filepath.Rel(root, pth)
+	if err != nil {
+		return filepath.ToSlash(pth)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// recordHashes hashes every file created by the template.
+func (p Project) recordHashes(tmpl string) error {
+	root, err := parseProjectTemplate(tmpl)
+	if err != nil {
+		return fmt.Errorf("project.Project.recordHashes: %s", err.Error())
+	}
+	hashes, err := p.readHashes()
+	if err != nil {
+		return fmt.Errorf("project.Project.recordHashes: %s", err.Error())
+	}
+	err = root.walk(p, func(n *Node, np Project) error {
+		if n.Info.IsDir || n.Info.Symlink != "" {
+			return nil
+		}
+		hash, err := hashFile(n.FullPath)
+		if err != nil {
+			return err
+		}
+		hashes[relPath(root.FullPath, n.FullPath)] = hash
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("project.Project.recordHashes: %s", err.Error())
+	}
+	if err := p.writeHashes(hashes); err != nil {
+		return fmt.Errorf("project.Project.recordHashes: %s", err.Error())
+	}
+	return nil
+}
+
+// Sync creates the nodes of the project template that are missing from the
+// project and reports files whose template output has changed. If apply is
+// set, changed files that have not been edited since they were created are
+// updated. Files edited by the user are never overwritten.
+func (p *Project) Sync(apply bool) (string, error) {
+	templateName := p.ProjectConfig[keyTemplate]
+	if templateName == "" {
+		return "", fmt.Errorf("project.Project.Sync: %s does not have a template in %s", p.ProjectName, configFilename)
+	}
+	if err := p.loadData(); err != nil {
+		return "", fmt.Errorf("project.Project.Sync: %s", err.Error())
+	}
+	var sb strings.Builder
+
+	newHash, err := templateHash(templateName)
+	if err != nil {
+		return "", fmt.Errorf("project.Project.Sync: %s", err.Error())
+	}
+	if newHash != p.ProjectConfig[keyTemplateHash] {
+		fmt.Fprintf(&sb, "Template %s has changed since the project was created or synced.\n", templateName)
+	}
+
+	tmpl, err := p.generateTemplate(templateName, true)
+	if err != nil {
+		return "", fmt.Errorf("project.Project.Sync: %s", err.Error())
+	}
+	root, err := parseProjectTemplate(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("project.Project.Sync: %s", err.Error())
+	}
+	hashes, err := p.readHashes()
+	if err != nil {
+		return "", fmt.Errorf("project.Project.Sync: %s", err.Error())
+	}
+
+	report := func(status, rel string) {
+		fmt.Fprintf(&sb, "%-12s %s\n", "["+status+"]", rel)
+	}
+	edited := 0
+	err = root.walk(*p, func(n *Node, np Project) error {
+		rel := relPath(root.FullPath, n.FullPath)
+		exists, err := n.exists()
+		if err != nil {
+			return err
+		}
+		// Create missing nodes with their children.
+		if !exists {
+			if err := n.Create(np, false); err != nil {
+				return err
+			}
+			// Hash the new files.
+			err := n.walk(np, func(c *Node, _ Project) error {
+				if c.Info.IsDir || c.Info.Symlink != "" {
+					return nil
+				}
+				hash, err := hashFile(c.FullPath)
+				hashes[relPath(root.FullPath, c.FullPath)] = hash
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			report(syncCreated, rel)
+			return errSkipChildren
+		}
+		// The project config is modified by borrowed time after creation.
+		// Directories copied from source and symlinks are not synced.
+		if n.Info.IsDir || n.Info.Symlink != "" || rel == configFilename {
+			return nil
+		}
+
+		expected, err := n.expectedContent(np)
+		if err != nil {
+			return err
+		}
+		current, err := hashFile(n.FullPath)
+		if err != nil {
+			return err
+		}
+		if current == hashBytes(expected) {
+			return nil
+		}
+		// Only update files that are unchanged since they were created.
+		if current != hashes[rel] {
+			edited++
+			report(syncEdited, rel)
+			return nil
+		}
+		if !apply {
+			report(syncChanged, rel)
+			return nil
+		}
+		if err := shared.WriteFile(n.FullPath, expected, true); err != nil {
+			return err
+		}
+		hashes[rel] = hashBytes(expected)
+		report(syncUpdated, rel)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("project.Project.Sync: %s", err.Error())
+	}
+	if err := p.writeHashes(hashes); err != nil {
+		return "", fmt.Errorf("project.Project.Sync: %s", err.Error())
+	}
+	if edited > 0 {
+		fmt.Fprintf(&sb, "%d file(s) were edited after creation and differ from the template, they are never updated.\n", edited)
+	}
+	// The project is in sync with the new template.
+	if apply {
+		p.ProjectConfig[keyTemplateHash] = newHash
+		if err := p.writeConfig(); err != nil {
+			return "", fmt.Errorf("project.Project.Sync: %s", err.Error())
+		}
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,102 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/parsiya/borrowedtime/config"
+	"github.com/parsiya/borrowedtime/shared"
+)
+
+// setTemplate replaces the content of a file template.
+func setTemplate(t *testing.T, name, content string) {
+	tmpl, err := config.FindTemplate(name, config.FileKind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shared.WriteFileString(tmpl.FullPath, content, true); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// syncLine returns a line of the sync report.
+func syncLine(status, rel string) string {
+	return fmt.Sprintf("%-12s %s\n", "["+status+"]", rel)
+}
+
+func TestSync(t *testing.T) {
+	home := testHome(t)
+	if err := New("acme").Create("project-structure", false); err != nil {
+		t.Fatal(err)
+	}
+	root := filepath.Join(home, "ws", "acme")
+	read := func(name string) string {
+		content, err := shared.ReadFileString(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return content
+	}
+
+	// The user edits notes, both templates change and a file is deleted.
+	if err := shared.WriteFileString(filepath.Join(root, "@notes.md"), "my notes", true); err != nil {
+		t.Fatal(err)
+	}
+	setTemplate(t, "notes", "new notes")
+	setTemplate(t, "todo", "new todo")
+	if err := os.Remove(filepath.Join(root, "@scratch-pad.md")); err != nil {
+		t.Fatal(err)
+	}
+	todo := read("@TODO.md")
+
+	p, err := Load("acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := p.Sync(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		syncLine(syncEdited, "@notes.md"),
+		syncLine(syncChanged, "@TODO.md"),
+		syncLine(syncCreated, "@scratch-pad.md"),
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Sync(false) output does not contain %q:\n%s", want, out)
+		}
+	}
+	if got := read("@TODO.md"); got != todo {
+		t.Errorf("Sync(false) updated @TODO.md to %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(root, "@scratch-pad.md")); err != nil {
+		t.Errorf("Sync(false) did not create @scratch-pad.md - %v", err)
+	}
+
+	out, err = p.Sync(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, syncLine(syncUpdated, "@TODO.md")) || !strings.Contains(out, syncLine(syncEdited, "@notes.md")) {
+		t.Errorf("Sync(true) output:\n%s", out)
+	}
+	if got := read("@TODO.md"); got != "new todo" {
+		t.Errorf("Sync(true) @TODO.md = %q, want %q", got, "new todo")
+	}
+	// Edited files are never overwritten.
+	if got := read("@notes.md"); got != "my notes" {
+		t.Errorf("Sync(true) overwrote the edited @notes.md with %q", got)
+	}
+
+	// Nothing is left to update.
+	out, err = p.Sync(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "["+syncUpdated+"]") || strings.Contains(out, "["+syncChanged+"]") {
+		t.Errorf("second Sync(true) output:\n%s", out)
+	}
+}
@@ -0,0 +1,273 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parsiya/borrowedtime/config"
+	"github.com/parsiya/borrowedtime/shared"
+)
+
+const (
+	// keyTrashDays is the number of days deleted projects are kept in the
+	// trash. 0 keeps them until the trash is emptied.
+	keyTrashDays = "trashdays"
+	// defaultTrashDays is used if trashdays is not in the workspace config.
+	defaultTrashDays = 30
+	// trashIDFormat is the timestamp at the start of trash IDs.
+	trashIDFormat = "20060102-150405"
+)
+
+// TrashItem is a deleted project. The project is moved to "trash/ID" and the
+// item is stored in "trash/ID.json".
+type TrashItem struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Original is the path of the project when it was deleted.
+	Original string `json:"original"`
+	// Deleted is the deletion date.
+	Deleted string `json:"deleted"`
+	// Entry is the registry entry of the project.
+	Entry RegistryEntry `json:"entry"`
+}
+
+// deletedAt returns the deletion time.
+func (t TrashItem) deletedAt() (time.Time, error) {
+	return time.ParseInLocation(archiveTimeFormat, t.Deleted, time.Local)
+}
+
+// Delete moves the project to the trash and removes it from the registry.
+// Expired items are purged from the trash first.
+func (p *Project) Delete() (*TrashItem, error) {
+	if _, err := PurgeTrash(); err != nil {
+		return nil, fmt.Errorf("project.Project.Delete: %s", err.Error())
+	}
+	dir, err := config.TrashDir()
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.Delete: %s", err.Error())
+	}
+	now := time.Now()
+	item := &TrashItem{
+		ID:       now.Format(trashIDFormat) + "-" + p.ProjectName,
+		Name:     p.ProjectName,
+		Original: p.Root(),
+		Deleted:  now.Format(archiveTimeFormat),
+		Entry:    p.entry(),
+	}
+	// Deleting the same project twice in a second.
+	for i := 2; ; i++ {
+		exists, err := shared.PathExists(filepath.Join(dir, item.ID))
+		if err != nil {
+			return nil, fmt.Errorf("project.Project.Delete: %s", err.Error())
+		}
+		if !exists {
+			break
+		}
+		item.ID = fmt.Sprintf("%s-%s-%d", now.Format(trashIDFormat), p.ProjectName, i)
+	}
+
+	// The record is written first so a project copied to the trash from
+	// another volume can always be restored.
+	trashPath := filepath.Join(dir, item.ID)
+	content, err := shared.StructToJSONString(item, true)
+	if err != nil {
+		return nil, fmt.Errorf("project.Project.Delete: %s", err.Error())
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("project.Project.Delete: %s", err.Error())
+	}
+	if err := shared.WriteFileString(trashPath+".json", content, false); err != nil {
+		return nil, fmt.Errorf("project.Project.Delete: %s", err.Error())
+	}
+	if err := shared.MoveDir(item.Original, trashPath); err != nil {
+		os.Remove(trashPath + ".json")
+		return nil, fmt.Errorf("project.Project.Delete: %s", err.Error())
+	}
+	if err := p.unregister(); err != nil {
+		return nil, fmt.Errorf("project.Project.Delete: %s", err.Error())
+	}
+	return item, nil
+}
+
+// TrashItems returns the items in the trash, most recently deleted first.
+func TrashItems() ([]TrashItem, error) {
+	dir, err := config.TrashDir()
+	if err != nil {
+		return nil, fmt.Errorf("project.TrashItems: %s", err.Error())
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("project.TrashItems: %s", err.Error())
+	}
+	var items []TrashItem
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+		content, err := shared.ReadFileByte(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("project.TrashItems: %s", err.Error())
+		}
+		var item TrashItem
+		if err := json.Unmarshal(content, &item); err != nil {
+			return nil, fmt.Errorf("project.TrashItems: unmarshal %s - %s", f.Name(), err.Error())
+		}
+		items = append(items, item)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Deleted == items[j].Deleted {
+			return items[i].ID > items[j].ID
+		}
+		return items[i].Deleted > items[j].Deleted
+	})
+	return items, nil
+}
+
+// findTrashItem returns the item with the ID or the most recently deleted
+// project with the name.
+func findTrashItem(nameOrID string) (*TrashItem, error) {
+	items, err := TrashItems()
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if item.ID == nameOrID {
+			return &item, nil
+		}
+	}
+	for _, item := range items {
+		if item.Name == nameOrID {
+			return &item, nil
+		}
+	}
+	return nil, fmt.Errorf("%s is not in the trash", nameOrID)
+}
+
+// RestoreTrash moves a deleted project back to its original path. nameOrID is
+// the ID of the item or the project name. If the name was deleted more than
+// once, the most recent one is restored.
+func RestoreTrash(nameOrID string) (*TrashItem, error) {
+	item, err := findTrashItem(nameOrID)
+	if err != nil {
+		return nil, fmt.Errorf("project.RestoreTrash: %s", err.Error())
+	}
+	exists, err := shared.PathExists(item.Original)
+	if err != nil {
+		return nil, fmt.Errorf("project.RestoreTrash: %s", err.Error())
+	}
+	if exists {
+		return nil, fmt.Errorf("project.RestoreTrash: %s already exists", item.Original)
+	}
+	dir, err := config.TrashDir()
+	if err != nil {
+		return nil, fmt.Errorf("project.RestoreTrash: %s", err.Error())
+	}
+	trashPath := filepath.Join(dir, item.ID)
+	if err := shared.MoveDir(trashPath, item.Original); err != nil {
+		return nil, fmt.Errorf("project.RestoreTrash: %s", err.Error())
+	}
+	if err := os.Remove(trashPath + ".json"); err != nil {
+		return nil, fmt.Errorf("project.RestoreTrash: %s", err.Error())
+	}
+	// Projects outside of the current workspace are not in the registry.
+	if p, err := Load(item.Name); err == nil && filepath.Clean(p.Root()) == filepath.Clean(item.Original) {
+		if err := p.register(); err != nil {
+			return nil, fmt.Errorf("project.RestoreTrash: %s", err.Error())
+		}
+	}
+	return item, nil
+}
+
+// EmptyTrash permanently deletes every item in the trash and returns the
+// number of deleted items.
+func EmptyTrash() (int, error) {
+	items, err := TrashItems()
+	if err != nil {
+		return 0, fmt.Errorf("project.EmptyTrash: %s", err.Error())
+	}
+	for i, item := range items {
+		if err := removeTrashItem(item); err != nil {
+			return i, fmt.Errorf("project.EmptyTrash: %s", err.Error())
+		}
+	}
+	return len(items), nil
+}
+
+// PurgeTrash permanently deletes the items that were deleted more than
+// trashdays days ago and returns the number of deleted items.
+func PurgeTrash() (int, error) {
+	cfg, err := config.Read()
+	if err != nil {
+		return 0, fmt.Errorf("project.PurgeTrash: %s", err.Error())
+	}
+	days, err := trashDays(cfg.Key(keyTrashDays))
+	if err != nil {
+		return 0, fmt.Errorf("project.PurgeTrash: %s", err.Error())
+	}
+	if days == 0 {
+		return 0, nil
+	}
+	items, err := TrashItems()
+	if err != nil {
+		return 0, fmt.Errorf("project.PurgeTrash: %s", err.Error())
+	}
+	n := 0
+	for _, item := range expiredTrash(items, days, time.Now()) {
+		if err := removeTrashItem(item); err != nil {
+			return n, fmt.Errorf("project.PurgeTrash: %s", err.Error())
+		}
+		n++
+	}
+	return n, nil
+}
+
+// trashDays parses trashdays. An empty value returns the default.
+func trashDays(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return defaultTrashDays, nil
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 0 {
+		return 0, fmt.Errorf("invalid %s %q, use a number of days", keyTrashDays, value)
+	}
+	return days, nil
+}
+
+// expiredTrash returns the items that were deleted more than days before now.
+// Items with an invalid date are kept.
+func expiredTrash(items []TrashItem, days int, now time.Time) []TrashItem {
+	var expired []TrashItem
+	for _, item := range items {
+		deleted, err := item.deletedAt()
+		if err != nil {
+			continue
+		}
+		if now.Sub(deleted) > time.Duration(days)*24*time.Hour {
+			expired = append(expired, item)
+		}
+	}
+	return expired
+}
+
+// removeTrashItem permanently deletes an item from the trash.
+func removeTrashItem(item TrashItem) error {
+	dir, err := config.TrashDir()
+	if err != nil {
+		return err
+	}
+	if err := shared.DeletePath(filepath.Join(dir, item.ID)); err != nil {
+		return err
+	}
+	return os.Remove(filepath.Join(dir, item.ID+".json"))
+}
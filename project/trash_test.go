@@ -0,0 +1,43 @@
+package project
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrashDays(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{"", defaultTrashDays, false},
+		{" 7 ", 7, false},
+		{"0", 0, false},
+		{"-1", 0, true},
+		{"week", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := trashDays(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("trashDays(%q) = %d, %v, want %d, wantErr %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestExpiredTrash(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.Local)
+	items := []TrashItem{
+		{ID: "old", Deleted: "2026-03-01 11:59:59"},
+		{ID: "boundary", Deleted: "2026-03-01 12:00:00"},
+		{ID: "new", Deleted: "2026-03-31 08:00:00"},
+		{ID: "invalid", Deleted: "yesterday"},
+	}
+	got := expiredTrash(items, 30, now)
+	if len(got) != 1 || got[0].ID != "old" {
+		t.Errorf("expiredTrash() = %v, want only old", got)
+	}
+	if got := expiredTrash(items, 1, now); len(got) != 2 {
+		t.Errorf("expiredTrash() with 1 day = %v, want old and boundary", got)
+	}
+}
@@ -0,0 +1,66 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/parsiya/borrowedtime/config"
+)
+
+// testHome deploys the config to a temporary home directory and sets the
+// workspace to "home/ws".
+func testHome(t *testing.T) string {
+	home := t.TempDir()
+	old, cache := os.Getenv("HOME"), homedir.DisableCache
+	os.Setenv("HOME", home)
+	homedir.DisableCache = true
+	t.Cleanup(func() {
+		os.Setenv("HOME", old)
+		// Dir caches the temporary home even if the cache is disabled.
+		homedir.Dir()
+		homedir.DisableCache = cache
+	})
+	if err := config.Deploy(); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Set("workspace", filepath.ToSlash(filepath.Join(home, "ws")))
+	if err := config.Write(cfg); err != nil {
+		t.Fatal(err)
+	}
+	return home
+}
+
+func TestDeployCreate(t *testing.T) {
+	home := testHome(t)
+	p := New("acme")
+	if err := p.Create("project-structure", false); err != nil {
+		t.Fatal(err)
+	}
+	root := filepath.Join(home, "ws", "acme")
+	for _, name := range []string{configFilename, "@notes.md", "@creds.md", "@pix", "@report/report.json"} {
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(name))); err != nil {
+			t.Errorf("Create() did not create %s - %v", name, err)
+		}
+	}
+
+	r, err := RefreshRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.Projects["acme"]; !ok || len(r.Projects) != 1 {
+		t.Errorf("RefreshRegistry() projects = %v, want acme", r.Projects)
+	}
+	loaded, err := Load("acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.ProjectConfig[keyTemplate] != "project-structure" {
+		t.Errorf("Load() template = %q, want project-structure", loaded.ProjectConfig[keyTemplate])
+	}
+}
@@ -0,0 +1,82 @@
+package shared
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Diff returns a line diff from a to b. Lines only in a start with "-", lines
+// only in b start with "+" and unchanged lines start with " ". Only context
+// unchanged lines around each change are included and hunks are separated by
+// "@@" lines with the line numbers in a and b. Returns "" if a and b are equal.
+func Diff(a, b string, context int) string {
+	if a == b {
+		return ""
+	}
+	al, bl := strings.Split(a, "\n"), strings.Split(b, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of al[i:] and
+	// bl[j:].
+	lcs := make([][]int, len(al)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bl)+1)
+	}
+	for i := len(al) - 1; i >= 0; i-- {
+		for j := len(bl) - 1; j >= 0; j-- {
+			if al[i] == bl[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	// Each line of the diff and its line number in a and b.
+	type line struct {
+		op     byte
+		text   string
+		ai, bi int
+	}
+	var lines []line
+	i, j := 0, 0
+	for i < len(al) || j < len(bl) {
+		switch {
+		case i < len(al) && j < len(bl) && al[i] == bl[j]:
+			lines = append(lines, line{' ', al[i], i, j})
+			i++
+			j++
+		case i < len(al) && (j == len(bl) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, line{'-', al[i], i, j})
+			i++
+		default:
+			lines = append(lines, line{'+', bl[j], i, j})
+			j++
+		}
+	}
+
+	// Keep the changes and context lines around them.
+	keep := make([]bool, len(lines))
+	for k, l := range lines {
+		if l.op == ' ' {
+			continue
+		}
+		for c := k - context; c <= k+context; c++ {
+			if c >= 0 && c < len(lines) {
+				keep[c] = true
+			}
+		}
+	}
+	var sb strings.Builder
+	for k, l := range lines {
+		if !keep[k] {
+			continue
+		}
+		if k == 0 || !keep[k-1] {
+			fmt.Fprintf(&sb, "@@ -%d +%d @@\n", l.ai+1, l.bi+1)
+		}
+		fmt.Fprintf(&sb, "%c%s\n", l.op, l.text)
+	}
+	return sb.String()
+}
@@ -0,0 +1,31 @@
+package shared
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	type args struct {
+		a       string
+		b       string
+		context int
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{"equal", args{"a\nb\n", "a\nb\n", 1}, ""},
+		{"change", args{"a\nb\nc\n", "a\nx\nc\n", 1}, "@@ -1 +1 @@\n a\n-b\n+x\n c\n"},
+		{"add", args{"a\nc", "a\nb\nc", 0}, "@@ -2 +2 @@\n+b\n"},
+		{"remove", args{"a\nb\nc", "a\nc", 0}, "@@ -2 +2 @@\n-b\n"},
+		{"two-hunks", args{"1\n2\n3\n4\n5\n6\n7", "x\n2\n3\n4\n5\n6\ny", 1},
+			"@@ -1 +1 @@\n-1\n+x\n 2\n@@ -6 +6 @@\n 6\n-7\n+y\n"},
+		{"trailing-newline", args{"a", "a\n", 0}, "@@ -2 +2 @@\n+\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.args.a, tt.args.b, tt.args.context); got != tt.want {
+				t.Errorf("Diff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,11 +2,14 @@
 package shared
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"runtime"
+	"syscall"
 
 	homedir "github.com/mitchellh/go-homedir"
 )
@@ -76,6 +79,95 @@ func WriteFileString(file, content string, overwrite bool) error {
 	return WriteFile(file, []byte(content), overwrite)
 }
 
+// CopyFile copies src to dst and preserves the file mode. dst is overwritten.
+func CopyFile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("shared.CopyFile: %s", err.Error())
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("shared.CopyFile: open source - %s", err.Error())
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("shared.CopyFile: create destination - %s", err.Error())
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("shared.CopyFile: copy - %s", err.Error())
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("shared.CopyFile: %s", err.Error())
+	}
+	// OpenFile does not change the mode of existing files.
+	return os.Chmod(dst, info.Mode().Perm())
+}
+
+// MoveDir moves the src directory to dst. If they are on different volumes,
+// src is copied and then removed. Symbolic links are copied as links.
+func MoveDir(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return fmt.Errorf("shared.MoveDir: %s", err.Error())
+	}
+	if _, err := os.Lstat(dst); err == nil {
+		return fmt.Errorf("shared.MoveDir: %s already exists", dst)
+	}
+	err := os.Rename(src, dst)
+	if err == nil {
+		return nil
+	}
+	// Rename does not work across volumes.
+	if !crossDevice(err) {
+		return fmt.Errorf("shared.MoveDir: %s", err.Error())
+	}
+	err = filepath.Walk(src, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, pth)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(pth)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		default:
+			return CopyFile(pth, target)
+		}
+	})
+	if err != nil {
+		os.RemoveAll(dst)
+		return fmt.Errorf("shared.MoveDir: copy - %s", err.Error())
+	}
+	if err := os.RemoveAll(src); err != nil {
+		return fmt.Errorf("shared.MoveDir: remove source - %s", err.Error())
+	}
+	return nil
+}
+
+// errNotSameDevice is ERROR_NOT_SAME_DEVICE. It is returned on Windows when a
+// file is moved to a different drive.
+const errNotSameDevice = syscall.Errno(0x11)
+
+// crossDevice returns true if err is returned by os.Rename because src and dst
+// are on different volumes.
+func crossDevice(err error) bool {
+	if runtime.GOOS == "windows" {
+		return errors.Is(err, errNotSameDevice)
+	}
+	return errors.Is(err, syscall.EXDEV)
+}
+
 // HomeDir calls homedir.Dir() but changes the backslashes with forwardslashes
 // on Windows.
 func HomeDir() (string, error) {
@@ -131,9 +223,11 @@ func ListFiles(root, pattern string) (files []string, err error) {
 	}
 
 	err = filepath.Walk(root, func(file string, info os.FileInfo, walkErr error) error {
-		// Convert file path to /, otherwise match will not work (for some reason).
-		file = filepath.ToSlash(file)
-		match, matchErr := filepath.Match(pattern, file)
+		if walkErr != nil {
+			return walkErr
+		}
+		// Match the name, "*" does not match the separators in the path.
+		match, matchErr := filepath.Match(pattern, filepath.Base(file))
 		if matchErr != nil {
 			return fmt.Errorf("shared.ListFiles: match error %s", matchErr.Error())
 		}
@@ -1,6 +1,12 @@
 package shared
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"syscall"
 	"testing"
 )
 
@@ -53,3 +59,103 @@ func TestAddExtension(t *testing.T) {
 		})
 	}
 }
+
+func TestCopyFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "copyfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src.bin")
+	content := []byte{0x89, 'P', 'N', 'G', 0x00, 0x0d, 0x0a}
+	if err := ioutil.WriteFile(src, content, 0700); err != nil {
+		t.Fatal(err)
+	}
+	// Existing destination files are overwritten.
+	dst := filepath.Join(dir, "dst.bin")
+	if err := ioutil.WriteFile(dst, []byte("old content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CopyFile(src, dst); err != nil {
+		t.Fatalf("CopyFile() error = %v", err)
+	}
+	got, err := ioutil.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(content) {
+		t.Errorf("CopyFile() content = %v, want %v", got, content)
+	}
+	// Windows does not support Unix permissions.
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0700 {
+			t.Errorf("CopyFile() mode = %v, want %v", info.Mode().Perm(), os.FileMode(0700))
+		}
+	}
+	if err := CopyFile(filepath.Join(dir, "missing"), dst); err == nil {
+		t.Errorf("CopyFile() with missing source did not return an error")
+	}
+}
+
+func TestListFiles(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.md", "b.json", "sub/c.md", "sub/deep/d.md"} {
+		pth := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(pth), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(pth, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"*", []string{"a.md", "b.json", "sub/c.md", "sub/deep/d.md"}},
+		{"*.md", []string{"a.md", "sub/c.md", "sub/deep/d.md"}},
+		{"*.txt", nil},
+	}
+	for _, tt := range tests {
+		got, err := ListFiles(root, tt.pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range got {
+			got[i] = filepath.ToSlash(got[i])
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ListFiles(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+	if _, err := ListFiles(filepath.Join(root, "missing"), "*"); err == nil {
+		t.Error("ListFiles() with a missing root did not return an error")
+	}
+}
+
+func TestCrossDevice(t *testing.T) {
+	crossErr := syscall.EXDEV
+	if runtime.GOOS == "windows" {
+		crossErr = errNotSameDevice
+	}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"different volume", &os.LinkError{Op: "rename", Old: "a", New: "b", Err: crossErr}, true},
+		{"missing source", &os.LinkError{Op: "rename", Old: "a", New: "b", Err: syscall.ENOENT}, false},
+		{"permission", &os.LinkError{Op: "rename", Old: "a", New: "b", Err: os.ErrPermission}, false},
+	}
+	for _, tt := range tests {
+		if got := crossDevice(tt.err); got != tt.want {
+			t.Errorf("crossDevice(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}